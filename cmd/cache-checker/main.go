@@ -0,0 +1,123 @@
+// cache-checker сверяет копии заказов в Redis (ключи order:*) с Postgres, печатает отчет
+// в JSON и, если разрешено флагами, исправляет расхождения. Скорость проверки ограничена
+// флагом -rate, поэтому его можно запускать на рабочем окружении.
+//
+//	go run ./cmd/cache-checker -rate 200 -repair -delete-orphans
+//
+// Код выхода 1 — проверка не завершилась, 2 — в кэше остались расхождения.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/zhavkk/order-service/internal/app"
+	"github.com/zhavkk/order-service/internal/config"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+	"github.com/zhavkk/order-service/internal/service"
+	"github.com/zhavkk/order-service/pkg/cache"
+	"github.com/zhavkk/order-service/pkg/cache/codec"
+	lrucache "github.com/zhavkk/order-service/pkg/cache/lru"
+	rediscache "github.com/zhavkk/order-service/pkg/cache/redis"
+	tieredcache "github.com/zhavkk/order-service/pkg/cache/tiered"
+	"github.com/zhavkk/order-service/pkg/pgstorage"
+	"github.com/zhavkk/order-service/pkg/utils"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	var (
+		configPath = flag.String("config", "config/config.yml", "path to the service config")
+		opts       service.CacheCheckOptions
+	)
+	flag.Float64Var(&opts.Rate, "rate", 100, "keys checked per second, 0 for no limit")
+	flag.Int64Var(&opts.ScanCount, "count", 100, "SCAN COUNT hint")
+	flag.IntVar(&opts.Limit, "limit", 0, "maximum number of keys to check, 0 for all")
+	flag.BoolVar(&opts.Repair, "repair", false, "delete stale and unreadable entries")
+	flag.BoolVar(&opts.DeleteOrphans, "delete-orphans", false, "delete entries of orders missing in Postgres")
+	flag.Parse()
+
+	cfg := config.MustLoad(*configPath)
+	logger.Init(cfg.Env)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	storage, err := pgstorage.NewStorage(ctx, cfg)
+	if err != nil {
+		logger.Log.Error("Failed to connect to PostgreSQL", "error", err)
+		return 1
+	}
+	defer func() {
+		if err := storage.Close(); err != nil {
+			logger.Log.Error("Failed to close PostgreSQL storage", "error", err)
+		}
+	}()
+
+	redisClient, err := app.NewRedisClient(cfg.Redis)
+	if err != nil {
+		logger.Log.Error("Invalid redis config", "error", err)
+		return 1
+	}
+	defer func() {
+		if err := redisClient.Close(); err != nil {
+			logger.Log.Error("Failed to close Redis client", "error", err)
+		}
+	}()
+
+	redisCache := rediscache.NewClient(redisClient, logger.Log)
+	cacheFormat, err := codec.NewFormat(cfg.Redis.Codec, cfg.Redis.Compression, cfg.Redis.CompressionThreshold)
+	if err != nil {
+		logger.Log.Error("Invalid cache format config", "error", err)
+		return 1
+	}
+	redisCache.SetFormat(cacheFormat)
+	if err := redisCache.Ping(ctx); err != nil {
+		logger.Log.Error("Redis is unavailable", "error", err)
+		return 1
+	}
+
+	// Исправления рассылаются инстансам сервиса, чтобы они сбросили локальные копии.
+	var orderCache cache.Cache = redisCache
+	if cfg.Redis.Local.Enabled {
+		orderCache = tieredcache.New(
+			lrucache.New(cfg.Redis.Local.Size, cfg.Redis.Local.TTL),
+			redisCache,
+			rediscache.NewInvalidationBus(redisClient, cfg.Redis.Local.Channel),
+			logger.Log,
+		)
+	}
+
+	retryDB := utils.NewRetryPolicy(
+		cfg.Postgres.Retries, cfg.Postgres.Backoff, cfg.Postgres.MaxBackoff, pgstorage.IsRetryable,
+	)
+	// Проверке нужны только чтение заказов и кэш.
+	orderService := service.NewOrderService(
+		postgres.NewOrderRepository(storage, retryDB), nil, nil, nil, nil, nil, nil, orderCache, cfg.Redis.TTL,
+	)
+
+	report, err := orderService.CheckCache(ctx, redisCache, opts)
+	if report != nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			logger.Log.Error("Failed to write report", "error", err)
+		}
+	}
+	if err != nil {
+		logger.Log.Error("Cache check failed", "error", err)
+		return 1
+	}
+	if report.Unresolved() > 0 {
+		return 2
+	}
+	return 0
+}
@@ -27,6 +27,11 @@ import (
 
 // @host localhost:8080
 // @BasePath /
+
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description Токен админского API в виде «Bearer <токен>».
 func main() {
 	cfg := config.MustLoad("config/config.yml")
 
@@ -93,9 +93,9 @@ func generateRandomOrder() models.Order {
 				Price:       1000,
 				Rid:         uuid.NewString(),
 				Name:        "Test Item",
-				Sale:        0,
+				Sale:        10,
 				Size:        "M",
-				TotalPrice:  1000,
+				TotalPrice:  900,
 				NmId:        2389212,
 				Brand:       "Test Brand",
 				Status:      1,
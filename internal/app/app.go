@@ -2,27 +2,50 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"time"
 
+	"github.com/IBM/sarama"
 	"github.com/go-chi/chi"
 	"github.com/redis/go-redis/v9"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"github.com/zhavkk/order-service/internal/app/consumer"
 	httpapp "github.com/zhavkk/order-service/internal/app/http"
+	"github.com/zhavkk/order-service/internal/app/outbox"
+	"github.com/zhavkk/order-service/internal/apperrors"
 	"github.com/zhavkk/order-service/internal/config"
 	"github.com/zhavkk/order-service/internal/handler"
 	"github.com/zhavkk/order-service/internal/logger"
+	metricsmw "github.com/zhavkk/order-service/internal/middleware"
 	"github.com/zhavkk/order-service/internal/repository/postgres"
 	"github.com/zhavkk/order-service/internal/service"
+	"github.com/zhavkk/order-service/pkg/cache"
+	cachebreaker "github.com/zhavkk/order-service/pkg/cache/breaker"
+	"github.com/zhavkk/order-service/pkg/cache/codec"
+	lrucache "github.com/zhavkk/order-service/pkg/cache/lru"
 	rediscache "github.com/zhavkk/order-service/pkg/cache/redis"
+	tieredcache "github.com/zhavkk/order-service/pkg/cache/tiered"
 	kafkapkg "github.com/zhavkk/order-service/pkg/kafka/consumer"
+	kafkaproducer "github.com/zhavkk/order-service/pkg/kafka/producer"
 	prometheusmetrics "github.com/zhavkk/order-service/pkg/metrics/prometheus"
 	"github.com/zhavkk/order-service/pkg/pgstorage"
+	"github.com/zhavkk/order-service/pkg/utils"
 )
 
 type App struct {
-	httpApp *httpapp.HTTPApp
+	httpApp        *httpapp.HTTPApp
+	kafkaConsumer  *consumer.KafkaConsumer
+	statusConsumer *consumer.KafkaConsumer
+	outboxRelay    *outbox.Relay
+	accessTracker  *service.AccessTracker
+	idempotency    *service.IdempotencyStore
+	redisClient    redis.UniversalClient
+	cacheBreaker   *cachebreaker.Cache
+	tieredCache    *tieredcache.Cache
+	storage        *pgstorage.Storage
+	txManager      *pgstorage.TxManager
 }
 
 func NewApp(ctx context.Context, cfg *config.Config) (*App, error) {
@@ -39,73 +62,197 @@ func NewApp(ctx context.Context, cfg *config.Config) (*App, error) {
 		logger.Log.Error("Failed to connect to PostgreSQL", "error", err)
 		return nil, err
 	}
-	redisClient := redis.NewClient(
-		&redis.Options{
-			Addr: cfg.Redis.Addr(),
-			DB:   cfg.Redis.Db,
-		},
-	)
-	cache, err := rediscache.NewClient(redisClient, logger.Log)
+	redisClient, err := NewRedisClient(cfg.Redis)
+	if err != nil {
+		logger.Log.Error("Invalid redis config", "error", err)
+		return nil, err
+	}
+	redisCache := rediscache.NewClient(redisClient, logger.Log)
+	cacheFormat, err := codec.NewFormat(cfg.Redis.Codec, cfg.Redis.Compression, cfg.Redis.CompressionThreshold)
 	if err != nil {
-		logger.Log.Error("Failed to create Redis cache client", "error", err)
+		logger.Log.Error("Invalid cache format config", "error", err)
 		return nil, err
 	}
+	redisCache.SetFormat(cacheFormat)
+	// Без Redis сервис продолжает принимать и отдавать заказы напрямую из Postgres.
+	cacheBreaker := cachebreaker.New(redisCache, redisCache.Ping, cachebreaker.Options{
+		FailureThreshold: cfg.Redis.Breaker.FailureThreshold,
+		ProbeInterval:    cfg.Redis.Breaker.ProbeInterval,
+	}, logger.Log)
+	go func() {
+		if err := cacheBreaker.Run(ctx); err != nil {
+			logger.Log.Error("Cache health probe stopped", "error", err)
+		}
+	}()
+	var (
+		orderCache  cache.Cache = cacheBreaker
+		tieredCache *tieredcache.Cache
+	)
+	if cfg.Redis.Local.Enabled {
+		tieredCache = tieredcache.New(
+			lrucache.New(cfg.Redis.Local.Size, cfg.Redis.Local.TTL),
+			cacheBreaker,
+			rediscache.NewInvalidationBus(redisClient, cfg.Redis.Local.Channel),
+			logger.Log,
+		)
+		go func() {
+			if err := tieredCache.Run(ctx); err != nil {
+				logger.Log.Error("Cache invalidation listener stopped", "error", err)
+			}
+		}()
+		orderCache = tieredCache
+	}
 
 	cacheTTL := cfg.Redis.TTL
 
-	retriesDB := cfg.Postgres.Retries
-	backoffDB := cfg.Postgres.Backoff
+	retryDB := utils.NewRetryPolicy(
+		cfg.Postgres.Retries, cfg.Postgres.Backoff, cfg.Postgres.MaxBackoff, pgstorage.IsRetryable,
+	)
 
-	orderRepo := postgres.NewOrderRepository(postgresStorage, retriesDB, backoffDB)
-	itemsRepo := postgres.NewItemRepository(postgresStorage, retriesDB, backoffDB)
-	paymentRepo := postgres.NewPaymentRepository(postgresStorage, retriesDB, backoffDB)
-	deliveryRepo := postgres.NewDeliveryRepository(postgresStorage, retriesDB, backoffDB)
+	orderRepo := postgres.NewOrderRepository(postgresStorage, retryDB)
+	itemsRepo := postgres.NewItemRepository(postgresStorage, retryDB)
+	paymentRepo := postgres.NewPaymentRepository(postgresStorage, retryDB)
+	deliveryRepo := postgres.NewDeliveryRepository(postgresStorage, retryDB)
+	outboxRepo := postgres.NewOutboxRepository(postgresStorage)
+	historyRepo := postgres.NewHistoryRepository(postgresStorage)
+	accessRepo := postgres.NewAccessRepository(postgresStorage)
+	idempotencyRepo := postgres.NewIdempotencyRepository(postgresStorage)
 
-	orderService := service.NewOrderService(orderRepo, deliveryRepo, paymentRepo, itemsRepo, txManager, cache, cacheTTL)
+	orderService := service.NewOrderService(
+		orderRepo, deliveryRepo, paymentRepo, itemsRepo, outboxRepo, historyRepo, txManager, orderCache, cacheTTL,
+	)
+	conflictPolicy, err := service.ParseConflictPolicy(cfg.Orders.ConflictPolicy)
+	if err != nil {
+		logger.Log.Error("Invalid order conflict policy", "error", err)
+		return nil, err
+	}
+	orderService.SetConflictPolicy(conflictPolicy)
+	orderValidator, err := newOrderValidator(cfg.Orders.Validation)
+	if err != nil {
+		logger.Log.Error("Invalid order validation config", "error", err)
+		return nil, err
+	}
+	orderService.SetOrderValidator(orderValidator)
+	orderService.SetLookupOptions(service.LookupOptions{
+		NegativeTTL:      cfg.Redis.NegativeTTL,
+		EarlyRefreshBeta: cfg.Redis.EarlyRefreshBeta,
+	})
 
+	accessTracker := service.NewAccessTracker(
+		accessRepo, cfg.WarmUp.AccessFlushInterval, cfg.WarmUp.AccessRetention, cfg.WarmUp.AccessMaxPending,
+	)
+	orderService.SetAccessTracker(accessTracker)
 	go func() {
-		warmUpCTX, cancel := context.WithTimeout(ctx, 30*time.Second)
+		if err := accessTracker.Run(ctx); err != nil {
+			logger.Log.Error("Access tracker stopped", "error", err)
+		}
+	}()
+
+	idempotency := service.NewIdempotencyStore(idempotencyRepo, cfg.Orders.Ingest.IdempotencyTTL)
+	orderService.SetIdempotencyStore(idempotency)
+	go func() {
+		if err := idempotency.Run(ctx); err != nil {
+			logger.Log.Error("Idempotency key cleanup stopped", "error", err)
+		}
+	}()
+
+	warmUpStrategy, err := service.ParseWarmUpStrategy(cfg.WarmUp.Strategy)
+	if err != nil {
+		logger.Log.Error("Invalid cache warm-up config", "error", err)
+		return nil, err
+	}
+	orderService.SetWarmUpOptions(service.WarmUpOptions{
+		Strategy:       warmUpStrategy,
+		Limit:          cfg.WarmUp.Limit,
+		Window:         cfg.WarmUp.Window,
+		Days:           cfg.WarmUp.Days,
+		Concurrency:    cfg.WarmUp.Concurrency,
+		BatchSize:      cfg.WarmUp.BatchSize,
+		ReadyThreshold: cfg.WarmUp.ReadyThreshold,
+		Timeout:        cfg.WarmUp.Timeout,
+	})
+
+	prometheusmetrics.Init()
+
+	go func() {
+		warmUpCTX, cancel := context.WithTimeout(ctx, cfg.WarmUp.Timeout)
 		defer cancel()
 		if err := orderService.WarmUpCache(warmUpCTX); err != nil {
 			logger.Log.Error("Failed to warm up cache", "error", err)
+			return
 		}
 
 		logger.Log.Info("Cache warmed up successfully")
 	}()
 
+	ingestOpts := handler.IngestOptions{
+		MaxBodyBytes: cfg.Orders.Ingest.MaxBodyBytes,
+		MaxBatchSize: cfg.Orders.Ingest.MaxBatchSize,
+	}
 	handler := handler.NewHandler(orderService)
+	handler.SetIngestOptions(ingestOpts)
 	router := httpapp.SetupRouter()
 
 	httpApp := httpapp.New(cfg, router)
 
-	handler.RegisterRoutes(router)
-
-	prometheusmetrics.Init()
-
-	addSystemRoutes(router)
+	// Чтение заказов недоступно, пока кэш не прогрет до warmup.ready_threshold;
+	// прием заказов и смена статуса работают сразу.
+	router.Group(func(r chi.Router) {
+		r.Use(metricsmw.ReadinessGate(orderService.WarmUpReady))
+		handler.RegisterRoutes(r)
+	})
+	handler.RegisterWriteRoutes(router)
 
-	app := &App{
-		httpApp: httpApp,
+	// Админское API не закрыто ReadinessGate: прогрев можно перезапустить и на холодном инстансе.
+	if len(cfg.Admin.Tokens) > 0 {
+		router.Group(func(r chi.Router) {
+			r.Use(metricsmw.AdminAuth(cfg.Admin.Tokens))
+			handler.RegisterAdminRoutes(r)
+		})
+	} else {
+		logger.Log.Warn("Admin API is disabled: no admin tokens configured")
 	}
 
+	addSystemRoutes(router, cacheBreaker.Degraded, orderService.WarmUpProgress)
+
 	saramaCfg, err := kafkapkg.NewSaramaConfig(cfg)
 	if err != nil {
 		logger.Log.Error("Failed to create Sarama config", "error", err)
 		return nil, err
 	}
 
-	retriesKafka := cfg.Kafka.Retries
-	backoffKafka := cfg.Kafka.Backoff
+	retryKafka := utils.NewRetryPolicy(
+		cfg.Kafka.Retries, cfg.Kafka.Backoff, cfg.Kafka.MaxBackoff, apperrors.IsTransient,
+	)
+
+	var dlq *consumer.DeadLetterQueue
+	if cfg.Kafka.DLQTopic != "" {
+		producerCfg, err := kafkaproducer.NewSaramaConfig(cfg)
+		if err != nil {
+			logger.Log.Error("Failed to create Sarama producer config", "error", err)
+			return nil, err
+		}
+		dlqProducer, err := sarama.NewSyncProducer(cfg.Kafka.Brokers, producerCfg)
+		if err != nil {
+			logger.Log.Error("Failed to create dead-letter producer", "error", err)
+			return nil, err
+		}
+		dlq = consumer.NewDeadLetterQueue(dlqProducer, cfg.Kafka.DLQTopic)
+	}
 
 	kafkaConsumer, err := consumer.NewKafkaConsumer(
 		cfg.Kafka.Brokers, cfg.Kafka.OrderTopic,
-		func(msg []byte) error { return orderService.ProcessMessage(ctx, msg) },
-		saramaCfg, cfg.Kafka.GroupID, retriesKafka, backoffKafka,
+		orderService.ProcessMessage,
+		saramaCfg, cfg.Kafka.GroupID, retryKafka, dlq, cfg.Kafka.Workers,
 	)
 
 	if err != nil {
 		return nil, err
 	}
+	kafkaConsumer.EnableBatching(
+		orderService.ProcessMessages,
+		cfg.Kafka.BatchSize, cfg.Kafka.BatchTimeout,
+	)
 
 	go func() {
 		if err := kafkaConsumer.Consume(ctx); err != nil {
@@ -113,9 +260,65 @@ func NewApp(ctx context.Context, cfg *config.Config) (*App, error) {
 		}
 	}()
 
+	var statusConsumer *consumer.KafkaConsumer
+	if cfg.Kafka.StatusTopic != "" {
+		// Отдельная группа, чтобы ребалансы топика статусов не останавливали прием заказов.
+		// Без DLQ: невалидные сообщения и переходы, запрещенные таблицей статусов, пропускаются.
+		statusConsumer, err = consumer.NewKafkaConsumer(
+			cfg.Kafka.Brokers, cfg.Kafka.StatusTopic,
+			orderService.ProcessStatusMessage,
+			saramaCfg, cfg.Kafka.GroupID+"_status", retryKafka, nil, cfg.Kafka.Workers,
+		)
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			if err := statusConsumer.Consume(ctx); err != nil {
+				logger.Log.Error("Kafka status consumer stopped", "error", err)
+			}
+		}()
+	}
+
+	var outboxRelay *outbox.Relay
+	if cfg.Outbox.Topic != "" {
+		producerCfg, err := kafkaproducer.NewSaramaConfig(cfg)
+		if err != nil {
+			logger.Log.Error("Failed to create Sarama producer config", "error", err)
+			return nil, err
+		}
+		outboxProducer, err := sarama.NewSyncProducer(cfg.Kafka.Brokers, producerCfg)
+		if err != nil {
+			logger.Log.Error("Failed to create outbox producer", "error", err)
+			return nil, err
+		}
+		outboxRelay = outbox.NewRelay(
+			outboxRepo, txManager, outbox.NewKafkaPublisher(outboxProducer),
+			cfg.Outbox.Topic, cfg.Outbox.PollInterval, cfg.Outbox.BatchSize,
+		)
+		// Новые события отправляются сразу после коммита, а не на следующем тике.
+		orderService.SetEventNotifier(outboxRelay.Notify)
+		go func() {
+			if err := outboxRelay.Run(ctx); err != nil {
+				logger.Log.Error("Outbox relay stopped", "error", err)
+			}
+		}()
+	}
+
 	logger.Log.Info("Application initialized successfully", "env", cfg.Env)
 
-	return app, nil
+	return &App{
+		httpApp:        httpApp,
+		kafkaConsumer:  kafkaConsumer,
+		statusConsumer: statusConsumer,
+		outboxRelay:    outboxRelay,
+		accessTracker:  accessTracker,
+		idempotency:    idempotency,
+		redisClient:    redisClient,
+		cacheBreaker:   cacheBreaker,
+		tieredCache:    tieredCache,
+		storage:        postgresStorage,
+		txManager:      txManager,
+	}, nil
 }
 
 func (a *App) Run() error {
@@ -123,30 +326,144 @@ func (a *App) Run() error {
 	return a.httpApp.Start()
 }
 
+// Stop останавливает компоненты в порядке, обратном зависимостям: сначала входящий
+// трафик (HTTP, Kafka), затем Redis и пулы Postgres, которые использовали обработчики.
+// Все шаги укладываются в дедлайн ctx; ошибки собираются с именем компонента.
 func (a *App) Stop(ctx context.Context) error {
 	logger.Log.Info("Stopping application")
-	return a.httpApp.Stop(ctx)
+
+	// Дедлайн ctx ограничивает только дренаж: прием запросов и сообщений, досылку outbox и
+	// сброс счетчиков. Соединения закрываются всегда, даже если дедлайн уже истек.
+	drainSteps := []struct {
+		name string
+		stop func(ctx context.Context) error
+	}{
+		{"http server", a.httpApp.Stop},
+		{"kafka consumer", a.kafkaConsumer.Shutdown},
+		{"kafka status consumer", func(ctx context.Context) error {
+			if a.statusConsumer == nil {
+				return nil
+			}
+			return a.statusConsumer.Shutdown(ctx)
+		}},
+		{"outbox relay", func(ctx context.Context) error {
+			if a.outboxRelay == nil {
+				return nil
+			}
+			return a.outboxRelay.Shutdown(ctx)
+		}},
+		{"access tracker", a.accessTracker.Shutdown},
+		{"idempotency key cleanup", a.idempotency.Shutdown},
+	}
+	closeSteps := []struct {
+		name  string
+		close func() error
+	}{
+		{"cache invalidation listener", func() error {
+			if a.tieredCache == nil {
+				return nil
+			}
+			return a.tieredCache.Close()
+		}},
+		{"cache health probe", a.cacheBreaker.Close},
+		{"redis client", a.redisClient.Close},
+		{"postgres storage", a.storage.Close},
+		{"postgres tx manager", a.txManager.Close},
+	}
+
+	var errs []error
+	stopped := func(name string, err error) {
+		if err != nil {
+			logger.Log.Error("Failed to stop component", "component", name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		logger.Log.Info("Component stopped", "component", name)
+	}
+	for _, step := range drainSteps {
+		stopped(step.name, stopWithContext(ctx, step.stop))
+	}
+	for _, step := range closeSteps {
+		stopped(step.name, step.close())
+	}
+
+	return errors.Join(errs...)
 }
 
-func addSystemRoutes(router *chi.Mux) {
+// stopWithContext не дает зависшему компоненту (например, пулу с занятыми соединениями)
+// задержать остановку дольше дедлайна.
+func stopWithContext(ctx context.Context, stop func(ctx context.Context) error) error {
+	done := make(chan error, 1)
+	go func() { done <- stop(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// addSystemRoutes регистрирует служебные маршруты. cacheDegraded сообщает, что Redis
+// недоступен: сервис при этом работает, поэтому /health отвечает 200 со статусом degraded.
+// /ready отвечает 503, пока не прогрет кэш, и отдает состояние прогрева.
+func addSystemRoutes(router *chi.Mux, cacheDegraded func() bool, warmUp func() service.WarmUpProgress) {
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		body := `{"status":"ok","service":"order-service","cache":"ok"}`
+		if cacheDegraded() {
+			body = `{"status":"degraded","service":"order-service","cache":"unavailable"}`
+		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write([]byte(`{"status":"ok","service":"order-service"}`)); err != nil {
+		if _, err := w.Write([]byte(body)); err != nil {
 			logger.Log.Error("Failed to write response", "error", err)
 		}
 	})
 
 	router.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+		if cacheDegraded() {
+			w.Header().Set("X-Service-Status", "degraded")
+		}
 		w.WriteHeader(http.StatusOK)
 		if _, err := w.Write([]byte("pong")); err != nil {
 			logger.Log.Error("Failed to write response", "error", err)
 		}
 	})
 
+	router.Get("/ready", func(w http.ResponseWriter, r *http.Request) {
+		progress := warmUp()
+		status := http.StatusOK
+		if !progress.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(progress); err != nil {
+			logger.Log.Error("Failed to write response", "error", err)
+		}
+	})
+
 	router.Handle("/metrics", prometheusmetrics.Handler())
 	router.Get("/swagger/*", httpSwagger.WrapHandler)
 
 	fileServer := http.FileServer(http.Dir("./internal/web"))
 	router.Handle("/*", fileServer)
 }
+
+func newOrderValidator(cfg config.ValidationConfig) (*service.OrderValidator, error) {
+	mode, err := service.ParseValidationMode(cfg.Mode)
+	if err != nil {
+		return nil, err
+	}
+	v := service.NewOrderValidator(mode, service.DefaultValidationRules()...)
+	for rule, value := range cfg.Rules {
+		ruleMode, err := service.ParseValidationMode(value)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule, err)
+		}
+		if err := v.SetRuleMode(rule, ruleMode); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,90 @@
+package consumer
+
+import (
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/audit"
+	"github.com/zhavkk/order-service/internal/logger"
+)
+
+func (kc *KafkaConsumer) consumeBatches(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	batch := make([]*sarama.ConsumerMessage, 0, kc.batchSize)
+
+	timer := time.NewTimer(kc.batchTimeout)
+	timer.Stop()
+	defer timer.Stop()
+	var deadline <-chan time.Time
+
+	flush := func() error {
+		deadline = nil
+		timer.Stop()
+		err := kc.flushBatch(session, batch)
+		batch = batch[:0]
+		return err
+	}
+
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				if session.Context().Err() != nil {
+					// Сессия завершена: непомеченные сообщения будут перечитаны новым владельцем.
+					return nil
+				}
+				return flush()
+			}
+			batch = append(batch, message)
+			if len(batch) == 1 {
+				timer.Reset(kc.batchTimeout)
+				deadline = timer.C
+			}
+			if len(batch) >= kc.batchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-deadline:
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// flushBatch передает пачку в batchHandler и помечает сообщения по порядку. Временные
+// ошибки повторяются поштучно через handleMessage, остальные сразу идут в handleFailure.
+func (kc *KafkaConsumer) flushBatch(session sarama.ConsumerGroupSession, batch []*sarama.ConsumerMessage) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	values := make([][]byte, len(batch))
+	origins := make([]audit.Origin, len(batch))
+	for i, message := range batch {
+		values[i] = message.Value
+		origins[i] = messageOrigin(message)
+	}
+
+	start := time.Now()
+	errs := kc.batchHandler(session.Context(), values, origins)
+	logger.Log.Info("Batch handled", "partition", batch[0].Partition, "size", len(batch), "duration", time.Since(start))
+
+	for i, message := range batch {
+		if err := errs[i]; err != nil {
+			if apperrors.IsTransient(err) {
+				err = kc.handleMessage(session.Context(), message)
+			} else {
+				err = kc.handleFailure(message, Failure{Err: err, Attempts: 1, FirstFailure: start})
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		session.MarkMessage(message, "")
+	}
+
+	return nil
+}
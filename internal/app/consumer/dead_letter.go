@@ -0,0 +1,99 @@
+package consumer
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/logger"
+	prometheusmetrics "github.com/zhavkk/order-service/pkg/metrics/prometheus"
+)
+
+const (
+	HeaderDLQError           = "x-dlq-error"
+	HeaderDLQErrorKind       = "x-dlq-error-kind"
+	HeaderDLQReason          = "x-dlq-reason"
+	HeaderDLQAttempts        = "x-dlq-attempts"
+	HeaderDLQSourceTopic     = "x-dlq-source-topic"
+	HeaderDLQSourcePartition = "x-dlq-source-partition"
+	HeaderDLQSourceOffset    = "x-dlq-source-offset"
+	HeaderDLQFirstFailure    = "x-dlq-first-failure"
+)
+
+// Failure описывает причину, по которой сообщение уходит в dead-letter топик.
+type Failure struct {
+	Err          error
+	Attempts     int
+	FirstFailure time.Time
+}
+
+type DeadLetterQueue struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+func NewDeadLetterQueue(producer sarama.SyncProducer, topic string) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		producer: producer,
+		topic:    topic,
+	}
+}
+
+func (q *DeadLetterQueue) Publish(message *sarama.ConsumerMessage, failure Failure) error {
+	const op = "DeadLetterQueue.Publish"
+
+	msg := &sarama.ProducerMessage{
+		Topic:   q.topic,
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: dlqHeaders(message, failure),
+	}
+	if message.Key != nil {
+		msg.Key = sarama.ByteEncoder(message.Key)
+	}
+
+	partition, offset, err := q.producer.SendMessage(msg)
+	if err != nil {
+		prometheusmetrics.DLQMessagesTotal.WithLabelValues(message.Topic, "failure").Inc()
+		logger.Log.Error(op, "Failed to publish message to dead-letter topic", err,
+			"source_partition", message.Partition, "source_offset", message.Offset)
+		return err
+	}
+
+	prometheusmetrics.DLQMessagesTotal.WithLabelValues(message.Topic, "success").Inc()
+	logger.Log.Warn(op, "Message published to dead-letter topic", q.topic,
+		"partition", partition, "offset", offset,
+		"source_partition", message.Partition, "source_offset", message.Offset,
+		"error", failure.Err)
+
+	return nil
+}
+
+func (q *DeadLetterQueue) Close() error {
+	return q.producer.Close()
+}
+
+func dlqHeaders(message *sarama.ConsumerMessage, failure Failure) []sarama.RecordHeader {
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+8)
+	for _, h := range message.Headers {
+		if h != nil {
+			headers = append(headers, *h)
+		}
+	}
+
+	errText := ""
+	if failure.Err != nil {
+		errText = failure.Err.Error()
+	}
+
+	return append(headers,
+		sarama.RecordHeader{Key: []byte(HeaderDLQError), Value: []byte(errText)},
+		sarama.RecordHeader{Key: []byte(HeaderDLQErrorKind), Value: []byte(apperrors.Kind(failure.Err))},
+		sarama.RecordHeader{Key: []byte(HeaderDLQReason), Value: []byte(apperrors.Reason(failure.Err))},
+		sarama.RecordHeader{Key: []byte(HeaderDLQAttempts), Value: []byte(strconv.Itoa(failure.Attempts))},
+		sarama.RecordHeader{Key: []byte(HeaderDLQSourceTopic), Value: []byte(message.Topic)},
+		sarama.RecordHeader{Key: []byte(HeaderDLQSourcePartition), Value: []byte(strconv.FormatInt(int64(message.Partition), 10))},
+		sarama.RecordHeader{Key: []byte(HeaderDLQSourceOffset), Value: []byte(strconv.FormatInt(message.Offset, 10))},
+		sarama.RecordHeader{Key: []byte(HeaderDLQFirstFailure), Value: []byte(failure.FirstFailure.UTC().Format(time.RFC3339Nano))},
+	)
+}
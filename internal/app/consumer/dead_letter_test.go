@@ -0,0 +1,66 @@
+package consumer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/logger"
+)
+
+func TestDeadLetterQueue_Publish(t *testing.T) {
+	logger.Init("local")
+
+	firstFailure := time.Date(2025, 8, 3, 16, 0, 0, 0, time.UTC)
+	source := &sarama.ConsumerMessage{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    42,
+		Key:       []byte("order-1"),
+		Value:     []byte(`{"order_uid":"order-1"}`),
+		Headers:   []*sarama.RecordHeader{{Key: []byte("trace"), Value: []byte("abc")}},
+	}
+
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "orders_dlq", msg.Topic)
+
+		value, err := msg.Value.Encode()
+		require.NoError(t, err)
+		assert.Equal(t, source.Value, value)
+
+		key, err := msg.Key.Encode()
+		require.NoError(t, err)
+		assert.Equal(t, source.Key, key)
+
+		headers := make(map[string]string, len(msg.Headers))
+		for _, h := range msg.Headers {
+			headers[string(h.Key)] = string(h.Value)
+		}
+		assert.Equal(t, "abc", headers["trace"])
+		assert.Equal(t, "invalid: order validation failed: missing order_uid", headers[HeaderDLQError])
+		assert.Equal(t, "invalid", headers[HeaderDLQErrorKind])
+		assert.Equal(t, "order validation failed", headers[HeaderDLQReason])
+		assert.Equal(t, "3", headers[HeaderDLQAttempts])
+		assert.Equal(t, "orders", headers[HeaderDLQSourceTopic])
+		assert.Equal(t, "2", headers[HeaderDLQSourcePartition])
+		assert.Equal(t, "42", headers[HeaderDLQSourceOffset])
+		assert.Equal(t, "2025-08-03T16:00:00Z", headers[HeaderDLQFirstFailure])
+		return nil
+	})
+
+	dlq := NewDeadLetterQueue(producer, "orders_dlq")
+	err := dlq.Publish(source, Failure{
+		Err:          apperrors.Invalid("order validation failed", errors.New("missing order_uid")),
+		Attempts:     3,
+		FirstFailure: firstFailure,
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, dlq.Close())
+}
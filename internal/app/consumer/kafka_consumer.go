@@ -2,55 +2,125 @@ package consumer
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/audit"
 	"github.com/zhavkk/order-service/internal/logger"
+	prometheusmetrics "github.com/zhavkk/order-service/pkg/metrics/prometheus"
 	"github.com/zhavkk/order-service/pkg/utils"
 )
 
+var ErrAlreadyRunning = errors.New("consumer is already running")
+
 type Consumer interface {
-	Consume(ctx context.Context, topic string, handler func(message []byte) error) error
+	Consume(ctx context.Context, topic string, handler func(ctx context.Context, message []byte) error) error
 	Close() error
 }
 
 type KafkaConsumer struct {
 	consumerGroup sarama.ConsumerGroup
 	topic         string
-	handler       func(message []byte) error
-	retryCount    int
-	backoff       time.Duration
+	handler       func(ctx context.Context, message []byte) error
+	retry         utils.RetryPolicy
+	dlq           *DeadLetterQueue
+	workers       int
+
+	batchHandler func(ctx context.Context, messages [][]byte, origins []audit.Origin) []error
+	batchSize    int
+	batchTimeout time.Duration
+
+	manualCommit   bool
+	commitInterval time.Duration
+	stopCommitter  chan struct{}
+	committerDone  chan struct{}
+
+	running  atomic.Bool
+	stopOnce sync.Once
+	stopped  chan struct{}
+	done     chan struct{}
 }
 
 func NewKafkaConsumer(
 	brokers []string,
 	topic string,
-	handler func(message []byte) error,
+	handler func(ctx context.Context, message []byte) error,
 	cfg *sarama.Config,
 	groupID string,
-	retryCount int,
-	backoff time.Duration,
+	retry utils.RetryPolicy,
+	dlq *DeadLetterQueue,
+	workers int,
 ) (*KafkaConsumer, error) {
 	consumerGroup, err := sarama.NewConsumerGroup(brokers, groupID, cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	commitInterval := cfg.Consumer.Offsets.AutoCommit.Interval
+	if commitInterval <= 0 {
+		commitInterval = time.Second
+	}
+
 	return &KafkaConsumer{
 		consumerGroup: consumerGroup,
 		topic:         topic,
 		handler:       handler,
-		retryCount:    retryCount,
-		backoff:       backoff,
+		retry:         retry,
+		dlq:           dlq,
+		workers:       workers,
+
+		manualCommit:   !cfg.Consumer.Offsets.AutoCommit.Enable,
+		commitInterval: commitInterval,
+
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
 	}, nil
 }
 
+// EnableBatching включает пакетный режим: сообщения партиции копятся до size штук или
+// timeout с момента первого сообщения пачки и передаются в handler одним вызовом.
+// Пакетный режим имеет приоритет над пулом воркеров. Вместе с сообщениями handler получает
+// инициатора каждого из них: источник указывает на партицию и offset сообщения.
+func (kc *KafkaConsumer) EnableBatching(handler func(ctx context.Context, messages [][]byte, origins []audit.Origin) []error, size int, timeout time.Duration) {
+	if size <= 1 {
+		return
+	}
+	kc.batchHandler = handler
+	kc.batchSize = size
+	kc.batchTimeout = timeout
+}
+
 func (kc *KafkaConsumer) Consume(ctx context.Context) error {
+	if !kc.running.CompareAndSwap(false, true) {
+		return ErrAlreadyRunning
+	}
+	defer close(kc.done)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-kc.stopped:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	for {
 		if err := kc.consumerGroup.Consume(ctx, []string{kc.topic}, kc); err != nil {
 			logger.Log.Error("Error consuming messages", "error", err)
 			return err
 		}
+		select {
+		case <-kc.stopped:
+			return nil
+		default:
+		}
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
@@ -58,26 +128,165 @@ func (kc *KafkaConsumer) Consume(ctx context.Context) error {
 	}
 }
 
+// Shutdown перестает брать новые сообщения, дожидается завершения обработчиков и коммита
+// оффсетов текущей сессии, после чего закрывает consumer group.
+func (kc *KafkaConsumer) Shutdown(ctx context.Context) error {
+	kc.stopOnce.Do(func() { close(kc.stopped) })
+
+	if kc.running.Load() {
+		select {
+		case <-kc.done:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for in-flight messages: %w", ctx.Err())
+		}
+	}
+
+	return kc.Close()
+}
+
 func (kc *KafkaConsumer) Close() error {
 	logger.Log.Info("Closing Kafka consumer")
+	if kc.dlq != nil {
+		if err := kc.dlq.Close(); err != nil {
+			logger.Log.Error("Failed to close dead-letter producer", "error", err)
+		}
+	}
 	return kc.consumerGroup.Close()
 }
 
-func (kc *KafkaConsumer) Setup(sarama.ConsumerGroupSession) error   { return nil }
-func (kc *KafkaConsumer) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+func (kc *KafkaConsumer) Setup(session sarama.ConsumerGroupSession) error {
+	if !kc.manualCommit {
+		return nil
+	}
+
+	kc.stopCommitter = make(chan struct{})
+	kc.committerDone = make(chan struct{})
+	go kc.runCommitter(session, kc.stopCommitter, kc.committerDone)
+
+	return nil
+}
+
+// Cleanup вызывается sarama после завершения всех ConsumeClaim сессии (в том числе при
+// отзыве партиций), поэтому здесь коммитятся все оффсеты, помеченные до ребаланса.
+func (kc *KafkaConsumer) Cleanup(session sarama.ConsumerGroupSession) error {
+	if !kc.manualCommit {
+		return nil
+	}
+
+	close(kc.stopCommitter)
+	<-kc.committerDone
+
+	session.Commit()
+	logger.Log.Info("Committed offsets on session cleanup", "generation", session.GenerationID())
+
+	return nil
+}
+
+// runCommitter периодически коммитит помеченные оффсеты. Оффсет помечается только после
+// того, как заказ записан в Postgres (или сообщение сохранено в DLQ), что дает at-least-once.
+func (kc *KafkaConsumer) runCommitter(session sarama.ConsumerGroupSession, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(kc.commitInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			session.Commit()
+		}
+	}
+}
 
 func (kc *KafkaConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for message := range claim.Messages() {
-		err := utils.RetryWithBackoff(func() error {
-			return kc.handler(message.Value)
-		}, kc.retryCount, kc.backoff)
+	if kc.batchHandler != nil {
+		return kc.consumeBatches(session, claim)
+	}
+	if kc.workers > 1 {
+		return kc.consumeConcurrently(session, claim)
+	}
 
-		if err != nil {
-			logger.Log.Error("Failed to handle message after retries", "error", err, "message", string(message.Value))
-			continue
+	for message := range claim.Messages() {
+		if err := kc.handleMessage(session.Context(), message); err != nil {
+			// Не коммитим оффсет: партиция будет перечитана с этого сообщения после ребаланса.
+			return err
 		}
 
 		session.MarkMessage(message, "")
 	}
 	return nil
 }
+
+// messageOrigin указывает в истории заказа на сообщение, из которого он пришел.
+func messageOrigin(message *sarama.ConsumerMessage) audit.Origin {
+	return audit.Origin{
+		Actor:  audit.ActorConsumer,
+		Source: audit.KafkaSource(message.Topic, message.Partition, message.Offset),
+	}
+}
+
+// handleMessage обрабатывает сообщение, повторяя только временные ошибки.
+// Конфликтующие сообщения пропускаются, невалидные и не обработанные после ретраев уходят в DLQ.
+// Ошибка возвращается, только если сообщение нельзя закоммитить.
+func (kc *KafkaConsumer) handleMessage(ctx context.Context, message *sarama.ConsumerMessage) error {
+	var (
+		attempts     int
+		lastErr      error
+		firstFailure time.Time
+	)
+	origin := messageOrigin(message)
+	msgCtx := audit.WithOrigin(ctx, origin.Actor, origin.Source)
+	err := kc.retry.Do(msgCtx, func(ctx context.Context) error {
+		attempts++
+		if lastErr = kc.handler(ctx, message.Value); lastErr != nil && firstFailure.IsZero() {
+			firstFailure = time.Now()
+		}
+		return lastErr
+	})
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		// Сессия завершается (ребаланс или остановка): сообщение будет обработано заново.
+		return err
+	}
+
+	return kc.handleFailure(message, Failure{
+		Err:          lastErr,
+		Attempts:     attempts,
+		FirstFailure: firstFailure,
+	})
+}
+
+// handleFailure решает судьбу сообщения, которое не удалось обработать.
+// Ошибка возвращается, только если сообщение нельзя закоммитить.
+func (kc *KafkaConsumer) handleFailure(message *sarama.ConsumerMessage, failure Failure) error {
+	lastErr := failure.Err
+
+	switch {
+	case apperrors.IsConflict(lastErr):
+		logger.Log.Warn("Skipping conflicting message", "reason", apperrors.Reason(lastErr),
+			"partition", message.Partition, "offset", message.Offset)
+		prometheusmetrics.MessageProcessedTotal.WithLabelValues("conflict").Inc()
+		return nil
+	case apperrors.IsInvalid(lastErr):
+		logger.Log.Warn("Quarantining invalid message", "reason", apperrors.Reason(lastErr),
+			"partition", message.Partition, "offset", message.Offset)
+		prometheusmetrics.MessageProcessedTotal.WithLabelValues("invalid").Inc()
+	default:
+		logger.Log.Error("Failed to handle message after retries", "error", lastErr,
+			"partition", message.Partition, "offset", message.Offset)
+		prometheusmetrics.MessageProcessedTotal.WithLabelValues("failed").Inc()
+	}
+
+	if kc.dlq == nil {
+		if apperrors.IsInvalid(lastErr) {
+			return nil
+		}
+		// Без DLQ сообщение нельзя считать обработанным: оффсет не коммитится.
+		return lastErr
+	}
+	return kc.dlq.Publish(message, failure)
+}
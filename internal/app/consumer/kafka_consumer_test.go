@@ -0,0 +1,339 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/audit"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/pkg/utils"
+)
+
+type fakeSession struct {
+	ctx context.Context
+
+	mu      sync.Mutex
+	marked  map[int32]int64
+	commits int
+}
+
+func newFakeSession(ctx context.Context) *fakeSession {
+	return &fakeSession{ctx: ctx, marked: make(map[int32]int64)}
+}
+
+func (s *fakeSession) Claims() map[string][]int32 { return nil }
+func (s *fakeSession) MemberID() string           { return "member" }
+func (s *fakeSession) GenerationID() int32        { return 1 }
+func (s *fakeSession) Context() context.Context   { return s.ctx }
+
+func (s *fakeSession) MarkOffset(_ string, partition int32, offset int64, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if offset > s.marked[partition] {
+		s.marked[partition] = offset
+	}
+}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+func (s *fakeSession) ResetOffset(string, int32, int64, string) {}
+
+func (s *fakeSession) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commits++
+}
+
+func (s *fakeSession) markedOffset(partition int32) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.marked[partition]
+}
+
+type fakeClaim struct {
+	partition int32
+	messages  chan *sarama.ConsumerMessage
+}
+
+func newFakeClaim(partition int32, values ...string) *fakeClaim {
+	claim := &fakeClaim{partition: partition, messages: make(chan *sarama.ConsumerMessage, len(values))}
+	for i, v := range values {
+		claim.messages <- &sarama.ConsumerMessage{
+			Topic:     "orders",
+			Partition: partition,
+			Offset:    int64(i),
+			Value:     []byte(v),
+		}
+	}
+	close(claim.messages)
+	return claim
+}
+
+func (c *fakeClaim) Topic() string                            { return "orders" }
+func (c *fakeClaim) Partition() int32                         { return c.partition }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return int64(cap(c.messages)) }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func TestKafkaConsumer_ManualCommit(t *testing.T) {
+	logger.Init("local")
+
+	processed := make(map[string]int)
+	kc := &KafkaConsumer{
+		topic: "orders",
+		handler: func(_ context.Context, message []byte) error {
+			processed[string(message)]++
+			switch string(message) {
+			case "duplicate":
+				return apperrors.Conflict("payment already exists", nil)
+			case "db-down":
+				return apperrors.Transient("connection refused", errors.New("dial tcp"))
+			}
+			return nil
+		},
+		retry:          utils.NewRetryPolicy(2, time.Millisecond, time.Millisecond, apperrors.IsTransient),
+		manualCommit:   true,
+		commitInterval: time.Hour,
+	}
+
+	session := newFakeSession(context.Background())
+	require.NoError(t, kc.Setup(session))
+
+	err := kc.ConsumeClaim(session, newFakeClaim(0, "order-1", "duplicate", "order-2", "db-down", "order-3"))
+	require.Error(t, err)
+
+	require.NoError(t, kc.Cleanup(session))
+
+	// Сообщение db-down не было ни сохранено, ни отправлено в DLQ: оффсет остается на нем.
+	assert.Equal(t, int64(3), session.markedOffset(0))
+	assert.Equal(t, 1, session.commits)
+	assert.Equal(t, 1, processed["duplicate"])
+	assert.Equal(t, 2, processed["db-down"])
+	assert.Zero(t, processed["order-3"])
+}
+
+func TestOffsetTracker_Complete(t *testing.T) {
+	tracker := newOffsetTracker()
+	for offset := int64(10); offset < 14; offset++ {
+		tracker.add(&sarama.ConsumerMessage{Offset: offset})
+	}
+
+	assert.Nil(t, tracker.complete(11))
+	assert.Nil(t, tracker.complete(13))
+
+	committable := tracker.complete(10)
+	require.NotNil(t, committable)
+	assert.Equal(t, int64(11), committable.Offset)
+
+	committable = tracker.complete(12)
+	require.NotNil(t, committable)
+	assert.Equal(t, int64(13), committable.Offset)
+}
+
+func TestKafkaConsumer_ConsumeConcurrently(t *testing.T) {
+	logger.Init("local")
+
+	const (
+		orders         = 20
+		updatesByOrder = 5
+	)
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[string][]int)
+	)
+	kc := &KafkaConsumer{
+		topic: "orders",
+		handler: func(_ context.Context, message []byte) error {
+			var payload struct {
+				OrderUID string `json:"order_uid"`
+				Seq      int    `json:"seq"`
+			}
+			if err := json.Unmarshal(message, &payload); err != nil {
+				return err
+			}
+			time.Sleep(time.Duration(payload.Seq%3) * time.Millisecond)
+			mu.Lock()
+			seen[payload.OrderUID] = append(seen[payload.OrderUID], payload.Seq)
+			mu.Unlock()
+			return nil
+		},
+		retry:   utils.NewRetryPolicy(1, time.Millisecond, time.Millisecond, apperrors.IsTransient),
+		workers: 4,
+	}
+
+	var values []string
+	for seq := 0; seq < updatesByOrder; seq++ {
+		for i := 0; i < orders; i++ {
+			values = append(values, fmt.Sprintf(`{"order_uid":"order-%d","seq":%d}`, i, seq))
+		}
+	}
+
+	session := newFakeSession(context.Background())
+	require.NoError(t, kc.ConsumeClaim(session, newFakeClaim(0, values...)))
+
+	assert.Equal(t, int64(len(values)), session.markedOffset(0))
+	require.Len(t, seen, orders)
+	for orderUID, seqs := range seen {
+		assert.Equal(t, []int{0, 1, 2, 3, 4}, seqs, orderUID)
+	}
+}
+
+func TestKafkaConsumer_ConsumeBatches(t *testing.T) {
+	logger.Init("local")
+
+	var (
+		batches [][]string
+		sources []string
+		single  []string
+	)
+	kc := &KafkaConsumer{
+		topic: "orders",
+		handler: func(_ context.Context, message []byte) error {
+			single = append(single, string(message))
+			return nil
+		},
+		retry: utils.NewRetryPolicy(2, time.Millisecond, time.Millisecond, apperrors.IsTransient),
+	}
+	kc.EnableBatching(func(_ context.Context, messages [][]byte, origins []audit.Origin) []error {
+		batch := make([]string, len(messages))
+		errs := make([]error, len(messages))
+		for i, m := range messages {
+			batch[i] = string(m)
+			sources = append(sources, origins[i].Source)
+			switch batch[i] {
+			case "duplicate":
+				errs[i] = apperrors.Conflict("payments_pkey", nil)
+			case "serialization":
+				errs[i] = apperrors.Transient("could not serialize access", nil)
+			}
+		}
+		batches = append(batches, batch)
+		return errs
+	}, 2, time.Hour)
+
+	session := newFakeSession(context.Background())
+	err := kc.ConsumeClaim(session, newFakeClaim(0, "order-1", "duplicate", "serialization", "order-2", "order-3"))
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{{"order-1", "duplicate"}, {"serialization", "order-2"}, {"order-3"}}, batches)
+	// У каждого сообщения пачки свой offset в истории заказа.
+	assert.Equal(t, []string{
+		"kafka:orders/0/0", "kafka:orders/0/1", "kafka:orders/0/2", "kafka:orders/0/3", "kafka:orders/0/4",
+	}, sources)
+	assert.Equal(t, []string{"serialization"}, single)
+	assert.Equal(t, int64(5), session.markedOffset(0))
+}
+
+type fakeGroup struct {
+	sarama.ConsumerGroup
+
+	session *fakeSession
+	claim   *fakeClaim
+	closed  atomic.Bool
+}
+
+func (g *fakeGroup) Consume(ctx context.Context, _ []string, handler sarama.ConsumerGroupHandler) error {
+	g.session = newFakeSession(ctx)
+	if err := handler.Setup(g.session); err != nil {
+		return err
+	}
+	// Как и sarama, ошибки ConsumeClaim не возвращаются из Consume.
+	_ = handler.ConsumeClaim(g.session, g.claim)
+	if err := handler.Cleanup(g.session); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (g *fakeGroup) Close() error {
+	g.closed.Store(true)
+	return nil
+}
+
+func TestKafkaConsumer_Shutdown(t *testing.T) {
+	logger.Init("local")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	group := &fakeGroup{claim: newFakeClaim(0, "order-1", "order-2")}
+	kc := &KafkaConsumer{
+		consumerGroup: group,
+		topic:         "orders",
+		handler: func(_ context.Context, message []byte) error {
+			if string(message) == "order-1" {
+				close(started)
+				<-release
+			}
+			return nil
+		},
+		retry:          utils.NewRetryPolicy(1, time.Millisecond, time.Millisecond, apperrors.IsTransient),
+		manualCommit:   true,
+		commitInterval: time.Hour,
+		stopped:        make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+
+	consumeErr := make(chan error, 1)
+	go func() { consumeErr <- kc.Consume(context.Background()) }()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- kc.Shutdown(context.Background()) }()
+
+	select {
+	case <-shutdownErr:
+		t.Fatal("shutdown returned before in-flight message was processed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-shutdownErr)
+	require.NoError(t, <-consumeErr)
+
+	// Обрабатываемое сообщение дописано и закоммичено, новые сообщения не берутся.
+	assert.True(t, group.closed.Load())
+	assert.Equal(t, int64(1), group.session.markedOffset(0))
+	assert.Equal(t, 1, group.session.commits)
+}
+
+func TestKafkaConsumer_ShutdownTimeout(t *testing.T) {
+	logger.Init("local")
+
+	release := make(chan struct{})
+	defer close(release)
+	group := &fakeGroup{claim: newFakeClaim(0, "order-1")}
+	kc := &KafkaConsumer{
+		consumerGroup: group,
+		topic:         "orders",
+		handler: func(context.Context, []byte) error {
+			<-release
+			return nil
+		},
+		retry:   utils.NewRetryPolicy(1, time.Millisecond, time.Millisecond, apperrors.IsTransient),
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go func() { _ = kc.Consume(context.Background()) }()
+	require.Eventually(t, kc.running.Load, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := kc.Shutdown(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, group.closed.Load())
+}
@@ -0,0 +1,146 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// workerQueueSize — размер очереди каждого воркера. Вместе с числом воркеров
+// ограничивает количество сообщений партиции, находящихся в обработке одновременно.
+const workerQueueSize = 64
+
+type messageResult struct {
+	message *sarama.ConsumerMessage
+	err     error
+}
+
+// consumeConcurrently раскладывает сообщения партиции по воркерам по хэшу order_uid,
+// поэтому сообщения одного заказа обрабатываются строго по порядку, а разных — параллельно.
+// Оффсет помечается только до последнего непрерывно обработанного сообщения.
+func (kc *KafkaConsumer) consumeConcurrently(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx, cancel := context.WithCancel(session.Context())
+	defer cancel()
+
+	tracker := newOffsetTracker()
+	results := make(chan messageResult, kc.workers*(workerQueueSize+1))
+	shards := make([]chan *sarama.ConsumerMessage, kc.workers)
+
+	var wg sync.WaitGroup
+	for i := range shards {
+		shards[i] = make(chan *sarama.ConsumerMessage, workerQueueSize)
+		wg.Add(1)
+		go func(messages <-chan *sarama.ConsumerMessage) {
+			defer wg.Done()
+			for message := range messages {
+				if err := ctx.Err(); err != nil {
+					results <- messageResult{message: message, err: err}
+					continue
+				}
+				results <- messageResult{message: message, err: kc.handleMessage(ctx, message)}
+			}
+		}(shards[i])
+	}
+
+	var failure error
+	handle := func(res messageResult) {
+		if res.err != nil {
+			if failure == nil {
+				failure = res.err
+				cancel()
+			}
+			return
+		}
+		if committable := tracker.complete(res.message.Offset); committable != nil {
+			session.MarkMessage(committable, "")
+		}
+	}
+
+dispatch:
+	for failure == nil {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				break dispatch
+			}
+			tracker.add(message)
+			shard := shards[kc.shardFor(message)]
+			for sent := false; !sent; {
+				select {
+				case shard <- message:
+					sent = true
+				case res := <-results:
+					handle(res)
+				}
+			}
+		case res := <-results:
+			handle(res)
+		}
+	}
+
+	for _, shard := range shards {
+		close(shard)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	for res := range results {
+		handle(res)
+	}
+
+	return failure
+}
+
+func (kc *KafkaConsumer) shardFor(message *sarama.ConsumerMessage) int {
+	h := fnv.New32a()
+	_, _ = h.Write(messageKey(message))
+	return int(h.Sum32() % uint32(kc.workers))
+}
+
+// messageKey возвращает ключ сообщения Kafka, а если он не задан — order_uid из тела.
+func messageKey(message *sarama.ConsumerMessage) []byte {
+	if len(message.Key) > 0 {
+		return message.Key
+	}
+	var payload struct {
+		OrderUID string `json:"order_uid"`
+	}
+	if err := json.Unmarshal(message.Value, &payload); err != nil {
+		return nil
+	}
+	return []byte(payload.OrderUID)
+}
+
+// offsetTracker отслеживает сообщения партиции в порядке чтения и отдает последнее
+// сообщение, до которого включительно все предыдущие обработаны.
+type offsetTracker struct {
+	pending   []*sarama.ConsumerMessage
+	completed map[int64]struct{}
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{completed: make(map[int64]struct{})}
+}
+
+func (t *offsetTracker) add(message *sarama.ConsumerMessage) {
+	t.pending = append(t.pending, message)
+}
+
+func (t *offsetTracker) complete(offset int64) *sarama.ConsumerMessage {
+	t.completed[offset] = struct{}{}
+
+	var committable *sarama.ConsumerMessage
+	for len(t.pending) > 0 {
+		if _, ok := t.completed[t.pending[0].Offset]; !ok {
+			break
+		}
+		committable = t.pending[0]
+		delete(t.completed, committable.Offset)
+		t.pending = t.pending[1:]
+	}
+	return committable
+}
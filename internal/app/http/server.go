@@ -2,6 +2,7 @@ package httpapp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -33,7 +34,10 @@ func New(cfg *config.Config, handler http.Handler) *HTTPApp {
 
 func (a *HTTPApp) Start() error {
 	logger.Log.Info("Starting HTTP server", "port", a.port)
-	return a.httpServer.ListenAndServe()
+	if err := a.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 func (a *HTTPApp) Stop(ctx context.Context) error {
@@ -50,5 +54,6 @@ func SetupRouter() *chi.Mux {
 	r.Use(middleware.Timeout(60 * time.Second))
 
 	r.Use(metricsmw.MetricsMiddleware)
+	r.Use(metricsmw.AuditMiddleware)
 	return r
 }
@@ -0,0 +1,75 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+type KafkaPublisher struct {
+	producer sarama.SyncProducer
+}
+
+func NewKafkaPublisher(producer sarama.SyncProducer) *KafkaPublisher {
+	return &KafkaPublisher{producer: producer}
+}
+
+func (p *KafkaPublisher) Publish(_ context.Context, message Message) error {
+	msg := &sarama.ProducerMessage{
+		Topic: message.Topic,
+		Key:   sarama.StringEncoder(message.Key),
+		Value: sarama.ByteEncoder(message.Value),
+	}
+	for k, v := range message.Headers {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+
+	_, _, err := p.producer.SendMessage(msg)
+	return err
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.producer.Close()
+}
+
+// MemoryPublisher хранит сообщения в памяти; используется в тестах и локальной отладке.
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []Message
+	fail     func(Message) error
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// FailWith задает функцию, которая может вернуть ошибку публикации для конкретного сообщения.
+func (p *MemoryPublisher) FailWith(fail func(Message) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fail = fail
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, message Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.fail != nil {
+		if err := p.fail(message); err != nil {
+			return err
+		}
+	}
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func (p *MemoryPublisher) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message(nil), p.messages...)
+}
+
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,220 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/models"
+	prometheusmetrics "github.com/zhavkk/order-service/pkg/metrics/prometheus"
+	"github.com/zhavkk/order-service/pkg/pgstorage"
+)
+
+const HeaderEventType = "x-event-type"
+
+var ErrAlreadyRunning = errors.New("outbox relay is already running")
+
+type Store interface {
+	FetchPending(ctx context.Context, limit int) ([]*models.OutboxEvent, error)
+	MarkSent(ctx context.Context, ids []int64) error
+	PendingStats(ctx context.Context) (int64, time.Time, error)
+}
+
+type Message struct {
+	Topic   string
+	Key     string
+	Value   []byte
+	Headers map[string]string
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, message Message) error
+	Close() error
+}
+
+// Relay переносит события из таблицы outbox в Kafka. Гарантия at-least-once: событие
+// помечается отправленным только после подтверждения брокера.
+type Relay struct {
+	store     Store
+	txManager pgstorage.TxManagerInterface
+	publisher Publisher
+	topic     string
+	interval  time.Duration
+	batchSize int
+
+	running  atomic.Bool
+	wake     chan struct{}
+	stopOnce sync.Once
+	stopped  chan struct{}
+	done     chan struct{}
+}
+
+func NewRelay(
+	store Store,
+	txManager pgstorage.TxManagerInterface,
+	publisher Publisher,
+	topic string,
+	interval time.Duration,
+	batchSize int,
+) *Relay {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &Relay{
+		store:     store,
+		txManager: txManager,
+		publisher: publisher,
+		topic:     topic,
+		interval:  interval,
+		batchSize: batchSize,
+		wake:      make(chan struct{}, 1),
+		stopped:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func (r *Relay) Run(ctx context.Context) error {
+	const op = "Relay.Run"
+
+	if !r.running.CompareAndSwap(false, true) {
+		return ErrAlreadyRunning
+	}
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := r.RelayOnce(ctx)
+		if err != nil {
+			logger.Log.Error(op, "Failed to relay outbox events", err)
+		}
+		r.updateLag(ctx)
+
+		// Полная пачка — вероятно, есть еще события: не ждем следующего тика.
+		if err == nil && sent == r.batchSize {
+			select {
+			case <-r.stopped:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+				continue
+			}
+		}
+
+		select {
+		case <-r.stopped:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+// Notify просит отправить новые события, не дожидаясь следующего тика. Не блокирует:
+// несколько вызовов до очередного прохода объединяются в один.
+func (r *Relay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RelayOnce публикует одну пачку событий и возвращает число отправленных.
+// Если публикация прервалась, уже отправленные события все равно помечаются.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	const op = "Relay.RelayOnce"
+
+	var (
+		sent       int
+		publishErr error
+	)
+	err := r.txManager.RunReadCommited(ctx, func(ctx context.Context) error {
+		events, err := r.store.FetchPending(ctx, r.batchSize)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int64, 0, len(events))
+		for _, event := range events {
+			if publishErr = r.publisher.Publish(ctx, r.message(event)); publishErr != nil {
+				break
+			}
+			ids = append(ids, event.ID)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := r.store.MarkSent(ctx, ids); err != nil {
+			return err
+		}
+		sent = len(ids)
+		return nil
+	})
+	if err != nil {
+		// Опубликованные события не помечены и будут отправлены повторно.
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	prometheusmetrics.OutboxEventsPublishedTotal.WithLabelValues("success").Add(float64(sent))
+	if publishErr != nil {
+		prometheusmetrics.OutboxEventsPublishedTotal.WithLabelValues("failure").Inc()
+		return sent, fmt.Errorf("%s: publish: %w", op, publishErr)
+	}
+
+	return sent, nil
+}
+
+// Shutdown останавливает цикл релея, дожидается текущей пачки и закрывает publisher.
+func (r *Relay) Shutdown(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stopped) })
+
+	if r.running.Load() {
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for outbox relay: %w", ctx.Err())
+		}
+	}
+
+	return r.publisher.Close()
+}
+
+func (r *Relay) message(event *models.OutboxEvent) Message {
+	return Message{
+		Topic: r.topic,
+		Key:   event.AggregateID,
+		Value: event.Payload,
+		Headers: map[string]string{
+			HeaderEventType: event.EventType,
+		},
+	}
+}
+
+func (r *Relay) updateLag(ctx context.Context) {
+	const op = "Relay.updateLag"
+
+	count, oldest, err := r.store.PendingStats(ctx)
+	if err != nil {
+		logger.Log.Warn(op, "Failed to get outbox stats", err)
+		return
+	}
+
+	lag := time.Duration(0)
+	if count > 0 {
+		lag = time.Since(oldest)
+	}
+	prometheusmetrics.OutboxPendingEvents.Set(float64(count))
+	prometheusmetrics.OutboxLagSeconds.Set(lag.Seconds())
+}
@@ -0,0 +1,192 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/mocks"
+)
+
+type memStore struct {
+	mu     sync.Mutex
+	events []*models.OutboxEvent
+}
+
+func newMemStore(uids ...string) *memStore {
+	s := &memStore{}
+	for i, uid := range uids {
+		s.events = append(s.events, &models.OutboxEvent{
+			ID:          int64(i + 1),
+			AggregateID: uid,
+			EventType:   "order.created",
+			Payload:     []byte(`{"order_uid":"` + uid + `"}`),
+			CreatedAt:   time.Now().Add(-time.Minute),
+		})
+	}
+	return s
+}
+
+func (s *memStore) FetchPending(_ context.Context, limit int) ([]*models.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pending []*models.OutboxEvent
+	for _, e := range s.events {
+		if e.SentAt == nil && len(pending) < limit {
+			pending = append(pending, e)
+		}
+	}
+	return pending, nil
+}
+
+func (s *memStore) MarkSent(_ context.Context, ids []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		s.events[id-1].SentAt = &now
+	}
+	return nil
+}
+
+func (s *memStore) PendingStats(context.Context) (int64, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		count  int64
+		oldest time.Time
+	)
+	for _, e := range s.events {
+		if e.SentAt == nil {
+			if count == 0 {
+				oldest = e.CreatedAt
+			}
+			count++
+		}
+	}
+	return count, oldest, nil
+}
+
+func newTestRelay(t *testing.T, store Store, publisher Publisher, batchSize int) *Relay {
+	ctrl := gomock.NewController(t)
+	txManager := mocks.NewMockTxManagerInterface(ctrl)
+	txManager.EXPECT().RunReadCommited(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		},
+	).AnyTimes()
+
+	return NewRelay(store, txManager, publisher, "order_events", 10*time.Millisecond, batchSize)
+}
+
+func TestRelay_RelayOnce(t *testing.T) {
+	logger.Init("local")
+
+	store := newMemStore("order-1", "order-2", "order-3")
+	publisher := NewMemoryPublisher()
+	relay := newTestRelay(t, store, publisher, 2)
+
+	sent, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	sent, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	messages := publisher.Messages()
+	require.Len(t, messages, 3)
+	for i, uid := range []string{"order-1", "order-2", "order-3"} {
+		assert.Equal(t, "order_events", messages[i].Topic)
+		assert.Equal(t, uid, messages[i].Key)
+		assert.Equal(t, "order.created", messages[i].Headers[HeaderEventType])
+	}
+
+	count, _, err := store.PendingStats(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestRelay_RelayOnce_PublishFailure(t *testing.T) {
+	logger.Init("local")
+
+	store := newMemStore("order-1", "order-2", "order-3")
+	publisher := NewMemoryPublisher()
+	brokerDown := errors.New("broker down")
+	publisher.FailWith(func(m Message) error {
+		if m.Key == "order-2" {
+			return brokerDown
+		}
+		return nil
+	})
+	relay := newTestRelay(t, store, publisher, 10)
+
+	sent, err := relay.RelayOnce(context.Background())
+	require.ErrorIs(t, err, brokerDown)
+	assert.Equal(t, 1, sent)
+
+	// Успешно отправленное событие не публикуется повторно.
+	publisher.FailWith(nil)
+	sent, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	var keys []string
+	for _, m := range publisher.Messages() {
+		keys = append(keys, m.Key)
+	}
+	assert.Equal(t, []string{"order-1", "order-2", "order-3"}, keys)
+}
+
+func TestRelay_RunAndShutdown(t *testing.T) {
+	logger.Init("local")
+
+	store := newMemStore("order-1", "order-2", "order-3", "order-4", "order-5")
+	publisher := NewMemoryPublisher()
+	relay := newTestRelay(t, store, publisher, 2)
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- relay.Run(context.Background()) }()
+
+	require.Eventually(t, func() bool { return len(publisher.Messages()) == 5 }, time.Second, time.Millisecond)
+
+	require.NoError(t, relay.Shutdown(context.Background()))
+	require.NoError(t, <-runErr)
+}
+
+func TestRelay_Notify(t *testing.T) {
+	logger.Init("local")
+
+	store := newMemStore()
+	publisher := NewMemoryPublisher()
+	relay := newTestRelay(t, store, publisher, 10)
+	relay.interval = time.Hour
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- relay.Run(context.Background()) }()
+
+	store.mu.Lock()
+	store.events = append(store.events, &models.OutboxEvent{
+		ID: 1, AggregateID: "order-1", EventType: "order.created", CreatedAt: time.Now(),
+	})
+	store.mu.Unlock()
+
+	// Без Notify событие ждало бы тика через час.
+	require.Eventually(t, func() bool {
+		relay.Notify()
+		return len(publisher.Messages()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, relay.Shutdown(context.Background()))
+	require.NoError(t, <-runErr)
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"github.com/redis/go-redis/v9"
+	"github.com/zhavkk/order-service/internal/config"
+	rediscache "github.com/zhavkk/order-service/pkg/cache/redis"
+)
+
+// NewRedisClient подключается к Redis в режиме из конфига.
+func NewRedisClient(conf config.RedisConfig) (redis.UniversalClient, error) {
+	return rediscache.NewUniversalClient(redisOptions(conf))
+}
+
+func redisOptions(conf config.RedisConfig) rediscache.Options {
+	opts := rediscache.Options{
+		Mode:             conf.Mode,
+		Addrs:            conf.Addrs,
+		MasterName:       conf.MasterName,
+		DB:               conf.Db,
+		Username:         conf.Username,
+		Password:         conf.Password,
+		SentinelUsername: conf.SentinelUsername,
+		SentinelPassword: conf.SentinelPassword,
+		TLS: rediscache.TLSOptions{
+			Enabled:            conf.TLS.Enabled,
+			ServerName:         conf.TLS.ServerName,
+			CAFile:             conf.TLS.CAFile,
+			CertFile:           conf.TLS.CertFile,
+			KeyFile:            conf.TLS.KeyFile,
+			InsecureSkipVerify: conf.TLS.InsecureSkipVerify,
+		},
+		PoolSize:     conf.PoolSize,
+		MinIdleConns: conf.MinIdleConns,
+		PoolTimeout:  conf.PoolTimeout,
+		DialTimeout:  conf.DialTimeout,
+		ReadTimeout:  conf.ReadTimeout,
+		WriteTimeout: conf.WriteTimeout,
+	}
+	// Одиночный Redis можно задать через host и port.
+	if (conf.Mode == rediscache.ModeStandalone || conf.Mode == "") && len(conf.Addrs) == 0 {
+		opts.Addrs = []string{conf.Addr()}
+	}
+	return opts
+}
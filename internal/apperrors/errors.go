@@ -0,0 +1,85 @@
+// Package apperrors содержит общую для сервиса, репозиториев и консьюмера классификацию ошибок.
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalid — сообщение невалидно (poison message), повторная обработка не поможет.
+	ErrInvalid = errors.New("invalid")
+	// ErrConflict — данные конфликтуют с уже сохраненными, повтор даст тот же результат.
+	ErrConflict = errors.New("conflict")
+	// ErrTransient — временный сбой инфраструктуры, операцию имеет смысл повторить.
+	ErrTransient = errors.New("transient")
+)
+
+type Error struct {
+	Kind   error
+	Reason string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %s", e.Kind, e.Reason)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.Kind, e.Reason, e.Err)
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+func Invalid(reason string, err error) error {
+	return &Error{Kind: ErrInvalid, Reason: reason, Err: err}
+}
+
+func Conflict(reason string, err error) error {
+	return &Error{Kind: ErrConflict, Reason: reason, Err: err}
+}
+
+func Transient(reason string, err error) error {
+	return &Error{Kind: ErrTransient, Reason: reason, Err: err}
+}
+
+func IsInvalid(err error) bool {
+	return errors.Is(err, ErrInvalid)
+}
+
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrTransient)
+}
+
+// Kind возвращает имя класса ошибки для логов, метрик и заголовков DLQ.
+func Kind(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case IsInvalid(err):
+		return ErrInvalid.Error()
+	case IsConflict(err):
+		return ErrConflict.Error()
+	case IsTransient(err):
+		return ErrTransient.Error()
+	default:
+		return "unknown"
+	}
+}
+
+// Reason возвращает причину, указанную при классификации ошибки.
+func Reason(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Reason
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
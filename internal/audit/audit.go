@@ -0,0 +1,43 @@
+// Package audit передает через контекст, кто и откуда инициировал изменение заказа,
+// чтобы сервис мог записать это в историю заказа.
+package audit
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	ActorSystem   = "system"
+	ActorConsumer = "kafka-consumer"
+	ActorAPI      = "api"
+	ActorAdmin    = "admin"
+)
+
+// Origin — инициатор изменения: Actor — кто, Source — откуда (сообщение Kafka или HTTP-запрос).
+type Origin struct {
+	Actor  string
+	Source string
+}
+
+type originKey struct{}
+
+func WithOrigin(ctx context.Context, actor, source string) context.Context {
+	return context.WithValue(ctx, originKey{}, Origin{Actor: actor, Source: source})
+}
+
+// FromContext возвращает инициатора из контекста. Без него изменение считается системным.
+func FromContext(ctx context.Context) Origin {
+	if origin, ok := ctx.Value(originKey{}).(Origin); ok {
+		return origin
+	}
+	return Origin{Actor: ActorSystem}
+}
+
+func KafkaSource(topic string, partition int32, offset int64) string {
+	return fmt.Sprintf("kafka:%s/%d/%d", topic, partition, offset)
+}
+
+func HTTPSource(requestID string) string {
+	return "http:" + requestID
+}
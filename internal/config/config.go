@@ -16,6 +16,10 @@ type Config struct {
 	Postgres PostgresConfig `yaml:"postgres"`
 	Redis    RedisConfig    `yaml:"redis"`
 	Kafka    KafkaConfig    `yaml:"kafka"`
+	Outbox   OutboxConfig   `yaml:"outbox"`
+	Orders   OrdersConfig   `yaml:"orders"`
+	WarmUp   WarmUpConfig   `yaml:"warmup"`
+	Admin    AdminConfig    `yaml:"admin"`
 }
 
 type HTTPConfig struct {
@@ -26,33 +30,151 @@ type HTTPConfig struct {
 }
 
 type PostgresConfig struct {
-	Host     string        `env:"POSTGRES_HOST" envDefault:"localhost"`
-	Port     string        `env:"POSTGRES_PORT" envDefault:"5432"`
-	Username string        `env:"POSTGRES_USER" envDefault:"postgres"`
-	Password string        `env:"POSTGRES_PASSWORD" envDefault:"postgres"`
-	Database string        `env:"POSTGRES_DB" envDefault:"order_service"`
-	SSLMode  string        `env:"POSTGRES_SSLMODE" envDefault:"disable"`
-	Retries  int           `yaml:"retries" env:"POSTGRES_RETRY_COUNT" env-default:"3"`
-	Backoff  time.Duration `yaml:"backoff" env:"POSTGRES_BACKOFF" env-default:"1s"`
+	Host       string        `env:"POSTGRES_HOST" envDefault:"localhost"`
+	Port       string        `env:"POSTGRES_PORT" envDefault:"5432"`
+	Username   string        `env:"POSTGRES_USER" envDefault:"postgres"`
+	Password   string        `env:"POSTGRES_PASSWORD" envDefault:"postgres"`
+	Database   string        `env:"POSTGRES_DB" envDefault:"order_service"`
+	SSLMode    string        `env:"POSTGRES_SSLMODE" envDefault:"disable"`
+	Retries    int           `yaml:"retries" env:"POSTGRES_RETRY_COUNT" env-default:"3"`
+	Backoff    time.Duration `yaml:"backoff" env:"POSTGRES_BACKOFF" env-default:"1s"`
+	MaxBackoff time.Duration `yaml:"max_backoff" env:"POSTGRES_MAX_BACKOFF" env-default:"10s"`
 }
 
 type RedisConfig struct {
+	// standalone (host:port или первый адрес из addrs), sentinel или cluster.
+	Mode string        `yaml:"mode" env:"REDIS_MODE" env-default:"standalone"`
 	Host string        `yaml:"host" envDefault:"localhost"`
 	Port string        `yaml:"port" envDefault:"6379"`
 	TTL  time.Duration `yaml:"ttl" env:"REDIS_TTL" env-default:"5m"`
 	Db   int           `yaml:"db" env:"REDIS_DB" env-default:"0"`
+	// Адреса sentinel-ов или узлов кластера.
+	Addrs      []string `yaml:"addrs" env:"REDIS_ADDRS" env-separator:","`
+	MasterName string   `yaml:"master_name" env:"REDIS_MASTER_NAME"`
+	// Пользователь ACL и пароль Redis; для sentinel-ов — отдельные, если они заданы.
+	Username         string `yaml:"username" env:"REDIS_USERNAME"`
+	Password         string `yaml:"password" env:"REDIS_PASSWORD"`
+	SentinelUsername string `yaml:"sentinel_username" env:"REDIS_SENTINEL_USERNAME"`
+	SentinelPassword string `yaml:"sentinel_password" env:"REDIS_SENTINEL_PASSWORD"`
+
+	TLS RedisTLSConfig `yaml:"tls"`
+	// 0 — значение go-redis по умолчанию (10 соединений на GOMAXPROCS).
+	PoolSize     int           `yaml:"pool_size" env:"REDIS_POOL_SIZE" env-default:"0"`
+	MinIdleConns int           `yaml:"min_idle_conns" env:"REDIS_MIN_IDLE_CONNS" env-default:"0"`
+	PoolTimeout  time.Duration `yaml:"pool_timeout" env:"REDIS_POOL_TIMEOUT" env-default:"4s"`
+	DialTimeout  time.Duration `yaml:"dial_timeout" env:"REDIS_DIAL_TIMEOUT" env-default:"5s"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"REDIS_READ_TIMEOUT" env-default:"3s"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"REDIS_WRITE_TIMEOUT" env-default:"3s"`
+	// Сколько помнить отсутствующий заказ; 0 — не кэшировать промахи.
+	NegativeTTL time.Duration `yaml:"negative_ttl" env:"REDIS_NEGATIVE_TTL" env-default:"30s"`
+	// Коэффициент раннего обновления горячих записей; 0 — выключено.
+	EarlyRefreshBeta float64 `yaml:"early_refresh_beta" env:"REDIS_EARLY_REFRESH_BETA" env-default:"0"`
+	// Формат новых записей: json, msgpack или binary; сжатие none, zstd или snappy для
+	// значений длиннее compression_threshold байт. Старые записи читаются в своем формате.
+	Codec                string `yaml:"codec" env:"REDIS_CODEC" env-default:"json"`
+	Compression          string `yaml:"compression" env:"REDIS_COMPRESSION" env-default:"none"`
+	CompressionThreshold int    `yaml:"compression_threshold" env:"REDIS_COMPRESSION_THRESHOLD" env-default:"1024"`
+
+	Local   LocalCacheConfig   `yaml:"local"`
+	Breaker CacheBreakerConfig `yaml:"breaker"`
+}
+
+type RedisTLSConfig struct {
+	Enabled    bool   `yaml:"enabled" env:"REDIS_TLS_ENABLED" env-default:"false"`
+	ServerName string `yaml:"server_name" env:"REDIS_TLS_SERVER_NAME"`
+	// CA для проверки сертификата сервера; пусто — системные корневые сертификаты.
+	CAFile string `yaml:"ca_file" env:"REDIS_TLS_CA_FILE"`
+	// Клиентский сертификат для mTLS.
+	CertFile           string `yaml:"cert_file" env:"REDIS_TLS_CERT_FILE"`
+	KeyFile            string `yaml:"key_file" env:"REDIS_TLS_KEY_FILE"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" env:"REDIS_TLS_INSECURE_SKIP_VERIFY" env-default:"false"`
+}
+
+// CacheBreakerConfig — когда считать Redis недоступным и как часто проверять его восстановление.
+type CacheBreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold" env:"REDIS_BREAKER_FAILURE_THRESHOLD" env-default:"5"`
+	ProbeInterval    time.Duration `yaml:"probe_interval" env:"REDIS_BREAKER_PROBE_INTERVAL" env-default:"5s"`
+}
+
+// LocalCacheConfig — LRU в памяти процесса перед Redis.
+type LocalCacheConfig struct {
+	Enabled bool          `yaml:"enabled" env:"LOCAL_CACHE_ENABLED" env-default:"true"`
+	Size    int           `yaml:"size" env:"LOCAL_CACHE_SIZE" env-default:"10000"`
+	TTL     time.Duration `yaml:"ttl" env:"LOCAL_CACHE_TTL" env-default:"30s"`
+	Channel string        `yaml:"invalidation_channel" env:"LOCAL_CACHE_CHANNEL" env-default:"order_cache_invalidation"`
+}
+
+// WarmUpConfig — прогрев кэша при старте и готовность принимать запросы.
+type WarmUpConfig struct {
+	// recent, most_accessed, recent_days или none.
+	Strategy       string        `yaml:"strategy" env:"WARMUP_STRATEGY" env-default:"recent"`
+	Limit          int           `yaml:"limit" env:"WARMUP_LIMIT" env-default:"1000"`
+	Window         time.Duration `yaml:"window" env:"WARMUP_WINDOW" env-default:"24h"`
+	Days           int           `yaml:"days" env:"WARMUP_DAYS" env-default:"1"`
+	Concurrency    int           `yaml:"concurrency" env:"WARMUP_CONCURRENCY" env-default:"4"`
+	BatchSize      int           `yaml:"batch_size" env:"WARMUP_BATCH_SIZE" env-default:"100"`
+	Timeout        time.Duration `yaml:"timeout" env:"WARMUP_TIMEOUT" env-default:"30s"`
+	ReadyThreshold float64       `yaml:"ready_threshold" env:"WARMUP_READY_THRESHOLD" env-default:"0"`
+	// Учет чтений заказов для most_accessed: как часто писать счетчики и сколько их хранить.
+	AccessFlushInterval time.Duration `yaml:"access_flush_interval" env:"WARMUP_ACCESS_FLUSH_INTERVAL" env-default:"10s"`
+	AccessRetention     time.Duration `yaml:"access_retention" env:"WARMUP_ACCESS_RETENTION" env-default:"168h"`
+	// AccessMaxPending — сколько счетчиков держать в памяти, пока их не удается записать.
+	AccessMaxPending int `yaml:"access_max_pending" env:"WARMUP_ACCESS_MAX_PENDING" env-default:"100000"`
+}
+
+type AdminConfig struct {
+	// Tokens — токены админского API по имени владельца (ADMIN_TOKENS=ops:secret,oncall:secret2).
+	// Без токенов админское API выключено.
+	Tokens map[string]string `yaml:"tokens" env:"ADMIN_TOKENS"`
 }
 
 type KafkaConfig struct {
 	Version            string        `yaml:"version" env:"KAFKA_VERSION" env-default:"2.8.0"`
 	AutoCommitInterval time.Duration `yaml:"auto_commit_interval" env:"KAFKA_AUTO_COMMIT_INTERVAL" env-default:"1s"`
+	CommitMode         string        `yaml:"commit_mode" env:"KAFKA_COMMIT_MODE" env-default:"auto"`
+	CommitInterval     time.Duration `yaml:"commit_interval" env:"KAFKA_COMMIT_INTERVAL" env-default:"1s"`
 	Brokers            []string      `yaml:"brokers" env:"KAFKA_BROKERS" env-default:"localhost:9092"`
 	OrderTopic         string        `yaml:"order_topic" env:"KAFKA_TOPIC" env-default:"orders"`
+	StatusTopic        string        `yaml:"status_topic" env:"KAFKA_STATUS_TOPIC" env-default:"order_status"`
 	GroupID            string        `yaml:"group_id" env:"KAFKA_GROUP_ID" env-default:"order_service_group"`
+	DLQTopic           string        `yaml:"dlq_topic" env:"KAFKA_DLQ_TOPIC" env-default:"orders_dlq"`
 	Retries            int           `yaml:"retries" env:"KAFKA_RETRY_COUNT" env-default:"3"`
 	Backoff            time.Duration `yaml:"backoff" env:"KAFKA_BACKOFF" env-default:"1s"`
+	MaxBackoff         time.Duration `yaml:"max_backoff" env:"KAFKA_MAX_BACKOFF" env-default:"30s"`
+	Workers            int           `yaml:"workers" env:"KAFKA_WORKERS" env-default:"1"`
+	BatchSize          int           `yaml:"batch_size" env:"KAFKA_BATCH_SIZE" env-default:"0"`
+	BatchTimeout       time.Duration `yaml:"batch_timeout" env:"KAFKA_BATCH_TIMEOUT" env-default:"100ms"`
+}
+
+type OutboxConfig struct {
+	Topic        string        `yaml:"topic" env:"OUTBOX_TOPIC" env-default:"order_events"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL" env-default:"1s"`
+	BatchSize    int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" env-default:"100"`
 }
 
+type OrdersConfig struct {
+	ConflictPolicy string           `yaml:"conflict_policy" env:"ORDERS_CONFLICT_POLICY" env-default:"reject"`
+	Validation     ValidationConfig `yaml:"validation"`
+	Ingest         IngestConfig     `yaml:"ingest"`
+}
+
+// IngestConfig — прием заказов по HTTP (POST /orders, POST /orders:batch).
+type IngestConfig struct {
+	MaxBodyBytes   int64         `yaml:"max_body_bytes" env:"ORDERS_INGEST_MAX_BODY_BYTES" env-default:"10485760"`
+	MaxBatchSize   int           `yaml:"max_batch_size" env:"ORDERS_INGEST_MAX_BATCH_SIZE" env-default:"1000"`
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl" env:"ORDERS_INGEST_IDEMPOTENCY_TTL" env-default:"24h"`
+}
+
+type ValidationConfig struct {
+	Mode  string            `yaml:"mode" env:"ORDERS_VALIDATION_MODE" env-default:"warn"`
+	Rules map[string]string `yaml:"rules"`
+}
+
+const (
+	CommitModeAuto   = "auto"
+	CommitModeManual = "manual"
+)
+
 func (r RedisConfig) Addr() string {
 	return fmt.Sprintf("%s:%s", r.Host, r.Port)
 }
@@ -10,6 +10,58 @@ type GetOrderByIDResponse struct {
 	Order OrderResponse `json:"order"`
 }
 
+type ListOrdersRequest struct {
+	CustomerID      string     `json:"customer_id"`
+	TrackNumber     string     `json:"track_number"`
+	DeliveryService string     `json:"delivery_service"`
+	Locale          string     `json:"locale"`
+	Currency        string     `json:"currency" validate:"omitempty,oneof=USD EUR RUB"`
+	Provider        string     `json:"provider"`
+	CreatedFrom     *time.Time `json:"created_from"`
+	CreatedTo       *time.Time `json:"created_to"`
+	Limit           int        `json:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor          string     `json:"cursor"`
+}
+
+type ListOrdersResponse struct {
+	Orders     []OrderResponse `json:"orders"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+type ChangeStatusRequest struct {
+	OrderUID  string `json:"order_uid" validate:"required"`
+	Status    string `json:"status" validate:"required"`
+	ChangedBy string `json:"changed_by" validate:"required"`
+	Reason    string `json:"reason"`
+}
+
+type ChangeStatusResponse struct {
+	OrderUID       string `json:"order_uid"`
+	PreviousStatus string `json:"previous_status"`
+	Status         string `json:"status"`
+	Changed        bool   `json:"changed"`
+}
+
+type OrderHistoryResponse struct {
+	OrderUID string                 `json:"order_uid"`
+	Events   []OrderHistoryEventDTO `json:"events"`
+}
+
+type OrderHistoryEventDTO struct {
+	ID        int64            `json:"id"`
+	EventType string           `json:"event_type"`
+	Actor     string           `json:"actor"`
+	Source    string           `json:"source"`
+	Changes   []FieldChangeDTO `json:"changes,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type FieldChangeDTO struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
 type ProcessOrderRequest struct {
 	Order OrderRequest `json:"order" validate:"required"`
 }
@@ -46,6 +98,9 @@ type OrderResponse struct {
 	SmID              int         `json:"sm_id"`
 	DateCreated       time.Time   `json:"date_created"`
 	OofShard          string      `json:"oof_shard"`
+	Status            string      `json:"status"`
+	Version           int         `json:"version"`
+	UpdatedAt         time.Time   `json:"updated_at"`
 }
 
 type DeliveryDTO struct {
@@ -85,6 +140,56 @@ type ItemDTO struct {
 	Status      int    `json:"status" validate:"required"`
 }
 
+// Результаты приема заказа по HTTP: accepted — сохранен (или уже был сохранен с тем же
+// содержимым), rejected — невалиден или конфликтует с сохраненным, повтор не поможет,
+// failed — временный сбой, заказ можно отправить еще раз.
+const (
+	SubmitStatusAccepted = "accepted"
+	SubmitStatusRejected = "rejected"
+	SubmitStatusFailed   = "failed"
+)
+
+type SubmitOrderResult struct {
+	// Index — номер заказа в запросе, с нуля.
+	Index    int    `json:"index"`
+	OrderUID string `json:"order_uid,omitempty"`
+	Status   string `json:"status"`
+	// Kind — класс ошибки: invalid, conflict или transient.
+	Kind  string `json:"kind,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type SubmitOrdersResponse struct {
+	Accepted int                 `json:"accepted"`
+	Rejected int                 `json:"rejected"`
+	Failed   int                 `json:"failed"`
+	Results  []SubmitOrderResult `json:"results"`
+	// Replayed — ответ взят из сохраненного по Idempotency-Key.
+	Replayed bool `json:"-"`
+}
+
+// EvictCacheRequest — ровно одно из полей: glob-шаблон ключей Redis (order:*) или покупатель,
+// все заказы которого удаляются из кэша.
+type EvictCacheRequest struct {
+	Pattern    string `json:"pattern" validate:"required_without=CustomerID"`
+	CustomerID string `json:"customer_id" validate:"required_without=Pattern"`
+}
+
+type EvictCacheResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+type CacheStatsResponse struct {
+	Keys        int64               `json:"keys"`
+	MemoryBytes int64               `json:"memory_bytes"`
+	Hits        int64               `json:"hits"`
+	Misses      int64               `json:"misses"`
+	HitRatio    float64             `json:"hit_ratio"`
+	Local       *CacheStatsResponse `json:"local,omitempty"`
+	// RemoteError — общий кэш недоступен, заполнен только local.
+	RemoteError string `json:"remote_error,omitempty"`
+}
+
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
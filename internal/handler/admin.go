@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/logger"
+)
+
+// RegisterAdminRoutes регистрирует служебные операции с кэшем. Аутентификацию
+// обеспечивает вызывающий (см. mw.AdminAuth).
+func (h *Handler) RegisterAdminRoutes(r chi.Router) {
+	r.Route("/admin/cache", func(r chi.Router) {
+		r.Delete("/orders/{order_id}", h.EvictOrder)
+		r.Post("/evict", h.EvictCache)
+		r.Post("/warmup", h.TriggerWarmUp)
+		r.Get("/stats", h.CacheStats)
+	})
+}
+
+// EvictOrder удаляет заказ из кэша.
+// @Summary Сбросить заказ в кэше
+// @Description Удаляет из кэша заказ и отметку о его отсутствии; следующее чтение пойдет в базу. Сброс записывается в историю заказа.
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param order_id path string true "ID заказа"
+// @Success 204
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /admin/cache/orders/{order_id} [delete]
+func (h *Handler) EvictOrder(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	const op = "Handler.EvictOrder"
+
+	if err := h.orderService.EvictOrder(r.Context(), chi.URLParam(r, "order_id")); err != nil {
+		logger.Log.Error(op, "Failed to evict cached order", err)
+		h.writeErrorResponse(w, "Failed to evict cached order", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EvictCache удаляет из кэша ключи по шаблону или заказы покупателя.
+// @Summary Сбросить кэш по шаблону или покупателю
+// @Description Передайте ровно одно поле: pattern — glob-шаблон ключей Redis, начинающийся с order: или order-missing: (например, order:test-*), или customer_id — все заказы покупателя.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param request body dto.EvictCacheRequest true "Что сбросить"
+// @Success 200 {object} dto.EvictCacheResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /admin/cache/evict [post]
+func (h *Handler) EvictCache(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	const op = "Handler.EvictCache"
+
+	var req dto.EvictCacheRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.Error(op, "Invalid request body", err)
+		h.writeErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validate.Struct(&req); err != nil {
+		logger.Log.Error(op, "Invalid request", err)
+		h.writeErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.orderService.EvictCache(r.Context(), &req)
+	if err != nil {
+		logger.Log.Error(op, "Failed to evict cache", err)
+		if apperrors.IsInvalid(err) {
+			h.writeErrorResponse(w, "Invalid request: "+apperrors.Reason(err), http.StatusBadRequest)
+			return
+		}
+		h.writeErrorResponse(w, "Failed to evict cache", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSONResponse(w, resp, http.StatusOK)
+}
+
+// TriggerWarmUp запускает прогрев кэша.
+// @Summary Прогреть кэш
+// @Description Запускает прогрев кэша в фоне по настроенной стратегии и сразу отвечает. Ход прогрева виден в /ready; сервис при этом остается готовым.
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Success 202
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/cache/warmup [post]
+func (h *Handler) TriggerWarmUp(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	const op = "Handler.TriggerWarmUp"
+
+	if err := h.orderService.TriggerWarmUp(r.Context()); err != nil {
+		logger.Log.Error(op, "Failed to start cache warm-up", err)
+		if apperrors.IsConflict(err) {
+			h.writeErrorResponse(w, apperrors.Reason(err), http.StatusConflict)
+			return
+		}
+		h.writeErrorResponse(w, "Failed to start cache warm-up", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// CacheStats возвращает статистику кэша.
+// @Summary Статистика кэша
+// @Description Число ключей, занятая память и доля попаданий общего кэша (Redis, по всем мастерам кластера) и, если включен, локального кэша этого инстанса. Попадания Redis считаются с его последнего рестарта. Если Redis недоступен, отдается статистика локального кэша, а причина — в remote_error.
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} dto.CacheStatsResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /admin/cache/stats [get]
+func (h *Handler) CacheStats(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	const op = "Handler.CacheStats"
+
+	resp, err := h.orderService.CacheStats(r.Context())
+	if err != nil {
+		logger.Log.Error(op, "Failed to get cache stats", err)
+		h.writeErrorResponse(w, "Failed to get cache stats", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSONResponse(w, resp, http.StatusOK)
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-playground/validator"
+	"github.com/zhavkk/order-service/internal/apperrors"
 	"github.com/zhavkk/order-service/internal/dto"
 	"github.com/zhavkk/order-service/internal/logger"
 	"github.com/zhavkk/order-service/internal/repository/postgres"
@@ -17,25 +22,44 @@ var validate = validator.New()
 
 type OrderService interface {
 	GetByID(ctx context.Context, req *dto.GetOrderByIDRequest) (*dto.GetOrderByIDResponse, error)
+	ListOrders(ctx context.Context, req *dto.ListOrdersRequest) (*dto.ListOrdersResponse, error)
+	ChangeStatus(ctx context.Context, req *dto.ChangeStatusRequest) (*dto.ChangeStatusResponse, error)
+	GetOrderHistory(ctx context.Context, orderUID string) (*dto.OrderHistoryResponse, error)
 	ProcessMessage(ctx context.Context, message []byte) error
 	ProcessOrder(ctx context.Context, req *dto.ProcessOrderRequest) error
 	WarmUpCache(ctx context.Context) error
+	EvictOrder(ctx context.Context, orderUID string) error
+	EvictCache(ctx context.Context, req *dto.EvictCacheRequest) (*dto.EvictCacheResponse, error)
+	TriggerWarmUp(ctx context.Context) error
+	CacheStats(ctx context.Context) (*dto.CacheStatsResponse, error)
+	SubmitOrders(ctx context.Context, idempotencyKey string, messages [][]byte) (*dto.SubmitOrdersResponse, error)
 }
 
 type Handler struct {
 	orderService OrderService
+	ingest       IngestOptions
 }
 
 func NewHandler(orderService OrderService) *Handler {
 	return &Handler{
 		orderService: orderService,
+		ingest:       defaultIngestOptions,
 	}
 }
 
+// RegisterRoutes регистрирует чтение заказов. В app эти маршруты закрыты ReadinessGate:
+// до прогрева кэша чтения ушли бы в базу.
 func (h *Handler) RegisterRoutes(r chi.Router) {
-	r.Route("/orders", func(r chi.Router) {
-		r.Get("/{order_id}", h.GetOrderByID)
-	})
+	r.Get("/orders", h.ListOrders)
+	r.Get("/orders/{order_id}", h.GetOrderByID)
+	r.Get("/orders/{order_id}/history", h.GetOrderHistory)
+}
+
+// RegisterWriteRoutes регистрирует прием заказов и смену статуса: им прогретый кэш не нужен.
+func (h *Handler) RegisterWriteRoutes(r chi.Router) {
+	r.Post("/orders", h.SubmitOrder)
+	r.Post("/orders:batch", h.SubmitOrders)
+	r.Patch("/orders/{order_id}/status", h.ChangeStatus)
 }
 
 // GetOrderByID получает заказ по его ID.
@@ -80,6 +104,174 @@ func (h *Handler) GetOrderByID(
 
 }
 
+// ListOrders возвращает страницу заказов с фильтрами.
+// @Summary Список заказов
+// @Description Возвращает заказы, отсортированные по дате создания (сначала новые). Для следующей страницы передайте next_cursor из ответа в параметр cursor.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param customer_id query string false "ID покупателя"
+// @Param track_number query string false "Трек-номер"
+// @Param delivery_service query string false "Служба доставки"
+// @Param locale query string false "Локаль"
+// @Param currency query string false "Валюта платежа" Enums(USD, EUR, RUB)
+// @Param provider query string false "Платежный провайдер"
+// @Param created_from query string false "Создан не раньше (RFC3339)"
+// @Param created_to query string false "Создан раньше (RFC3339)"
+// @Param limit query int false "Размер страницы (1-100, по умолчанию 20)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Success 200 {object} dto.ListOrdersResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /orders [get]
+func (h *Handler) ListOrders(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	const op = "Handler.ListOrders"
+
+	req, err := parseListOrdersRequest(r.URL.Query())
+	if err != nil {
+		logger.Log.Error(op, "Invalid request", err)
+		h.writeErrorResponse(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		logger.Log.Error(op, "Invalid request", err)
+		h.writeErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.orderService.ListOrders(r.Context(), req)
+	if err != nil {
+		logger.Log.Error(op, "Failed to list orders", err)
+		if apperrors.IsInvalid(err) {
+			h.writeErrorResponse(w, "Invalid request: "+apperrors.Reason(err), http.StatusBadRequest)
+			return
+		}
+		h.writeErrorResponse(w, "Failed to list orders", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSONResponse(w, resp, http.StatusOK)
+}
+
+// ChangeStatus меняет статус заказа.
+// @Summary Изменить статус заказа
+// @Description Переводит заказ в новый статус. Допустимые переходы: created → paid|cancelled, paid → assembling|cancelled, assembling → shipped|cancelled, shipped → delivered|returned, delivered → returned.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param order_id path string true "ID заказа"
+// @Param request body dto.ChangeStatusRequest true "Новый статус"
+// @Success 200 {object} dto.ChangeStatusResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /orders/{order_id}/status [patch]
+func (h *Handler) ChangeStatus(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	const op = "Handler.ChangeStatus"
+
+	var req dto.ChangeStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.Error(op, "Invalid request body", err)
+		h.writeErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.OrderUID = chi.URLParam(r, "order_id")
+
+	if err := validate.Struct(&req); err != nil {
+		logger.Log.Error(op, "Invalid request", err)
+		h.writeErrorResponse(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.orderService.ChangeStatus(r.Context(), &req)
+	if err != nil {
+		logger.Log.Error(op, "Failed to change order status", err)
+		switch {
+		case errors.Is(err, postgres.ErrOrderNotFound):
+			h.writeErrorResponse(w, "Order not found", http.StatusNotFound)
+		case apperrors.IsInvalid(err):
+			h.writeErrorResponse(w, "Invalid request: "+apperrors.Reason(err), http.StatusBadRequest)
+		case apperrors.IsConflict(err):
+			h.writeErrorResponse(w, apperrors.Reason(err), http.StatusConflict)
+		default:
+			h.writeErrorResponse(w, "Failed to change order status", http.StatusInternalServerError)
+		}
+		return
+	}
+	h.writeJSONResponse(w, resp, http.StatusOK)
+}
+
+// GetOrderHistory возвращает историю изменений заказа.
+// @Summary История заказа
+// @Description Возвращает все события заказа в порядке записи: создание, обновления с измененными полями, смены статуса, сбросы кэша. Для каждого события указаны инициатор (actor) и источник (source): сообщение Kafka (kafka:topic/partition/offset) или HTTP-запрос (http:request_id).
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param order_id path string true "ID заказа"
+// @Success 200 {object} dto.OrderHistoryResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /orders/{order_id}/history [get]
+func (h *Handler) GetOrderHistory(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	const op = "Handler.GetOrderHistory"
+
+	resp, err := h.orderService.GetOrderHistory(r.Context(), chi.URLParam(r, "order_id"))
+	if err != nil {
+		logger.Log.Error(op, "Failed to get order history", err)
+		if errors.Is(err, postgres.ErrOrderNotFound) {
+			h.writeErrorResponse(w, "Order not found", http.StatusNotFound)
+			return
+		}
+		h.writeErrorResponse(w, "Failed to get order history", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSONResponse(w, resp, http.StatusOK)
+}
+
+func parseListOrdersRequest(q url.Values) (*dto.ListOrdersRequest, error) {
+	req := &dto.ListOrdersRequest{
+		CustomerID:      q.Get("customer_id"),
+		TrackNumber:     q.Get("track_number"),
+		DeliveryService: q.Get("delivery_service"),
+		Locale:          q.Get("locale"),
+		Currency:        q.Get("currency"),
+		Provider:        q.Get("provider"),
+		Cursor:          q.Get("cursor"),
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("limit: %w", err)
+		}
+		req.Limit = limit
+	}
+	for name, dst := range map[string]**time.Time{
+		"created_from": &req.CreatedFrom,
+		"created_to":   &req.CreatedTo,
+	} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = &t
+		}
+	}
+
+	return req, nil
+}
+
 func (h *Handler) writeJSONResponse(w http.ResponseWriter, data interface{}, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
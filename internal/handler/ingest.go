@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/service"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+type IngestOptions struct {
+	// MaxBodyBytes ограничивает размер тела запроса приема заказов.
+	MaxBodyBytes int64
+	// MaxBatchSize — сколько заказов можно передать в POST /orders:batch.
+	MaxBatchSize int
+}
+
+var defaultIngestOptions = IngestOptions{
+	MaxBodyBytes: 10 << 20,
+	MaxBatchSize: 1000,
+}
+
+func (h *Handler) SetIngestOptions(opts IngestOptions) {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaultIngestOptions.MaxBodyBytes
+	}
+	if opts.MaxBatchSize <= 0 {
+		opts.MaxBatchSize = defaultIngestOptions.MaxBatchSize
+	}
+	h.ingest = opts
+}
+
+// SubmitOrder принимает один заказ.
+// @Summary Создать заказ
+// @Description Сохраняет заказ тем же путем, что и сообщение Kafka: те же проверки, политика конфликтов и история. Повтор заказа с тем же содержимым — не ошибка. С заголовком Idempotency-Key повтор запроса получает сохраненный ответ с заголовком Idempotent-Replayed: true.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности (до 255 символов)"
+// @Param request body dto.OrderRequest true "Заказ"
+// @Success 201 {object} dto.SubmitOrderResult
+// @Failure 400 {object} dto.SubmitOrderResult
+// @Failure 409 {object} dto.SubmitOrderResult
+// @Failure 413 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.SubmitOrderResult
+// @Router /orders [post]
+func (h *Handler) SubmitOrder(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	const op = "Handler.SubmitOrder"
+
+	body, ok := h.readIngestBody(w, r)
+	if !ok {
+		return
+	}
+
+	resp, ok := h.submitOrders(w, r, [][]byte{body})
+	if !ok {
+		return
+	}
+
+	result := resp.Results[0]
+	status := http.StatusCreated
+	switch {
+	case result.Status == dto.SubmitStatusFailed:
+		logger.Log.Error(op, "Failed to submit order, order_id: ", result.OrderUID)
+		status = http.StatusServiceUnavailable
+	case result.Kind == apperrors.ErrConflict.Error():
+		status = http.StatusConflict
+	case result.Status == dto.SubmitStatusRejected:
+		status = http.StatusBadRequest
+	}
+	h.writeJSONResponse(w, result, status)
+}
+
+// SubmitOrders принимает пачку заказов.
+// @Summary Создать заказы пачкой
+// @Description Принимает JSON-массив заказов или NDJSON (по заказу в строке) и сохраняет их тем же путем, что и пачку сообщений Kafka. Ответ 200 содержит результат по каждому заказу: accepted, rejected (невалиден или конфликтует, повтор не поможет) или failed (временный сбой, заказ можно отправить снова). С заголовком Idempotency-Key повтор запроса получает сохраненный ответ; ответ с failed не сохраняется.
+// @Tags Orders
+// @Accept json
+// @Accept x-ndjson
+// @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности (до 255 символов)"
+// @Param request body []dto.OrderRequest true "Заказы"
+// @Success 200 {object} dto.SubmitOrdersResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /orders:batch [post]
+func (h *Handler) SubmitOrders(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	const op = "Handler.SubmitOrders"
+
+	body, ok := h.readIngestBody(w, r)
+	if !ok {
+		return
+	}
+
+	messages, err := splitOrders(body)
+	if err != nil {
+		logger.Log.Error(op, "Invalid request body", err)
+		h.writeErrorResponse(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(messages) == 0 {
+		h.writeErrorResponse(w, "Invalid request: no orders", http.StatusBadRequest)
+		return
+	}
+	if len(messages) > h.ingest.MaxBatchSize {
+		h.writeErrorResponse(w, fmt.Sprintf("Invalid request: more than %d orders", h.ingest.MaxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	resp, ok := h.submitOrders(w, r, messages)
+	if !ok {
+		return
+	}
+	h.writeJSONResponse(w, resp, http.StatusOK)
+}
+
+func (h *Handler) readIngestBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	const op = "Handler.readIngestBody"
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.ingest.MaxBodyBytes))
+	if err != nil {
+		logger.Log.Error(op, "Failed to read request body", err)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.writeErrorResponse(w, fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
+		h.writeErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+// submitOrders передает заказы сервису и отвечает сам, если запрос целиком не обработан.
+func (h *Handler) submitOrders(w http.ResponseWriter, r *http.Request, messages [][]byte) (*dto.SubmitOrdersResponse, bool) {
+	const op = "Handler.submitOrders"
+
+	key := r.Header.Get(idempotencyKeyHeader)
+	if len(key) > maxIdempotencyKeyLength {
+		h.writeErrorResponse(w, fmt.Sprintf("Invalid request: %s is longer than %d characters",
+			idempotencyKeyHeader, maxIdempotencyKeyLength), http.StatusBadRequest)
+		return nil, false
+	}
+
+	resp, err := h.orderService.SubmitOrders(r.Context(), key, messages)
+	if err != nil {
+		logger.Log.Error(op, "Failed to submit orders", err)
+		if errors.Is(err, service.ErrIdempotencyKeyReused) {
+			h.writeErrorResponse(w, apperrors.Reason(err), http.StatusUnprocessableEntity)
+			return nil, false
+		}
+		h.writeErrorResponse(w, "Failed to submit orders", http.StatusInternalServerError)
+		return nil, false
+	}
+	if resp.Replayed {
+		w.Header().Set(idempotentReplayedHeader, "true")
+	}
+	return resp, true
+}
+
+// splitOrders разбивает тело на заказы: JSON-массив, если тело начинается с «[», иначе
+// NDJSON. Разбор самих заказов остается сервису, чтобы невалидный заказ отклонялся
+// отдельно от остальных.
+func splitOrders(body []byte) ([][]byte, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, err
+		}
+		messages := make([][]byte, len(raw))
+		for i, message := range raw {
+			messages[i] = message
+		}
+		return messages, nil
+	}
+
+	var messages [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(nil, len(body)+1)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			messages = append(messages, bytes.Clone(line))
+		}
+	}
+	return messages, scanner.Err()
+}
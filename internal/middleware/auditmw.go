@@ -0,0 +1,18 @@
+package mw
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/zhavkk/order-service/internal/audit"
+)
+
+// AuditMiddleware помечает контекст запроса как изменение через API с request id
+// в качестве источника. Должен стоять после middleware.RequestID.
+func AuditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		source := audit.HTTPSource(middleware.GetReqID(r.Context()))
+		ctx := audit.WithOrigin(r.Context(), audit.ActorAPI, source)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
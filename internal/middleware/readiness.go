@@ -0,0 +1,22 @@
+package mw
+
+import (
+	"net/http"
+)
+
+// ReadinessGate отвечает 503, пока ready возвращает false (например, пока прогревается кэш),
+// чтобы балансировщик не отправлял запросы в холодный инстанс.
+func ReadinessGate(ready func() bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !ready() {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{"error":"service is warming up","code":503}`))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
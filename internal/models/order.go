@@ -3,20 +3,31 @@ package models
 import "time"
 
 type Order struct {
-	OrderUID          string    `json:"order_uid" db:"order_uid"`
-	TrackNumber       string    `json:"track_number" db:"track_number"`
-	Entry             string    `json:"entry" db:"entry"`
-	Delivery          Delivery  `json:"delivery"`
-	Payment           Payment   `json:"payment"`
-	Items             []Item    `json:"items"`
-	Locale            string    `json:"locale" db:"locale"`
-	InternalSignature string    `json:"internal_signature" db:"internal_signature"`
-	CustomerID        string    `json:"customer_id" db:"customer_id"`
-	DeliveryService   string    `json:"delivery_service" db:"delivery_service"`
-	ShardKey          string    `json:"shardkey" db:"shardkey"`
-	SmID              int       `json:"sm_id" db:"sm_id"`
-	DateCreated       time.Time `json:"date_created" db:"date_created"`
-	OofShard          string    `json:"oof_shard" db:"oof_shard"`
+	OrderUID          string      `json:"order_uid" db:"order_uid"`
+	TrackNumber       string      `json:"track_number" db:"track_number"`
+	Entry             string      `json:"entry" db:"entry"`
+	Delivery          Delivery    `json:"delivery"`
+	Payment           Payment     `json:"payment"`
+	Items             []Item      `json:"items"`
+	Locale            string      `json:"locale" db:"locale"`
+	InternalSignature string      `json:"internal_signature" db:"internal_signature"`
+	CustomerID        string      `json:"customer_id" db:"customer_id"`
+	DeliveryService   string      `json:"delivery_service" db:"delivery_service"`
+	ShardKey          string      `json:"shardkey" db:"shardkey"`
+	SmID              int         `json:"sm_id" db:"sm_id"`
+	DateCreated       time.Time   `json:"date_created" db:"date_created"`
+	OofShard          string      `json:"oof_shard" db:"oof_shard"`
+	Status            OrderStatus `json:"status" db:"status"`
+	ContentHash       string      `json:"-" db:"content_hash"`
+	Version           int         `json:"version" db:"version"`
+	UpdatedAt         time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// OrderVersion — то, что нужно для решения о перезаписи уже сохраненного заказа.
+type OrderVersion struct {
+	ContentHash string
+	Version     int
+	DateCreated time.Time
 }
 type Item struct {
 	ID          int    `json:"id" db:"item_id"`
@@ -59,3 +70,83 @@ type Delivery struct {
 	Region  string `json:"region" db:"region"`
 	Email   string `json:"email" db:"email"`
 }
+
+type OrderStatus string
+
+const (
+	OrderStatusCreated    OrderStatus = "created"
+	OrderStatusPaid       OrderStatus = "paid"
+	OrderStatusAssembling OrderStatus = "assembling"
+	OrderStatusShipped    OrderStatus = "shipped"
+	OrderStatusDelivered  OrderStatus = "delivered"
+	OrderStatusCancelled  OrderStatus = "cancelled"
+	OrderStatusReturned   OrderStatus = "returned"
+)
+
+// StatusChange — запись в status_history: кто, когда и почему изменил статус заказа.
+type StatusChange struct {
+	ID        int64       `json:"id" db:"id"`
+	OrderUID  string      `json:"order_uid" db:"order_uid"`
+	From      OrderStatus `json:"from_status" db:"from_status"`
+	To        OrderStatus `json:"to_status" db:"to_status"`
+	ChangedBy string      `json:"changed_by" db:"changed_by"`
+	Reason    string      `json:"reason" db:"reason"`
+	ChangedAt time.Time   `json:"changed_at" db:"changed_at"`
+}
+
+type OutboxEvent struct {
+	ID          int64      `json:"id" db:"id"`
+	AggregateID string     `json:"aggregate_id" db:"aggregate_id"`
+	EventType   string     `json:"event_type" db:"event_type"`
+	Payload     []byte     `json:"payload" db:"payload"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	SentAt      *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+}
+
+// OrderHistoryEvent — запись в order_events: что произошло с заказом, кто (Actor)
+// и откуда (Source: сообщение Kafka или HTTP-запрос) это инициировал.
+type OrderHistoryEvent struct {
+	ID        int64         `json:"id" db:"id"`
+	OrderUID  string        `json:"order_uid" db:"order_uid"`
+	EventType string        `json:"event_type" db:"event_type"`
+	Actor     string        `json:"actor" db:"actor"`
+	Source    string        `json:"source" db:"source"`
+	Changes   []FieldChange `json:"changes,omitempty" db:"changes"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+}
+
+// FieldChange — изменение одного поля. Field — путь в JSON заказа, например items[0].price.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// OrderCursor — позиция для keyset-пагинации: последний заказ предыдущей страницы.
+// Нулевая DateCreated — заказ без date_created (такие заказы идут последними).
+type OrderCursor struct {
+	DateCreated time.Time
+	OrderUID    string
+}
+
+type OrderFilter struct {
+	CustomerID      string
+	TrackNumber     string
+	DeliveryService string
+	Locale          string
+	Currency        string
+	Provider        string
+	CreatedFrom     time.Time
+	CreatedTo       time.Time
+	After           *OrderCursor
+	Limit           int
+}
+
+// IdempotencyRecord — сохраненный ответ на запрос с Idempotency-Key. RequestHash
+// отличает повтор запроса от другого запроса с тем же ключом.
+type IdempotencyRecord struct {
+	Key         string    `db:"key"`
+	RequestHash string    `db:"request_hash"`
+	Response    []byte    `db:"response"`
+	CreatedAt   time.Time `db:"created_at"`
+}
@@ -10,6 +10,7 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	cache "github.com/zhavkk/order-service/pkg/cache"
 )
 
 // MockCache is a mock of Cache interface.
@@ -49,6 +50,21 @@ func (mr *MockCacheMockRecorder) Delete(ctx, key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCache)(nil).Delete), ctx, key)
 }
 
+// DeleteByPattern mocks base method.
+func (m *MockCache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByPattern", ctx, pattern)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByPattern indicates an expected call of DeleteByPattern.
+func (mr *MockCacheMockRecorder) DeleteByPattern(ctx, pattern interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByPattern", reflect.TypeOf((*MockCache)(nil).DeleteByPattern), ctx, pattern)
+}
+
 // Get mocks base method.
 func (m *MockCache) Get(ctx context.Context, key string, destination any) error {
 	m.ctrl.T.Helper()
@@ -76,3 +92,18 @@ func (mr *MockCacheMockRecorder) Set(ctx, key, value, ttl interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCache)(nil).Set), ctx, key, value, ttl)
 }
+
+// Stats mocks base method.
+func (m *MockCache) Stats(ctx context.Context) (cache.Stats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats", ctx)
+	ret0, _ := ret[0].(cache.Stats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockCacheMockRecorder) Stats(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockCache)(nil).Stats), ctx)
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	models "github.com/zhavkk/order-service/internal/models"
@@ -49,6 +50,20 @@ func (mr *MockOrderRepositoryMockRecorder) CreateOrder(ctx, order interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderRepository)(nil).CreateOrder), ctx, order)
 }
 
+// CreateOrders mocks base method.
+func (m *MockOrderRepository) CreateOrders(ctx context.Context, orders []*models.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrders", ctx, orders)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOrders indicates an expected call of CreateOrders.
+func (mr *MockOrderRepositoryMockRecorder) CreateOrders(ctx, orders interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrders", reflect.TypeOf((*MockOrderRepository)(nil).CreateOrders), ctx, orders)
+}
+
 // GetOrderByID mocks base method.
 func (m *MockOrderRepository) GetOrderByID(ctx context.Context, orderID string) (*models.Order, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +79,66 @@ func (mr *MockOrderRepositoryMockRecorder) GetOrderByID(ctx, orderID interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockOrderRepository)(nil).GetOrderByID), ctx, orderID)
 }
 
+// GetOrderUIDsCreatedSince mocks base method.
+func (m *MockOrderRepository) GetOrderUIDsCreatedSince(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderUIDsCreatedSince", ctx, since, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderUIDsCreatedSince indicates an expected call of GetOrderUIDsCreatedSince.
+func (mr *MockOrderRepositoryMockRecorder) GetOrderUIDsCreatedSince(ctx, since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderUIDsCreatedSince", reflect.TypeOf((*MockOrderRepository)(nil).GetOrderUIDsCreatedSince), ctx, since, limit)
+}
+
+// GetOrderVersion mocks base method.
+func (m *MockOrderRepository) GetOrderVersion(ctx context.Context, orderUID string) (*models.OrderVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderVersion", ctx, orderUID)
+	ret0, _ := ret[0].(*models.OrderVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderVersion indicates an expected call of GetOrderVersion.
+func (mr *MockOrderRepositoryMockRecorder) GetOrderVersion(ctx, orderUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderVersion", reflect.TypeOf((*MockOrderRepository)(nil).GetOrderVersion), ctx, orderUID)
+}
+
+// GetOrdersByIDs mocks base method.
+func (m *MockOrderRepository) GetOrdersByIDs(ctx context.Context, orderIDs []string) ([]*models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersByIDs", ctx, orderIDs)
+	ret0, _ := ret[0].([]*models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersByIDs indicates an expected call of GetOrdersByIDs.
+func (mr *MockOrderRepositoryMockRecorder) GetOrdersByIDs(ctx, orderIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByIDs", reflect.TypeOf((*MockOrderRepository)(nil).GetOrdersByIDs), ctx, orderIDs)
+}
+
+// GetRecentOrderUIDs mocks base method.
+func (m *MockOrderRepository) GetRecentOrderUIDs(ctx context.Context, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecentOrderUIDs", ctx, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecentOrderUIDs indicates an expected call of GetRecentOrderUIDs.
+func (mr *MockOrderRepositoryMockRecorder) GetRecentOrderUIDs(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecentOrderUIDs", reflect.TypeOf((*MockOrderRepository)(nil).GetRecentOrderUIDs), ctx, limit)
+}
+
 // GetRecentOrders mocks base method.
 func (m *MockOrderRepository) GetRecentOrders(ctx context.Context, limit int) ([]*models.Order, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +154,78 @@ func (mr *MockOrderRepositoryMockRecorder) GetRecentOrders(ctx, limit interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecentOrders", reflect.TypeOf((*MockOrderRepository)(nil).GetRecentOrders), ctx, limit)
 }
 
+// GetStatusForUpdate mocks base method.
+func (m *MockOrderRepository) GetStatusForUpdate(ctx context.Context, orderUID string) (models.OrderStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatusForUpdate", ctx, orderUID)
+	ret0, _ := ret[0].(models.OrderStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatusForUpdate indicates an expected call of GetStatusForUpdate.
+func (mr *MockOrderRepositoryMockRecorder) GetStatusForUpdate(ctx, orderUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatusForUpdate", reflect.TypeOf((*MockOrderRepository)(nil).GetStatusForUpdate), ctx, orderUID)
+}
+
+// ListOrders mocks base method.
+func (m *MockOrderRepository) ListOrders(ctx context.Context, filter models.OrderFilter) ([]*models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", ctx, filter)
+	ret0, _ := ret[0].([]*models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockOrderRepositoryMockRecorder) ListOrders(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockOrderRepository)(nil).ListOrders), ctx, filter)
+}
+
+// SetContentHash mocks base method.
+func (m *MockOrderRepository) SetContentHash(ctx context.Context, orderUID, contentHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetContentHash", ctx, orderUID, contentHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetContentHash indicates an expected call of SetContentHash.
+func (mr *MockOrderRepositoryMockRecorder) SetContentHash(ctx, orderUID, contentHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetContentHash", reflect.TypeOf((*MockOrderRepository)(nil).SetContentHash), ctx, orderUID, contentHash)
+}
+
+// UpdateOrder mocks base method.
+func (m *MockOrderRepository) UpdateOrder(ctx context.Context, order *models.Order, expectedVersion int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrder", ctx, order, expectedVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrder indicates an expected call of UpdateOrder.
+func (mr *MockOrderRepositoryMockRecorder) UpdateOrder(ctx, order, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrder", reflect.TypeOf((*MockOrderRepository)(nil).UpdateOrder), ctx, order, expectedVersion)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, change *models.StatusChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockOrderRepositoryMockRecorder) UpdateStatus(ctx, change interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateStatus), ctx, change)
+}
+
 // MockDeliveryRepository is a mock of DeliveryRepository interface.
 type MockDeliveryRepository struct {
 	ctrl     *gomock.Controller
@@ -102,6 +249,20 @@ func (m *MockDeliveryRepository) EXPECT() *MockDeliveryRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CreateDeliveries mocks base method.
+func (m *MockDeliveryRepository) CreateDeliveries(ctx context.Context, deliveries []*models.Delivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDeliveries", ctx, deliveries)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDeliveries indicates an expected call of CreateDeliveries.
+func (mr *MockDeliveryRepositoryMockRecorder) CreateDeliveries(ctx, deliveries interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDeliveries", reflect.TypeOf((*MockDeliveryRepository)(nil).CreateDeliveries), ctx, deliveries)
+}
+
 // CreateDelivery mocks base method.
 func (m *MockDeliveryRepository) CreateDelivery(ctx context.Context, delivery *models.Delivery) error {
 	m.ctrl.T.Helper()
@@ -168,6 +329,34 @@ func (mr *MockPaymentRepositoryMockRecorder) CreatePayment(ctx, payment interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayment", reflect.TypeOf((*MockPaymentRepository)(nil).CreatePayment), ctx, payment)
 }
 
+// CreatePayments mocks base method.
+func (m *MockPaymentRepository) CreatePayments(ctx context.Context, payments []*models.Payment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayments", ctx, payments)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePayments indicates an expected call of CreatePayments.
+func (mr *MockPaymentRepositoryMockRecorder) CreatePayments(ctx, payments interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayments", reflect.TypeOf((*MockPaymentRepository)(nil).CreatePayments), ctx, payments)
+}
+
+// ReplacePayment mocks base method.
+func (m *MockPaymentRepository) ReplacePayment(ctx context.Context, payment *models.Payment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplacePayment", ctx, payment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplacePayment indicates an expected call of ReplacePayment.
+func (mr *MockPaymentRepositoryMockRecorder) ReplacePayment(ctx, payment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplacePayment", reflect.TypeOf((*MockPaymentRepository)(nil).ReplacePayment), ctx, payment)
+}
+
 // MockItemsRepository is a mock of ItemsRepository interface.
 type MockItemsRepository struct {
 	ctrl     *gomock.Controller
@@ -205,6 +394,20 @@ func (mr *MockItemsRepositoryMockRecorder) AddItems(ctx, orderID, items interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddItems", reflect.TypeOf((*MockItemsRepository)(nil).AddItems), ctx, orderID, items)
 }
 
+// AddItemsBatch mocks base method.
+func (m *MockItemsRepository) AddItemsBatch(ctx context.Context, items []*models.Item) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddItemsBatch", ctx, items)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddItemsBatch indicates an expected call of AddItemsBatch.
+func (mr *MockItemsRepositoryMockRecorder) AddItemsBatch(ctx, items interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddItemsBatch", reflect.TypeOf((*MockItemsRepository)(nil).AddItemsBatch), ctx, items)
+}
+
 // GetItemsByOrderID mocks base method.
 func (m *MockItemsRepository) GetItemsByOrderID(ctx context.Context, orderID string) ([]*models.Item, error) {
 	m.ctrl.T.Helper()
@@ -219,3 +422,226 @@ func (mr *MockItemsRepositoryMockRecorder) GetItemsByOrderID(ctx, orderID interf
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemsByOrderID", reflect.TypeOf((*MockItemsRepository)(nil).GetItemsByOrderID), ctx, orderID)
 }
+
+// MockOutboxRepository is a mock of OutboxRepository interface.
+type MockOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxRepositoryMockRecorder
+}
+
+// MockOutboxRepositoryMockRecorder is the mock recorder for MockOutboxRepository.
+type MockOutboxRepositoryMockRecorder struct {
+	mock *MockOutboxRepository
+}
+
+// NewMockOutboxRepository creates a new mock instance.
+func NewMockOutboxRepository(ctrl *gomock.Controller) *MockOutboxRepository {
+	mock := &MockOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxRepository) EXPECT() *MockOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddEvents mocks base method.
+func (m *MockOutboxRepository) AddEvents(ctx context.Context, events []*models.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddEvents", ctx, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddEvents indicates an expected call of AddEvents.
+func (mr *MockOutboxRepositoryMockRecorder) AddEvents(ctx, events interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddEvents", reflect.TypeOf((*MockOutboxRepository)(nil).AddEvents), ctx, events)
+}
+
+// MockHistoryRepository is a mock of HistoryRepository interface.
+type MockHistoryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockHistoryRepositoryMockRecorder
+}
+
+// MockHistoryRepositoryMockRecorder is the mock recorder for MockHistoryRepository.
+type MockHistoryRepositoryMockRecorder struct {
+	mock *MockHistoryRepository
+}
+
+// NewMockHistoryRepository creates a new mock instance.
+func NewMockHistoryRepository(ctrl *gomock.Controller) *MockHistoryRepository {
+	mock := &MockHistoryRepository{ctrl: ctrl}
+	mock.recorder = &MockHistoryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHistoryRepository) EXPECT() *MockHistoryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddEvents mocks base method.
+func (m *MockHistoryRepository) AddEvents(ctx context.Context, events []*models.OrderHistoryEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddEvents", ctx, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddEvents indicates an expected call of AddEvents.
+func (mr *MockHistoryRepositoryMockRecorder) AddEvents(ctx, events interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddEvents", reflect.TypeOf((*MockHistoryRepository)(nil).AddEvents), ctx, events)
+}
+
+// GetHistory mocks base method.
+func (m *MockHistoryRepository) GetHistory(ctx context.Context, orderUID string) ([]*models.OrderHistoryEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, orderUID)
+	ret0, _ := ret[0].([]*models.OrderHistoryEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockHistoryRepositoryMockRecorder) GetHistory(ctx, orderUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockHistoryRepository)(nil).GetHistory), ctx, orderUID)
+}
+
+// MockAccessRepository is a mock of AccessRepository interface.
+type MockAccessRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessRepositoryMockRecorder
+}
+
+// MockAccessRepositoryMockRecorder is the mock recorder for MockAccessRepository.
+type MockAccessRepositoryMockRecorder struct {
+	mock *MockAccessRepository
+}
+
+// NewMockAccessRepository creates a new mock instance.
+func NewMockAccessRepository(ctrl *gomock.Controller) *MockAccessRepository {
+	mock := &MockAccessRepository{ctrl: ctrl}
+	mock.recorder = &MockAccessRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessRepository) EXPECT() *MockAccessRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddAccessCounts mocks base method.
+func (m *MockAccessRepository) AddAccessCounts(ctx context.Context, bucket time.Time, counts map[string]int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccessCounts", ctx, bucket, counts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAccessCounts indicates an expected call of AddAccessCounts.
+func (mr *MockAccessRepositoryMockRecorder) AddAccessCounts(ctx, bucket, counts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccessCounts", reflect.TypeOf((*MockAccessRepository)(nil).AddAccessCounts), ctx, bucket, counts)
+}
+
+// DeleteAccessCountsBefore mocks base method.
+func (m *MockAccessRepository) DeleteAccessCountsBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccessCountsBefore", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAccessCountsBefore indicates an expected call of DeleteAccessCountsBefore.
+func (mr *MockAccessRepositoryMockRecorder) DeleteAccessCountsBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccessCountsBefore", reflect.TypeOf((*MockAccessRepository)(nil).DeleteAccessCountsBefore), ctx, before)
+}
+
+// GetMostAccessedOrderUIDs mocks base method.
+func (m *MockAccessRepository) GetMostAccessedOrderUIDs(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMostAccessedOrderUIDs", ctx, since, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMostAccessedOrderUIDs indicates an expected call of GetMostAccessedOrderUIDs.
+func (mr *MockAccessRepositoryMockRecorder) GetMostAccessedOrderUIDs(ctx, since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMostAccessedOrderUIDs", reflect.TypeOf((*MockAccessRepository)(nil).GetMostAccessedOrderUIDs), ctx, since, limit)
+}
+
+// MockIdempotencyRepository is a mock of IdempotencyRepository interface.
+type MockIdempotencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyRepositoryMockRecorder
+}
+
+// MockIdempotencyRepositoryMockRecorder is the mock recorder for MockIdempotencyRepository.
+type MockIdempotencyRepositoryMockRecorder struct {
+	mock *MockIdempotencyRepository
+}
+
+// NewMockIdempotencyRepository creates a new mock instance.
+func NewMockIdempotencyRepository(ctrl *gomock.Controller) *MockIdempotencyRepository {
+	mock := &MockIdempotencyRepository{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyRepository) EXPECT() *MockIdempotencyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteIdempotencyRecordsBefore mocks base method.
+func (m *MockIdempotencyRepository) DeleteIdempotencyRecordsBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyRecordsBefore", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteIdempotencyRecordsBefore indicates an expected call of DeleteIdempotencyRecordsBefore.
+func (mr *MockIdempotencyRepositoryMockRecorder) DeleteIdempotencyRecordsBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyRecordsBefore", reflect.TypeOf((*MockIdempotencyRepository)(nil).DeleteIdempotencyRecordsBefore), ctx, before)
+}
+
+// GetIdempotencyRecord mocks base method.
+func (m *MockIdempotencyRepository) GetIdempotencyRecord(ctx context.Context, key string, since time.Time) (*models.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyRecord", ctx, key, since)
+	ret0, _ := ret[0].(*models.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyRecord indicates an expected call of GetIdempotencyRecord.
+func (mr *MockIdempotencyRepositoryMockRecorder) GetIdempotencyRecord(ctx, key, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyRecord", reflect.TypeOf((*MockIdempotencyRepository)(nil).GetIdempotencyRecord), ctx, key, since)
+}
+
+// SaveIdempotencyRecord mocks base method.
+func (m *MockIdempotencyRepository) SaveIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord, expiredBefore time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIdempotencyRecord", ctx, record, expiredBefore)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveIdempotencyRecord indicates an expected call of SaveIdempotencyRecord.
+func (mr *MockIdempotencyRepositoryMockRecorder) SaveIdempotencyRecord(ctx, record, expiredBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIdempotencyRecord", reflect.TypeOf((*MockIdempotencyRepository)(nil).SaveIdempotencyRecord), ctx, record, expiredBefore)
+}
@@ -89,3 +89,17 @@ func (mr *MockTxManagerInterfaceMockRecorder) RunSerializable(ctx, f interface{}
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunSerializable", reflect.TypeOf((*MockTxManagerInterface)(nil).RunSerializable), ctx, f)
 }
+
+// RunSerializableWithRetry mocks base method.
+func (m *MockTxManagerInterface) RunSerializableWithRetry(ctx context.Context, f func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunSerializableWithRetry", ctx, f)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunSerializableWithRetry indicates an expected call of RunSerializableWithRetry.
+func (mr *MockTxManagerInterfaceMockRecorder) RunSerializableWithRetry(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunSerializableWithRetry", reflect.TypeOf((*MockTxManagerInterface)(nil).RunSerializableWithRetry), ctx, f)
+}
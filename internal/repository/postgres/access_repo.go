@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/zhavkk/order-service/pkg/pgstorage"
+)
+
+// AccessRepository хранит почасовые счетчики чтений заказов (order_access_counts).
+type AccessRepository struct {
+	storage *pgstorage.Storage
+}
+
+func NewAccessRepository(storage *pgstorage.Storage) *AccessRepository {
+	return &AccessRepository{
+		storage: storage,
+	}
+}
+
+// AddAccessCounts прибавляет счетчики к часу bucket.
+func (r *AccessRepository) AddAccessCounts(ctx context.Context, bucket time.Time, counts map[string]int64) error {
+	const op = "AccessRepository.AddAccessCounts"
+
+	orderUIDs := make([]string, 0, len(counts))
+	hits := make([]int64, 0, len(counts))
+	for orderUID, n := range counts {
+		orderUIDs = append(orderUIDs, orderUID)
+		hits = append(hits, n)
+	}
+
+	query := `
+	INSERT INTO order_access_counts (order_uid, bucket, hits)
+	SELECT order_uid, $1, hits
+	  FROM unnest($2::varchar[], $3::bigint[]) AS t(order_uid, hits)
+	    ON CONFLICT (order_uid, bucket) DO UPDATE SET hits = order_access_counts.hits + EXCLUDED.hits
+	`
+	_, err := r.storage.GetPool().Exec(ctx, query, bucket, orderUIDs, hits)
+	return pgstorage.ClassifyError(op, err)
+}
+
+// GetMostAccessedOrderUIDs возвращает заказы, которые чаще всего читали начиная с since.
+func (r *AccessRepository) GetMostAccessedOrderUIDs(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	const op = "AccessRepository.GetMostAccessedOrderUIDs"
+
+	query := `
+	SELECT order_uid
+	  FROM order_access_counts
+	 WHERE bucket >= $1
+	 GROUP BY order_uid
+	 ORDER BY SUM(hits) DESC, order_uid
+	 LIMIT $2
+	`
+	rows, err := r.storage.GetPool().Query(ctx, query, since, limit)
+	if err != nil {
+		return nil, pgstorage.ClassifyError(op, err)
+	}
+	defer rows.Close()
+
+	var orderUIDs []string
+	for rows.Next() {
+		var orderUID string
+		if err := rows.Scan(&orderUID); err != nil {
+			return nil, pgstorage.ClassifyError(op, err)
+		}
+		orderUIDs = append(orderUIDs, orderUID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pgstorage.ClassifyError(op, err)
+	}
+
+	return orderUIDs, nil
+}
+
+// DeleteAccessCountsBefore удаляет счетчики старше before и возвращает число удаленных строк.
+func (r *AccessRepository) DeleteAccessCountsBefore(ctx context.Context, before time.Time) (int64, error) {
+	const op = "AccessRepository.DeleteAccessCountsBefore"
+
+	tag, err := r.storage.GetPool().Exec(ctx, `DELETE FROM order_access_counts WHERE bucket < $1`, before)
+	if err != nil {
+		return 0, pgstorage.ClassifyError(op, err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -2,8 +2,8 @@ package postgres
 
 import (
 	"context"
-	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/zhavkk/order-service/internal/logger"
 	"github.com/zhavkk/order-service/internal/models"
 	"github.com/zhavkk/order-service/pkg/pgstorage"
@@ -11,20 +11,29 @@ import (
 )
 
 type DeliveryRepository struct {
-	storage    *pgstorage.Storage
-	retryCount int
-	backoff    time.Duration
+	storage *pgstorage.Storage
+	retry   utils.RetryPolicy
 }
 
-func NewDeliveryRepository(storage *pgstorage.Storage, retryCount int, backoff time.Duration) *DeliveryRepository {
+func NewDeliveryRepository(storage *pgstorage.Storage, retry utils.RetryPolicy) *DeliveryRepository {
 	return &DeliveryRepository{
-		storage:    storage,
-		retryCount: retryCount,
-		backoff:    backoff,
+		storage: storage,
+		retry:   retry,
 	}
 }
 
 func (r *DeliveryRepository) GetDeliveryByOrderID(ctx context.Context, orderID string) (*models.Delivery, error) {
+	var delivery *models.Delivery
+	err := r.retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		delivery, err = r.getDeliveryByOrderID(ctx, orderID)
+		return err
+	})
+	return delivery, err
+}
+
+func (r *DeliveryRepository) getDeliveryByOrderID(ctx context.Context, orderID string) (*models.Delivery, error) {
+	const op = "DeliveryRepository.GetDeliveryByOrderID"
 	query := `
         SELECT delivery_id, order_uid, name, phone, zip, city, address, region, email
         FROM delivery
@@ -43,32 +52,59 @@ func (r *DeliveryRepository) GetDeliveryByOrderID(ctx context.Context, orderID s
 		&delivery.Email,
 	)
 	if err != nil {
-		return nil, err
+		return nil, pgstorage.ClassifyError(op, err)
 	}
 	return &delivery, nil
 }
 
+// CreateDelivery сохраняет доставку заказа; у заказа одна доставка, поэтому существующая перезаписывается.
 func (r *DeliveryRepository) CreateDelivery(ctx context.Context, delivery *models.Delivery) error {
 	const op = "DeliveryRepository.CreateDelivery"
 
-	return utils.RetryWithBackoff(func() error {
-		query := `INSERT INTO delivery (order_uid, name, phone, zip, city, address, region, email)
-	 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	query := `INSERT INTO delivery (order_uid, name, phone, zip, city, address, region, email)
+	 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	 ON CONFLICT (order_uid) DO UPDATE SET
+	 name = EXCLUDED.name, phone = EXCLUDED.phone, zip = EXCLUDED.zip, city = EXCLUDED.city,
+	 address = EXCLUDED.address, region = EXCLUDED.region, email = EXCLUDED.email`
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		logger.Log.Error(op, "No transaction found in context", nil)
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, query, delivery.OrderID, delivery.Name, delivery.Phone, delivery.Zip,
+		delivery.City, delivery.Address, delivery.Region, delivery.Email)
+	if err != nil {
+		logger.Log.Error(op, "Failed to create delivery", err)
+		return pgstorage.ClassifyError(op, err)
+	}
+
+	logger.Log.Info(op, "Delivery created successfully, order_uid: ", delivery.OrderID)
+	return nil
+}
+
+func (r *DeliveryRepository) CreateDeliveries(ctx context.Context, deliveries []*models.Delivery) error {
+	const op = "DeliveryRepository.CreateDeliveries"
 
-		tx, ok := pgstorage.GetTxFromContext(ctx)
-		if !ok {
-			logger.Log.Error(op, "No transaction found in context", nil)
-			return ErrNoTransaction
-		}
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		logger.Log.Error(op, "No transaction found in context", nil)
+		return ErrNoTransaction
+	}
 
-		_, err := tx.Exec(ctx, query, delivery.OrderID, delivery.Name, delivery.Phone, delivery.Zip,
-			delivery.City, delivery.Address, delivery.Region, delivery.Email)
-		if err != nil {
-			logger.Log.Error(op, "Failed to create delivery", err)
-			return err
-		}
+	columns := []string{"order_uid", "name", "phone", "zip", "city", "address", "region", "email"}
+	copied, err := tx.CopyFrom(ctx, pgx.Identifier{"delivery"}, columns,
+		pgx.CopyFromSlice(len(deliveries), func(i int) ([]any, error) {
+			d := deliveries[i]
+			return []any{d.OrderID, d.Name, d.Phone, d.Zip, d.City, d.Address, d.Region, d.Email}, nil
+		}),
+	)
+	if err != nil {
+		logger.Log.Error(op, "Failed to copy deliveries", err)
+		return pgstorage.ClassifyError(op, err)
+	}
 
-		logger.Log.Info(op, "Delivery created successfully, order_uid: ", delivery.OrderID)
-		return nil
-	}, r.retryCount, r.backoff)
+	logger.Log.Info(op, "Deliveries copied successfully, count: ", copied)
+	return nil
 }
@@ -5,4 +5,9 @@ import "errors"
 var (
 	ErrNoTransaction = errors.New("no transaction found")
 	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderExists   = errors.New("order already exists")
+	// ErrVersionConflict — заказ изменился после чтения версии (оптимистичная блокировка).
+	ErrVersionConflict = errors.New("order version conflict")
+	// ErrIdempotencyKeyNotFound — ключа нет или он истек.
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
 )
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/pkg/pgstorage"
+)
+
+// HistoryRepository хранит историю заказов (order_events). Таблица только дописывается.
+type HistoryRepository struct {
+	storage *pgstorage.Storage
+}
+
+func NewHistoryRepository(storage *pgstorage.Storage) *HistoryRepository {
+	return &HistoryRepository{
+		storage: storage,
+	}
+}
+
+// AddEvents записывает события в транзакции из контекста, если она есть: события
+// об изменении заказа должны сохраняться атомарно с самим изменением.
+func (r *HistoryRepository) AddEvents(ctx context.Context, events []*models.OrderHistoryEvent) error {
+	const op = "HistoryRepository.AddEvents"
+
+	rows := make([][]any, len(events))
+	for i, e := range events {
+		var changes []byte
+		if len(e.Changes) > 0 {
+			var err error
+			if changes, err = json.Marshal(e.Changes); err != nil {
+				return err
+			}
+		}
+		rows[i] = []any{e.OrderUID, e.EventType, e.Actor, e.Source, changes}
+	}
+
+	columns := []string{"order_uid", "event_type", "actor", "source", "changes"}
+	var err error
+	if tx, ok := pgstorage.GetTxFromContext(ctx); ok {
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{"order_events"}, columns, pgx.CopyFromRows(rows))
+	} else {
+		_, err = r.storage.GetPool().CopyFrom(ctx, pgx.Identifier{"order_events"}, columns, pgx.CopyFromRows(rows))
+	}
+	if err != nil {
+		logger.Log.Error(op, "Failed to add order events", err)
+		return pgstorage.ClassifyError(op, err)
+	}
+
+	return nil
+}
+
+// GetHistory возвращает события заказа в порядке записи.
+func (r *HistoryRepository) GetHistory(ctx context.Context, orderUID string) ([]*models.OrderHistoryEvent, error) {
+	const op = "HistoryRepository.GetHistory"
+
+	query := `
+	SELECT id, order_uid, event_type, actor, source, changes, created_at
+	  FROM order_events
+	 WHERE order_uid = $1
+	 ORDER BY id
+	`
+	rows, err := r.storage.GetPool().Query(ctx, query, orderUID)
+	if err != nil {
+		return nil, pgstorage.ClassifyError(op, err)
+	}
+	defer rows.Close()
+
+	var events []*models.OrderHistoryEvent
+	for rows.Next() {
+		var (
+			e       models.OrderHistoryEvent
+			changes []byte
+		)
+		if err := rows.Scan(&e.ID, &e.OrderUID, &e.EventType, &e.Actor, &e.Source, &changes, &e.CreatedAt); err != nil {
+			return nil, pgstorage.ClassifyError(op, err)
+		}
+		if changes != nil {
+			if err := json.Unmarshal(changes, &e.Changes); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pgstorage.ClassifyError(op, err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/pkg/pgstorage"
+)
+
+// IdempotencyRepository хранит ответы на запросы с Idempotency-Key (idempotency_keys).
+type IdempotencyRepository struct {
+	storage *pgstorage.Storage
+}
+
+func NewIdempotencyRepository(storage *pgstorage.Storage) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		storage: storage,
+	}
+}
+
+// GetIdempotencyRecord возвращает ответ, сохраненный по ключу не раньше since.
+func (r *IdempotencyRepository) GetIdempotencyRecord(ctx context.Context, key string, since time.Time) (*models.IdempotencyRecord, error) {
+	const op = "IdempotencyRepository.GetIdempotencyRecord"
+
+	query := `
+	SELECT key, request_hash, response, created_at
+	  FROM idempotency_keys
+	 WHERE key = $1 AND created_at >= $2
+	`
+	var record models.IdempotencyRecord
+	err := r.storage.GetPool().QueryRow(ctx, query, key, since).Scan(
+		&record.Key, &record.RequestHash, &record.Response, &record.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrIdempotencyKeyNotFound
+	}
+	if err != nil {
+		return nil, pgstorage.ClassifyError(op, err)
+	}
+	return &record, nil
+}
+
+// SaveIdempotencyRecord сохраняет ответ. Запись, созданная раньше expiredBefore,
+// перезаписывается; действующая остается: ответ по ключу уже отдан первому запросу.
+func (r *IdempotencyRepository) SaveIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord, expiredBefore time.Time) error {
+	const op = "IdempotencyRepository.SaveIdempotencyRecord"
+
+	query := `
+	INSERT INTO idempotency_keys (key, request_hash, response)
+	VALUES ($1, $2, $3)
+	    ON CONFLICT (key) DO UPDATE
+	   SET request_hash = EXCLUDED.request_hash, response = EXCLUDED.response, created_at = now()
+	 WHERE idempotency_keys.created_at < $4
+	`
+	_, err := r.storage.GetPool().Exec(ctx, query, record.Key, record.RequestHash, record.Response, expiredBefore)
+	return pgstorage.ClassifyError(op, err)
+}
+
+// DeleteIdempotencyRecordsBefore удаляет ответы старше before и возвращает число удаленных строк.
+func (r *IdempotencyRepository) DeleteIdempotencyRecordsBefore(ctx context.Context, before time.Time) (int64, error) {
+	const op = "IdempotencyRepository.DeleteIdempotencyRecordsBefore"
+
+	tag, err := r.storage.GetPool().Exec(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, pgstorage.ClassifyError(op, err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -2,8 +2,8 @@ package postgres
 
 import (
 	"context"
-	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/zhavkk/order-service/internal/logger"
 	"github.com/zhavkk/order-service/internal/models"
 	"github.com/zhavkk/order-service/pkg/pgstorage"
@@ -11,20 +11,29 @@ import (
 )
 
 type ItemRepository struct {
-	storage    *pgstorage.Storage
-	retryCount int
-	backoff    time.Duration
+	storage *pgstorage.Storage
+	retry   utils.RetryPolicy
 }
 
-func NewItemRepository(storage *pgstorage.Storage, retryCount int, backoff time.Duration) *ItemRepository {
+func NewItemRepository(storage *pgstorage.Storage, retry utils.RetryPolicy) *ItemRepository {
 	return &ItemRepository{
-		storage:    storage,
-		retryCount: retryCount,
-		backoff:    backoff,
+		storage: storage,
+		retry:   retry,
 	}
 }
 
 func (r *ItemRepository) GetItemsByOrderID(ctx context.Context, orderID string) ([]*models.Item, error) {
+	var items []*models.Item
+	err := r.retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		items, err = r.getItemsByOrderID(ctx, orderID)
+		return err
+	})
+	return items, err
+}
+
+func (r *ItemRepository) getItemsByOrderID(ctx context.Context, orderID string) ([]*models.Item, error) {
+	const op = "ItemRepository.GetItemsByOrderID"
 	query := `
         SELECT item_id, order_uid, chrt_id, track_number, price, rid, name,
                sale, size, total_price, nm_id, brand, status
@@ -33,7 +42,7 @@ func (r *ItemRepository) GetItemsByOrderID(ctx context.Context, orderID string)
     `
 	rows, err := r.storage.GetPool().Query(ctx, query, orderID)
 	if err != nil {
-		return nil, err
+		return nil, pgstorage.ClassifyError(op, err)
 	}
 	defer rows.Close()
 
@@ -45,12 +54,12 @@ func (r *ItemRepository) GetItemsByOrderID(ctx context.Context, orderID string)
 			&item.Name, &item.Sale, &item.Size, &item.TotalPrice, &item.NmId,
 			&item.Brand, &item.Status,
 		); err != nil {
-			return nil, err
+			return nil, pgstorage.ClassifyError(op, err)
 		}
 		items = append(items, &item)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, pgstorage.ClassifyError(op, err)
 	}
 	return items, nil
 }
@@ -58,41 +67,84 @@ func (r *ItemRepository) GetItemsByOrderID(ctx context.Context, orderID string)
 func (r *ItemRepository) AddItems(ctx context.Context, orderID string, items []*models.Item) error {
 	const op = "ItemRepository.AddItemsToOrder"
 
-	return utils.RetryWithBackoff(func() error {
-		query := `INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status)
+	query := `INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status)
 	 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
 
-		tx, ok := pgstorage.GetTxFromContext(ctx)
-		if !ok {
-			logger.Log.Error(op, "No transaction found in context", nil)
-			return ErrNoTransaction
-		}
-		if _, err := tx.Exec(ctx, `DELETE FROM items WHERE order_uid = $1`, orderID); err != nil {
-			return err
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		logger.Log.Error(op, "No transaction found in context", nil)
+		return ErrNoTransaction
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM items WHERE order_uid = $1`, orderID); err != nil {
+		return pgstorage.ClassifyError(op, err)
+	}
+	for _, item := range items {
+		_, err := tx.Exec(ctx, query,
+			orderID,
+			item.ChrtID,
+			item.TrackNumber,
+			item.Price,
+			item.Rid,
+			item.Name,
+			item.Sale,
+			item.Size,
+			item.TotalPrice,
+			item.NmId,
+			item.Brand,
+			item.Status,
+		)
+		if err != nil {
+			return pgstorage.ClassifyError(op, err)
 		}
-		for _, item := range items {
-			_, err := tx.Exec(ctx, query,
-				orderID,
-				item.ChrtID,
-				item.TrackNumber,
-				item.Price,
-				item.Rid,
-				item.Name,
-				item.Sale,
-				item.Size,
-				item.TotalPrice,
-				item.NmId,
-				item.Brand,
-				item.Status,
-			)
-			if err != nil {
-				return err
-			}
-			logger.Log.Info(op, "Item added successfully, order_uid: ", orderID, "item_id", item.ID)
+		logger.Log.Info(op, "Item added successfully, order_uid: ", orderID, "item_id", item.ID)
+	}
+
+	logger.Log.Info(op, "All items added successfully to order, order_uid: ", orderID)
+
+	return nil
+}
+
+// AddItemsBatch заменяет товары всех заказов пачки: удаляет старые одним запросом и
+// загружает новые через COPY.
+func (r *ItemRepository) AddItemsBatch(ctx context.Context, items []*models.Item) error {
+	const op = "ItemRepository.AddItemsBatch"
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		logger.Log.Error(op, "No transaction found in context", nil)
+		return ErrNoTransaction
+	}
+
+	orderIDs := make([]string, 0, len(items))
+	seen := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		if _, ok := seen[item.OrderID]; ok {
+			continue
 		}
+		seen[item.OrderID] = struct{}{}
+		orderIDs = append(orderIDs, item.OrderID)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM items WHERE order_uid = ANY($1)`, orderIDs); err != nil {
+		return pgstorage.ClassifyError(op, err)
+	}
 
-		logger.Log.Info(op, "All items added successfully to order, order_uid: ", orderID)
+	columns := []string{
+		"order_uid", "chrt_id", "track_number", "price", "rid", "name",
+		"sale", "size", "total_price", "nm_id", "brand", "status",
+	}
+	copied, err := tx.CopyFrom(ctx, pgx.Identifier{"items"}, columns,
+		pgx.CopyFromSlice(len(items), func(i int) ([]any, error) {
+			item := items[i]
+			return []any{
+				item.OrderID, item.ChrtID, item.TrackNumber, item.Price, item.Rid, item.Name,
+				item.Sale, item.Size, item.TotalPrice, item.NmId, item.Brand, item.Status,
+			}, nil
+		}),
+	)
+	if err != nil {
+		return pgstorage.ClassifyError(op, err)
+	}
 
-		return nil
-	}, r.retryCount, r.backoff)
+	logger.Log.Info(op, "Items copied successfully, count: ", copied, "orders", len(orderIDs))
+	return nil
 }
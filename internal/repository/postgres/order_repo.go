@@ -2,116 +2,184 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/zhavkk/order-service/internal/logger"
 	"github.com/zhavkk/order-service/internal/models"
 	"github.com/zhavkk/order-service/pkg/pgstorage"
 	"github.com/zhavkk/order-service/pkg/utils"
 )
 
 type OrderRepository struct {
-	storage    *pgstorage.Storage
-	retryCount int
-	backoff    time.Duration
+	storage *pgstorage.Storage
+	retry   utils.RetryPolicy
 }
 
-func NewOrderRepository(storage *pgstorage.Storage, retryCount int, backoff time.Duration) *OrderRepository {
+func NewOrderRepository(storage *pgstorage.Storage, retry utils.RetryPolicy) *OrderRepository {
 	return &OrderRepository{
-		storage:    storage,
-		retryCount: retryCount,
-		backoff:    backoff,
+		storage: storage,
+		retry:   retry,
 	}
 }
 
 func (r *OrderRepository) GetOrderByID(ctx context.Context, orderID string) (*models.Order, error) {
-	var fo models.Order
-	orderQ := `
-        SELECT order_uid, track_number, entry, locale, internal_signature, customer_id,
-               delivery_service, shardkey, sm_id, date_created, oof_shard
-        FROM orders
-    	WHERE order_uid = $1
-    `
-	err := r.storage.GetPool().
-		QueryRow(ctx, orderQ, orderID).
-		Scan(
-			&fo.OrderUID, &fo.TrackNumber, &fo.Entry,
-			&fo.Locale, &fo.InternalSignature, &fo.CustomerID,
-			&fo.DeliveryService, &fo.ShardKey, &fo.SmID,
-			&fo.DateCreated, &fo.OofShard,
-		)
+	orders, err := r.GetOrdersByIDs(ctx, []string{orderID})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrOrderNotFound
-		}
 		return nil, err
 	}
-
-	deliveryQ := `
-        SELECT delivery_id, order_uid, name, phone, zip, city, address, region, email
-          FROM delivery
-         WHERE order_uid = $1
-    `
-	if err := r.storage.GetPool().
-		QueryRow(ctx, deliveryQ, orderID).
-		Scan(
-			&fo.Delivery.ID, &fo.Delivery.OrderID, &fo.Delivery.Name,
-			&fo.Delivery.Phone, &fo.Delivery.Zip, &fo.Delivery.City,
-			&fo.Delivery.Address, &fo.Delivery.Region, &fo.Delivery.Email,
-		); err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return nil, err
+	if len(orders) == 0 {
+		return nil, ErrOrderNotFound
 	}
+	return orders[0], nil
+}
 
-	paymentQ := `
-        SELECT transaction, order_uid, request_id, currency, provider, amount,
-               payment_dt, bank, delivery_cost, goods_total, custom_fee
-          FROM payments
-         WHERE order_uid = $1
-    `
-	if err := r.storage.GetPool().
-		QueryRow(ctx, paymentQ, orderID).
-		Scan(
-			&fo.Payment.Transaction, &fo.Payment.OrderID, &fo.Payment.RequestID,
-			&fo.Payment.Currency, &fo.Payment.Provider, &fo.Payment.Amount,
-			&fo.Payment.PaymentDt, &fo.Payment.Bank, &fo.Payment.DeliveryCost,
-			&fo.Payment.GoodsTotal, &fo.Payment.CustomFee,
-		); err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return nil, err
+// GetOrdersByIDs загружает заказы вместе с доставкой, платежом и товарами одним запросом.
+// Один запрос видит один снимок данных, поэтому параллельная перезапись заказа не может
+// вернуться наполовину старой. Заказы возвращаются в порядке orderIDs, отсутствующие пропускаются.
+func (r *OrderRepository) GetOrdersByIDs(ctx context.Context, orderIDs []string) ([]*models.Order, error) {
+	if len(orderIDs) == 0 {
+		return nil, nil
 	}
 
-	itemsQ := `
-        SELECT item_id, order_uid, chrt_id, track_number, price, rid, name,
-               sale, size, total_price, nm_id, brand, status
-          FROM items
-         WHERE order_uid = $1
+	var orders []*models.Order
+	err := r.retryOutsideTx(ctx, func(ctx context.Context) error {
+		var err error
+		orders, err = r.getOrdersByIDs(ctx, orderIDs)
+		return err
+	})
+	return orders, err
+}
+
+func (r *OrderRepository) getOrdersByIDs(ctx context.Context, orderIDs []string) ([]*models.Order, error) {
+	const op = "OrderRepository.GetOrdersByIDs"
+
+	query := `
+        SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
+               o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.status,
+               o.content_hash, o.version, o.updated_at,
+               (SELECT json_build_object(
+                           'id', d.delivery_id, 'order_id', d.order_uid, 'name', d.name,
+                           'phone', d.phone, 'zip', d.zip, 'city', d.city,
+                           'address', d.address, 'region', d.region, 'email', d.email)
+                  FROM delivery d
+                 WHERE d.order_uid = o.order_uid
+                 ORDER BY d.delivery_id
+                 LIMIT 1),
+               (SELECT json_build_object(
+                           'transaction', p.transaction, 'order_id', p.order_uid, 'request_id', p.request_id,
+                           'currency', p.currency, 'provider', p.provider, 'amount', p.amount,
+                           'payment_dt', p.payment_dt, 'bank', p.bank, 'delivery_cost', p.delivery_cost,
+                           'goods_total', p.goods_total, 'custom_fee', p.custom_fee)
+                  FROM payments p
+                 WHERE p.order_uid = o.order_uid
+                 LIMIT 1),
+               (SELECT json_agg(json_build_object(
+                           'id', i.item_id, 'order_id', i.order_uid, 'chrt_id', i.chrt_id,
+                           'track_number', i.track_number, 'price', i.price, 'rid', i.rid,
+                           'name', i.name, 'sale', i.sale, 'size', i.size,
+                           'total_price', i.total_price, 'nm_id', i.nm_id, 'brand', i.brand,
+                           'status', i.status) ORDER BY i.item_id)
+                  FROM items i
+                 WHERE i.order_uid = o.order_uid)
+          FROM orders o
+         WHERE o.order_uid = ANY($1)
     `
-	rows, err := r.storage.GetPool().Query(ctx, itemsQ, orderID)
+	rows, err := r.querier(ctx).Query(ctx, query, orderIDs)
 	if err != nil {
-		return nil, err
+		return nil, pgstorage.ClassifyError(op, err)
 	}
 	defer rows.Close()
 
+	byID := make(map[string]*models.Order, len(orderIDs))
 	for rows.Next() {
-		var it models.Item
+		var (
+			fo                       models.Order
+			dateCreated              *time.Time
+			delivery, payment, items []byte
+		)
 		if err := rows.Scan(
-			&it.ID, &it.OrderID, &it.ChrtID, &it.TrackNumber, &it.Price, &it.Rid,
-			&it.Name, &it.Sale, &it.Size, &it.TotalPrice, &it.NmId, &it.Brand, &it.Status,
+			&fo.OrderUID, &fo.TrackNumber, &fo.Entry,
+			&fo.Locale, &fo.InternalSignature, &fo.CustomerID,
+			&fo.DeliveryService, &fo.ShardKey, &fo.SmID,
+			&dateCreated, &fo.OofShard, &fo.Status,
+			&fo.ContentHash, &fo.Version, &fo.UpdatedAt,
+			&delivery, &payment, &items,
 		); err != nil {
-			return nil, err
+			return nil, pgstorage.ClassifyError(op, err)
+		}
+		if dateCreated != nil {
+			fo.DateCreated = *dateCreated
+		}
+		if err := unmarshalNullable(delivery, &fo.Delivery); err != nil {
+			return nil, fmt.Errorf("%s: delivery: %w", op, err)
+		}
+		if err := unmarshalNullable(payment, &fo.Payment); err != nil {
+			return nil, fmt.Errorf("%s: payment: %w", op, err)
+		}
+		if err := unmarshalNullable(items, &fo.Items); err != nil {
+			return nil, fmt.Errorf("%s: items: %w", op, err)
 		}
-		fo.Items = append(fo.Items, it)
+		byID[fo.OrderUID] = &fo
 	}
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, pgstorage.ClassifyError(op, err)
+	}
+
+	orders := make([]*models.Order, 0, len(byID))
+	for _, id := range orderIDs {
+		if order, ok := byID[id]; ok {
+			orders = append(orders, order)
+			delete(byID, id)
+		}
+	}
+
+	return orders, nil
+}
+
+// querier возвращает транзакцию из контекста, если она есть (например, RunRepeatableRead
+// для нескольких чтений из одного снимка), иначе пул.
+func (r *OrderRepository) querier(ctx context.Context) interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+} {
+	if tx, ok := pgstorage.GetTxFromContext(ctx); ok {
+		return tx
 	}
+	return r.storage.GetPool()
+}
 
-	return &fo, nil
+// retryOutsideTx повторяет чтение только вне транзакции: внутри нее после ошибки
+// (например, 40001) транзакция уже прервана, и перезапускать ее целиком должен TxManager.
+func (r *OrderRepository) retryOutsideTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := pgstorage.GetTxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+	return r.retry.Do(ctx, fn)
+}
+
+func unmarshalNullable(data []byte, v any) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
 }
 
 func (r *OrderRepository) GetRecentOrders(ctx context.Context, limit int) ([]*models.Order, error) {
-	const op = "OrderRepository.GetRecentOrders"
+	orderUIDs, err := r.GetRecentOrderUIDs(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetOrdersByIDs(ctx, orderUIDs)
+}
+
+// GetRecentOrderUIDs возвращает ID последних limit заказов по date_created.
+func (r *OrderRepository) GetRecentOrderUIDs(ctx context.Context, limit int) ([]string, error) {
+	const op = "OrderRepository.GetRecentOrderUIDs"
 	orderQuery := `
         SELECT order_uid
         FROM orders
@@ -119,57 +187,301 @@ func (r *OrderRepository) GetRecentOrders(ctx context.Context, limit int) ([]*mo
         LIMIT $1
     `
 
-	rows, err := r.storage.GetPool().Query(ctx, orderQuery, limit)
+	return r.selectOrderUIDs(ctx, op, orderQuery, limit)
+}
+
+// GetOrderUIDsCreatedSince возвращает ID заказов, созданных начиная с since, от новых к старым.
+func (r *OrderRepository) GetOrderUIDsCreatedSince(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	const op = "OrderRepository.GetOrderUIDsCreatedSince"
+	orderQuery := `
+        SELECT order_uid
+        FROM orders
+        WHERE date_created >= $1
+        ORDER BY date_created DESC
+        LIMIT $2
+    `
+
+	return r.selectOrderUIDs(ctx, op, orderQuery, since, limit)
+}
+
+// ListOrders возвращает заказы, отсортированные по (date_created, order_uid) по убыванию.
+// Следующая страница запрашивается через filter.After — последний заказ текущей страницы.
+func (r *OrderRepository) ListOrders(ctx context.Context, filter models.OrderFilter) ([]*models.Order, error) {
+	const op = "OrderRepository.ListOrders"
+
+	query, args := buildListOrdersQuery(filter)
+
+	orderUIDs, err := r.selectOrderUIDs(ctx, op, query, args...)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
+	return r.GetOrdersByIDs(ctx, orderUIDs)
+}
+
+func (r *OrderRepository) selectOrderUIDs(ctx context.Context, op, query string, args ...any) ([]string, error) {
 	var orderUIDs []string
-	for rows.Next() {
-		var orderUID string
-		if err := rows.Scan(&orderUID); err != nil {
-			return nil, err
+	err := r.retryOutsideTx(ctx, func(ctx context.Context) error {
+		rows, err := r.querier(ctx).Query(ctx, query, args...)
+		if err != nil {
+			return pgstorage.ClassifyError(op, err)
 		}
-		orderUIDs = append(orderUIDs, orderUID)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		defer rows.Close()
+
+		orderUIDs = orderUIDs[:0]
+		for rows.Next() {
+			var orderUID string
+			if err := rows.Scan(&orderUID); err != nil {
+				return pgstorage.ClassifyError(op, err)
+			}
+			orderUIDs = append(orderUIDs, orderUID)
+		}
+		return pgstorage.ClassifyError(op, rows.Err())
+	})
+	return orderUIDs, err
+}
+
+func buildListOrdersQuery(filter models.OrderFilter) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
 	}
 
-	var orders []*models.Order
-	for _, uid := range orderUIDs {
-		fullOrder, err := r.GetOrderByID(ctx, uid)
-		if err != nil {
-			logger.Log.Error(op, "Failed to get order by ID", err)
-			continue
+	if filter.CustomerID != "" {
+		conds = append(conds, "o.customer_id = "+arg(filter.CustomerID))
+	}
+	if filter.TrackNumber != "" {
+		conds = append(conds, "o.track_number = "+arg(filter.TrackNumber))
+	}
+	if filter.DeliveryService != "" {
+		conds = append(conds, "o.delivery_service = "+arg(filter.DeliveryService))
+	}
+	if filter.Locale != "" {
+		conds = append(conds, "o.locale = "+arg(filter.Locale))
+	}
+	if filter.Currency != "" {
+		conds = append(conds, "p.currency = "+arg(filter.Currency))
+	}
+	if filter.Provider != "" {
+		conds = append(conds, "p.provider = "+arg(filter.Provider))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		conds = append(conds, "o.date_created >= "+arg(filter.CreatedFrom))
+	}
+	if !filter.CreatedTo.IsZero() {
+		conds = append(conds, "o.date_created < "+arg(filter.CreatedTo))
+	}
+	if filter.After != nil {
+		// date_created может быть NULL: такие заказы идут в конце выдачи (NULLS LAST),
+		// а курсор на них хранит нулевую дату.
+		if filter.After.DateCreated.IsZero() {
+			conds = append(conds, "o.date_created IS NULL AND o.order_uid < "+arg(filter.After.OrderUID))
+		} else {
+			conds = append(conds, fmt.Sprintf("((o.date_created, o.order_uid) < (%s, %s) OR o.date_created IS NULL)",
+				arg(filter.After.DateCreated), arg(filter.After.OrderUID)))
 		}
-		orders = append(orders, fullOrder)
 	}
 
-	return orders, nil
+	var b strings.Builder
+	b.WriteString("SELECT o.order_uid FROM orders o")
+	if filter.Currency != "" || filter.Provider != "" {
+		b.WriteString(" JOIN payments p ON p.order_uid = o.order_uid")
+	}
+	if len(conds) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(strings.Join(conds, " AND "))
+	}
+	b.WriteString(" ORDER BY o.date_created DESC NULLS LAST, o.order_uid DESC LIMIT ")
+	b.WriteString(arg(filter.Limit))
+
+	return b.String(), args
 }
+
+// CreateOrder вставляет новый заказ. Если заказ уже есть, возвращает ErrOrderExists.
 func (r *OrderRepository) CreateOrder(ctx context.Context, order *models.Order) error {
-	return utils.RetryWithBackoff(func() error {
+	const op = "OrderRepository.CreateOrder"
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		return ErrNoTransaction
+	}
+	err := tx.QueryRow(ctx, insertOrderQuery,
+		order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature, order.CustomerID,
+		order.DeliveryService, order.ShardKey, order.SmID, order.DateCreated, order.OofShard, order.ContentHash,
+	).Scan(&order.Version, &order.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrOrderExists
+		}
+		return pgstorage.ClassifyError(op, err)
+	}
+	return nil
+}
 
-		query := `
+const insertOrderQuery = `
 	INSERT INTO orders (
         order_uid, track_number, entry, locale, internal_signature, customer_id,
-        delivery_service, shardkey, sm_id, date_created, oof_shard
+        delivery_service, shardkey, sm_id, date_created, oof_shard, content_hash
     ) VALUES (
-        $1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11
+        $1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12
     )
-	ON CONFLICT (order_uid) DO NOTHING	
+	ON CONFLICT (order_uid) DO NOTHING
+	RETURNING version, updated_at
 	`
 
-		tx, ok := pgstorage.GetTxFromContext(ctx)
-		if !ok {
-			return ErrNoTransaction
-		}
-		_, err := tx.Exec(ctx, query,
+// CreateOrders вставляет заказы пачкой за один round trip (pgx.Batch).
+// COPY здесь не подходит, так как не поддерживает ON CONFLICT.
+func (r *OrderRepository) CreateOrders(ctx context.Context, orders []*models.Order) error {
+	const op = "OrderRepository.CreateOrders"
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		return ErrNoTransaction
+	}
+
+	batch := &pgx.Batch{}
+	for _, order := range orders {
+		batch.Queue(insertOrderQuery,
 			order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature, order.CustomerID,
-			order.DeliveryService, order.ShardKey, order.SmID, order.DateCreated, order.OofShard,
+			order.DeliveryService, order.ShardKey, order.SmID, order.DateCreated, order.OofShard, order.ContentHash,
 		)
-		return err
-	}, r.retryCount, r.backoff)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	for _, order := range orders {
+		if err := results.QueryRow().Scan(&order.Version, &order.UpdatedAt); err != nil {
+			_ = results.Close()
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%s: %s: %w", op, order.OrderUID, ErrOrderExists)
+			}
+			return pgstorage.ClassifyError(op, err)
+		}
+	}
+
+	return pgstorage.ClassifyError(op, results.Close())
+}
+
+// GetStatusForUpdate блокирует строку заказа до конца транзакции, чтобы параллельные
+// смены статуса проверялись по таблице переходов последовательно.
+func (r *OrderRepository) GetStatusForUpdate(ctx context.Context, orderUID string) (models.OrderStatus, error) {
+	const op = "OrderRepository.GetStatusForUpdate"
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		return "", ErrNoTransaction
+	}
+
+	var status models.OrderStatus
+	err := tx.QueryRow(ctx, `SELECT status FROM orders WHERE order_uid = $1 FOR UPDATE`, orderUID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrOrderNotFound
+		}
+		return "", pgstorage.ClassifyError(op, err)
+	}
+
+	return status, nil
+}
+
+// UpdateStatus меняет статус заказа и записывает переход в status_history.
+func (r *OrderRepository) UpdateStatus(ctx context.Context, change *models.StatusChange) error {
+	const op = "OrderRepository.UpdateStatus"
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		return ErrNoTransaction
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE orders SET status = $2 WHERE order_uid = $1`, change.OrderUID, change.To)
+	if err != nil {
+		return pgstorage.ClassifyError(op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrOrderNotFound
+	}
+
+	query := `
+	INSERT INTO status_history (order_uid, from_status, to_status, changed_by, reason)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, changed_at
+	`
+	err = tx.QueryRow(ctx, query, change.OrderUID, change.From, change.To, change.ChangedBy, change.Reason).
+		Scan(&change.ID, &change.ChangedAt)
+	return pgstorage.ClassifyError(op, err)
+}
+
+// GetOrderVersion возвращает хэш содержимого и версию сохраненного заказа.
+func (r *OrderRepository) GetOrderVersion(ctx context.Context, orderUID string) (*models.OrderVersion, error) {
+	const op = "OrderRepository.GetOrderVersion"
+
+	var (
+		v           models.OrderVersion
+		dateCreated *time.Time
+	)
+	err := r.querier(ctx).
+		QueryRow(ctx, `SELECT content_hash, version, date_created FROM orders WHERE order_uid = $1`, orderUID).
+		Scan(&v.ContentHash, &v.Version, &dateCreated)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, pgstorage.ClassifyError(op, err)
+	}
+	if dateCreated != nil {
+		v.DateCreated = *dateCreated
+	}
+
+	return &v, nil
+}
+
+// SetContentHash записывает хэш содержимого заказу, сохраненному до появления хэшей.
+// Версия не меняется; заказ с уже записанным хэшем не трогается.
+func (r *OrderRepository) SetContentHash(ctx context.Context, orderUID, contentHash string) error {
+	const op = "OrderRepository.SetContentHash"
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx,
+		`UPDATE orders SET content_hash = $2 WHERE order_uid = $1 AND content_hash = ''`, orderUID, contentHash)
+	return pgstorage.ClassifyError(op, err)
+}
+
+// UpdateOrder перезаписывает поля заказа, если его версия все еще expectedVersion,
+// иначе возвращает ErrVersionConflict. Статус заказа не меняется.
+func (r *OrderRepository) UpdateOrder(ctx context.Context, order *models.Order, expectedVersion int) error {
+	const op = "OrderRepository.UpdateOrder"
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		return ErrNoTransaction
+	}
+
+	query := `
+	UPDATE orders
+	   SET track_number = $2, entry = $3, locale = $4, internal_signature = $5, customer_id = $6,
+	       delivery_service = $7, shardkey = $8, sm_id = $9, date_created = $10, oof_shard = $11,
+	       content_hash = $12, version = version + 1, updated_at = now()
+	 WHERE order_uid = $1 AND version = $13
+	RETURNING version, updated_at
+	`
+	err := tx.QueryRow(ctx, query,
+		order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature, order.CustomerID,
+		order.DeliveryService, order.ShardKey, order.SmID, order.DateCreated, order.OofShard,
+		order.ContentHash, expectedVersion,
+	).Scan(&order.Version, &order.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrVersionConflict
+		}
+		return pgstorage.ClassifyError(op, err)
+	}
+
+	return nil
 }
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/pkg/pgstorage"
+)
+
+type OutboxRepository struct {
+	storage *pgstorage.Storage
+}
+
+func NewOutboxRepository(storage *pgstorage.Storage) *OutboxRepository {
+	return &OutboxRepository{
+		storage: storage,
+	}
+}
+
+// AddEvents записывает события в outbox в транзакции из контекста, чтобы они
+// сохранялись атомарно вместе с заказом.
+func (r *OutboxRepository) AddEvents(ctx context.Context, events []*models.OutboxEvent) error {
+	const op = "OutboxRepository.AddEvents"
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		logger.Log.Error(op, "No transaction found in context", nil)
+		return ErrNoTransaction
+	}
+
+	columns := []string{"aggregate_id", "event_type", "payload"}
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"outbox"}, columns,
+		pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+			e := events[i]
+			return []any{e.AggregateID, e.EventType, e.Payload}, nil
+		}),
+	)
+	if err != nil {
+		logger.Log.Error(op, "Failed to add outbox events", err)
+		return pgstorage.ClassifyError(op, err)
+	}
+
+	return nil
+}
+
+// FetchPending блокирует до limit неотправленных событий. SKIP LOCKED позволяет запускать
+// несколько релеев параллельно без повторной отправки одних и тех же строк.
+func (r *OutboxRepository) FetchPending(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+	const op = "OutboxRepository.FetchPending"
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		logger.Log.Error(op, "No transaction found in context", nil)
+		return nil, ErrNoTransaction
+	}
+
+	query := `
+        SELECT id, aggregate_id, event_type, payload, created_at
+        FROM outbox
+        WHERE sent_at IS NULL
+        ORDER BY id
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    `
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return nil, pgstorage.ClassifyError(op, err)
+	}
+	defer rows.Close()
+
+	var events []*models.OutboxEvent
+	for rows.Next() {
+		var event models.OutboxEvent
+		if err := rows.Scan(
+			&event.ID,
+			&event.AggregateID,
+			&event.EventType,
+			&event.Payload,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, pgstorage.ClassifyError(op, err)
+		}
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pgstorage.ClassifyError(op, err)
+	}
+
+	return events, nil
+}
+
+func (r *OutboxRepository) MarkSent(ctx context.Context, ids []int64) error {
+	const op = "OutboxRepository.MarkSent"
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		logger.Log.Error(op, "No transaction found in context", nil)
+		return ErrNoTransaction
+	}
+
+	query := `UPDATE outbox SET sent_at = now() WHERE id = ANY($1)`
+	if _, err := tx.Exec(ctx, query, ids); err != nil {
+		logger.Log.Error(op, "Failed to mark outbox events as sent", err)
+		return pgstorage.ClassifyError(op, err)
+	}
+
+	return nil
+}
+
+// PendingStats возвращает число неотправленных событий и время создания самого старого из них.
+func (r *OutboxRepository) PendingStats(ctx context.Context) (int64, time.Time, error) {
+	const op = "OutboxRepository.PendingStats"
+
+	query := `SELECT count(*), coalesce(min(created_at), now()) FROM outbox WHERE sent_at IS NULL`
+
+	var (
+		count  int64
+		oldest time.Time
+	)
+	if err := r.storage.GetPool().QueryRow(ctx, query).Scan(&count, &oldest); err != nil {
+		return 0, time.Time{}, pgstorage.ClassifyError(op, err)
+	}
+
+	return count, oldest, nil
+}
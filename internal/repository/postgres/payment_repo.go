@@ -2,42 +2,81 @@ package postgres
 
 import (
 	"context"
-	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/zhavkk/order-service/internal/models"
 	"github.com/zhavkk/order-service/pkg/pgstorage"
 	"github.com/zhavkk/order-service/pkg/utils"
 )
 
 type PaymentRepository struct {
-	storage    *pgstorage.Storage
-	retryCount int
-	backoff    time.Duration
+	storage *pgstorage.Storage
+	retry   utils.RetryPolicy
 }
 
-func NewPaymentRepository(storage *pgstorage.Storage, retryCount int, backoff time.Duration) *PaymentRepository {
+func NewPaymentRepository(storage *pgstorage.Storage, retry utils.RetryPolicy) *PaymentRepository {
 	return &PaymentRepository{
-		storage:    storage,
-		retryCount: retryCount,
-		backoff:    backoff,
+		storage: storage,
+		retry:   retry,
 	}
 }
 
 func (r *PaymentRepository) CreatePayment(ctx context.Context, payment *models.Payment) error {
-	return utils.RetryWithBackoff(func() error {
-		query := `INSERT INTO payments (
+	const op = "PaymentRepository.CreatePayment"
+
+	query := `INSERT INTO payments (
         transaction, order_uid, request_id, currency, provider, amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
     ) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`
 
-		tx, ok := pgstorage.GetTxFromContext(ctx)
-		if !ok {
-			return ErrNoTransaction
-		}
-
-		_, err := tx.Exec(ctx, query,
-			payment.Transaction, payment.OrderID, payment.RequestID, payment.Currency, payment.Provider,
-			payment.Amount, payment.PaymentDt, payment.Bank, payment.DeliveryCost, payment.GoodsTotal, payment.CustomFee,
-		)
-		return err
-	}, r.retryCount, r.backoff)
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, query,
+		payment.Transaction, payment.OrderID, payment.RequestID, payment.Currency, payment.Provider,
+		payment.Amount, payment.PaymentDt, payment.Bank, payment.DeliveryCost, payment.GoodsTotal, payment.CustomFee,
+	)
+	return pgstorage.ClassifyError(op, err)
+}
+
+// ReplacePayment заменяет платеж заказа при обновлении: старая строка удаляется, даже если
+// у нового платежа другой transaction.
+func (r *PaymentRepository) ReplacePayment(ctx context.Context, payment *models.Payment) error {
+	const op = "PaymentRepository.ReplacePayment"
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM payments WHERE order_uid = $1`, payment.OrderID); err != nil {
+		return pgstorage.ClassifyError(op, err)
+	}
+
+	return r.CreatePayment(ctx, payment)
+}
+
+func (r *PaymentRepository) CreatePayments(ctx context.Context, payments []*models.Payment) error {
+	const op = "PaymentRepository.CreatePayments"
+
+	tx, ok := pgstorage.GetTxFromContext(ctx)
+	if !ok {
+		return ErrNoTransaction
+	}
+
+	columns := []string{
+		"transaction", "order_uid", "request_id", "currency", "provider", "amount",
+		"payment_dt", "bank", "delivery_cost", "goods_total", "custom_fee",
+	}
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"payments"}, columns,
+		pgx.CopyFromSlice(len(payments), func(i int) ([]any, error) {
+			p := payments[i]
+			return []any{
+				p.Transaction, p.OrderID, p.RequestID, p.Currency, p.Provider, p.Amount,
+				p.PaymentDt, p.Bank, p.DeliveryCost, p.GoodsTotal, p.CustomFee,
+			}, nil
+		}),
+	)
+	return pgstorage.ClassifyError(op, err)
 }
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zhavkk/order-service/internal/logger"
+	prometheusmetrics "github.com/zhavkk/order-service/pkg/metrics/prometheus"
+)
+
+var ErrAccessTrackerRunning = errors.New("access tracker is already running")
+
+// accessBucket — размер интервала, по которому агрегируются счетчики чтений.
+const accessBucket = time.Hour
+
+// AccessTracker считает чтения заказов через API для стратегии прогрева most_accessed.
+// Счетчики копятся в памяти и раз в flushInterval одной вставкой добавляются в базу,
+// поэтому чтение заказа не делает лишних запросов. Счетчики старше retention удаляются.
+// В памяти держится не больше maxPending счетчиков: пока база недоступна, сначала
+// отбрасываются самые старые интервалы.
+type AccessTracker struct {
+	repo          AccessRepository
+	flushInterval time.Duration
+	retention     time.Duration
+	maxPending    int
+
+	mu      sync.Mutex
+	counts  map[time.Time]map[string]int64
+	pending int
+
+	running  atomic.Bool
+	stopOnce sync.Once
+	stopped  chan struct{}
+	done     chan struct{}
+}
+
+func NewAccessTracker(repo AccessRepository, flushInterval, retention time.Duration, maxPending int) *AccessTracker {
+	if flushInterval <= 0 {
+		flushInterval = 10 * time.Second
+	}
+	if retention <= 0 {
+		retention = 7 * 24 * time.Hour
+	}
+	if maxPending <= 0 {
+		maxPending = 100_000
+	}
+	return &AccessTracker{
+		repo:          repo,
+		flushInterval: flushInterval,
+		retention:     retention,
+		maxPending:    maxPending,
+		counts:        make(map[time.Time]map[string]int64),
+		stopped:       make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Record засчитывает одно чтение заказа.
+func (t *AccessTracker) Record(orderUID string) {
+	bucket := time.Now().UTC().Truncate(accessBucket)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(bucket, orderUID, 1)
+}
+
+// Flush записывает накопленные счетчики. Не записанные из-за ошибки счетчики
+// возвращаются в память и уходят со следующей попыткой.
+func (t *AccessTracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	pending := t.counts
+	t.counts = make(map[time.Time]map[string]int64)
+	t.pending = 0
+	t.mu.Unlock()
+
+	var errs []error
+	for bucket, counts := range pending {
+		if err := t.repo.AddAccessCounts(ctx, bucket, counts); err != nil {
+			errs = append(errs, err)
+			t.restore(bucket, counts)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t *AccessTracker) restore(bucket time.Time, counts map[string]int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for orderUID, n := range counts {
+		t.add(bucket, orderUID, n)
+	}
+}
+
+// add прибавляет n чтений заказа к счетчику интервала bucket. Для нового счетчика при
+// заполненном буфере отбрасываются интервалы старше bucket, а если таких нет — сами чтения.
+// Вызывается под mu.
+func (t *AccessTracker) add(bucket time.Time, orderUID string, n int64) {
+	counts, ok := t.counts[bucket]
+	if _, exists := counts[orderUID]; !exists {
+		for t.pending >= t.maxPending {
+			if !t.dropOldestBefore(bucket) {
+				prometheusmetrics.AccessCountsDroppedTotal.Add(float64(n))
+				return
+			}
+		}
+		if !ok {
+			counts = make(map[string]int64)
+			t.counts[bucket] = counts
+		}
+		t.pending++
+	}
+	counts[orderUID] += n
+}
+
+// dropOldestBefore отбрасывает самый старый интервал, если он старше bucket.
+// Вызывается под mu.
+func (t *AccessTracker) dropOldestBefore(bucket time.Time) bool {
+	const op = "AccessTracker.dropOldestBefore"
+
+	oldest := bucket
+	for b := range t.counts {
+		if b.Before(oldest) {
+			oldest = b
+		}
+	}
+	if oldest.Equal(bucket) {
+		return false
+	}
+
+	var reads int64
+	for _, n := range t.counts[oldest] {
+		reads += n
+	}
+	t.pending -= len(t.counts[oldest])
+	delete(t.counts, oldest)
+	prometheusmetrics.AccessCountsDroppedTotal.Add(float64(reads))
+	logger.Log.Warn(op, "Access counts buffer is full, dropped bucket: ", oldest, "reads", reads)
+	return true
+}
+
+// MostAccessed возвращает до limit заказов, которые чаще всего читали за window.
+func (t *AccessTracker) MostAccessed(ctx context.Context, window time.Duration, limit int) ([]string, error) {
+	since := time.Now().UTC().Add(-window).Truncate(accessBucket)
+	return t.repo.GetMostAccessedOrderUIDs(ctx, since, limit)
+}
+
+// Run периодически сбрасывает счетчики в базу и удаляет устаревшие. При остановке
+// накопленное записывается последний раз.
+func (t *AccessTracker) Run(ctx context.Context) error {
+	const op = "AccessTracker.Run"
+
+	if !t.running.CompareAndSwap(false, true) {
+		return ErrAccessTrackerRunning
+	}
+	defer close(t.done)
+
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	var lastCleanup time.Time
+	for {
+		select {
+		case <-t.stopped:
+			return t.Flush(context.WithoutCancel(ctx))
+		case <-ctx.Done():
+			if err := t.Flush(context.WithoutCancel(ctx)); err != nil {
+				logger.Log.Error(op, "Failed to flush access counts", err)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if err := t.Flush(ctx); err != nil {
+			logger.Log.Error(op, "Failed to flush access counts", err)
+		}
+		if time.Since(lastCleanup) >= accessBucket {
+			deleted, err := t.repo.DeleteAccessCountsBefore(ctx, time.Now().UTC().Add(-t.retention))
+			if err != nil {
+				logger.Log.Error(op, "Failed to delete old access counts", err)
+				continue
+			}
+			lastCleanup = time.Now()
+			logger.Log.Info(op, "Old access counts deleted: ", deleted)
+		}
+	}
+}
+
+// Shutdown останавливает Run и ждет последней записи счетчиков, но не дольше дедлайна ctx.
+func (t *AccessTracker) Shutdown(ctx context.Context) error {
+	t.stopOnce.Do(func() { close(t.stopped) })
+
+	if t.running.Load() {
+		select {
+		case <-t.done:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for access tracker: %w", ctx.Err())
+		}
+	}
+	return nil
+}
+
+// SetAccessTracker включает учет чтений заказов через GetByID.
+func (s *OrderService) SetAccessTracker(tracker *AccessTracker) {
+	s.access = tracker
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/repository/mocks"
+)
+
+func TestAccessTracker_Flush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	logger.Init("local")
+	accessRepo := mocks.NewMockAccessRepository(ctrl)
+	tracker := NewAccessTracker(accessRepo, time.Second, time.Hour, 0)
+
+	tracker.Record("a")
+	tracker.Record("a")
+	tracker.Record("b")
+
+	bucket := time.Now().UTC().Truncate(time.Hour)
+	accessRepo.EXPECT().AddAccessCounts(gomock.Any(), bucket, map[string]int64{"a": 2, "b": 1}).
+		Return(errors.New("db down"))
+	require.Error(t, tracker.Flush(context.Background()))
+
+	// Неудачная запись не теряет счетчики.
+	tracker.Record("b")
+	accessRepo.EXPECT().AddAccessCounts(gomock.Any(), bucket, map[string]int64{"a": 2, "b": 2}).Return(nil)
+	require.NoError(t, tracker.Flush(context.Background()))
+
+	// Пустой буфер ничего не пишет.
+	require.NoError(t, tracker.Flush(context.Background()))
+}
+
+func TestAccessTracker_MaxPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	logger.Init("local")
+	accessRepo := mocks.NewMockAccessRepository(ctrl)
+	tracker := NewAccessTracker(accessRepo, time.Second, time.Hour, 2)
+
+	bucket := time.Now().UTC().Truncate(time.Hour)
+	// Счетчики, не записанные во время недоступности базы.
+	tracker.restore(bucket.Add(-2*time.Hour), map[string]int64{"old": 5})
+
+	tracker.Record("a")
+	tracker.Record("b") // вытесняет старый интервал
+	tracker.Record("c") // буфер полон, старше текущего интервала ничего нет
+	tracker.Record("a")
+
+	accessRepo.EXPECT().AddAccessCounts(gomock.Any(), bucket, map[string]int64{"a": 2, "b": 1}).Return(nil)
+	require.NoError(t, tracker.Flush(context.Background()))
+}
+
+func TestAccessTracker_FlushesOnShutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	logger.Init("local")
+	accessRepo := mocks.NewMockAccessRepository(ctrl)
+	tracker := NewAccessTracker(accessRepo, time.Hour, time.Hour, 0)
+
+	done := make(chan error, 1)
+	go func() { done <- tracker.Run(context.Background()) }()
+
+	tracker.Record("a")
+	accessRepo.EXPECT().AddAccessCounts(gomock.Any(), gomock.Any(), map[string]int64{"a": 1}).Return(nil)
+
+	require.Eventually(t, tracker.running.Load, time.Second, time.Millisecond)
+	require.NoError(t, tracker.Shutdown(context.Background()))
+	require.NoError(t, <-done)
+}
+
+func TestOrderService_GetByID_RecordsAccess(t *testing.T) {
+	deps := newTestDeps(t)
+	deps.useLRUCache()
+	tracker := NewAccessTracker(deps.accessRepo, time.Second, time.Hour, 0)
+	deps.service.SetAccessTracker(tracker)
+
+	order := generateRandomOrder()
+	deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), order.OrderUID).Return(&order, nil)
+
+	for range 2 {
+		_, err := deps.service.GetByID(context.Background(), &dto.GetOrderByIDRequest{OrderID: order.OrderUID})
+		require.NoError(t, err)
+	}
+
+	deps.accessRepo.EXPECT().AddAccessCounts(gomock.Any(), gomock.Any(), map[string]int64{order.OrderUID: 2}).Return(nil)
+	assert.NoError(t, tracker.Flush(context.Background()))
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+	"github.com/zhavkk/order-service/pkg/cache"
+)
+
+// evictPageSize — сколько заказов покупателя читается из базы за раз при сбросе кэша.
+const evictPageSize = 500
+
+var ErrWarmUpRunning = apperrors.Conflict("cache warm-up is already running", nil)
+
+// EvictOrder удаляет из кэша заказ и отметку о его отсутствии. В отличие от сброса при
+// обновлении заказа, ошибка кэша возвращается: администратор должен знать, что сброс не удался.
+// В историю сброс пишется, только если заказ есть в базе.
+func (s *OrderService) EvictOrder(ctx context.Context, orderUID string) error {
+	const op = "OrderService.EvictOrder"
+
+	for _, key := range []string{orderCacheKey(orderUID), missingOrderCacheKey(orderUID)} {
+		if err := s.cache.Delete(ctx, key); err != nil {
+			logger.Log.Error(op, "Failed to delete cached order", err)
+			return err
+		}
+	}
+	logger.Log.Info(op, "Cached order evicted, order_id: ", orderUID)
+
+	if _, err := s.orderRepo.GetOrderVersion(ctx, orderUID); err != nil {
+		if errors.Is(err, postgres.ErrOrderNotFound) {
+			return nil
+		}
+		logger.Log.Error(op, "Failed to check evicted order", err)
+		return err
+	}
+	return s.addHistory(ctx, newHistoryEvent(ctx, orderUID, EventCacheInvalidated, nil))
+}
+
+// EvictCache удаляет из кэша ключи по шаблону или все заказы покупателя.
+func (s *OrderService) EvictCache(ctx context.Context, req *dto.EvictCacheRequest) (*dto.EvictCacheResponse, error) {
+	switch {
+	case req.Pattern != "" && req.CustomerID != "":
+		return nil, apperrors.Invalid("pattern and customer_id are mutually exclusive", nil)
+	case req.CustomerID != "":
+		return s.evictCustomerOrders(ctx, req.CustomerID)
+	default:
+		return s.evictByPattern(ctx, req.Pattern)
+	}
+}
+
+// evictByPattern принимает только шаблоны ключей заказов: в той же базе Redis могут
+// лежать чужие ключи.
+func (s *OrderService) evictByPattern(ctx context.Context, pattern string) (*dto.EvictCacheResponse, error) {
+	const op = "OrderService.evictByPattern"
+
+	if !strings.HasPrefix(pattern, orderCacheKey("")) && !strings.HasPrefix(pattern, missingOrderCacheKey("")) {
+		return nil, apperrors.Invalid("pattern must start with "+orderCacheKey("")+" or "+missingOrderCacheKey(""), nil)
+	}
+
+	deleted, err := s.cache.DeleteByPattern(ctx, pattern)
+	if err != nil {
+		logger.Log.Error(op, "Failed to delete cached keys by pattern", err)
+		return nil, err
+	}
+
+	logger.Log.Info(op, "Cached keys evicted, pattern: ", pattern, "deleted", deleted)
+	event := newHistoryEvent(ctx, "", EventCachePatternEvicted, []models.FieldChange{
+		{Field: "pattern", New: pattern},
+		{Field: "deleted", New: deleted},
+	})
+	if err := s.addHistory(ctx, event); err != nil {
+		return nil, err
+	}
+	return &dto.EvictCacheResponse{Deleted: deleted}, nil
+}
+
+func (s *OrderService) evictCustomerOrders(ctx context.Context, customerID string) (*dto.EvictCacheResponse, error) {
+	const op = "OrderService.evictCustomerOrders"
+
+	resp := &dto.EvictCacheResponse{}
+	filter := models.OrderFilter{CustomerID: customerID, Limit: evictPageSize}
+	for {
+		orders, err := s.orderRepo.ListOrders(ctx, filter)
+		if err != nil {
+			logger.Log.Error(op, "Failed to list customer orders", err)
+			return nil, err
+		}
+
+		events := make([]*models.OrderHistoryEvent, 0, len(orders))
+		for _, order := range orders {
+			if err := s.cache.Delete(ctx, orderCacheKey(order.OrderUID)); err != nil {
+				logger.Log.Error(op, "Failed to delete cached order", err)
+				return nil, err
+			}
+			resp.Deleted++
+			events = append(events, newHistoryEvent(ctx, order.OrderUID, EventCacheInvalidated, nil))
+		}
+		if len(events) > 0 {
+			if err := s.addHistory(ctx, events...); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(orders) < evictPageSize {
+			break
+		}
+		last := orders[len(orders)-1]
+		filter.After = &models.OrderCursor{DateCreated: last.DateCreated, OrderUID: last.OrderUID}
+	}
+
+	logger.Log.Info(op, "Customer orders evicted, customer_id: ", customerID, "deleted", resp.Deleted)
+	return resp, nil
+}
+
+// TriggerWarmUp запускает прогрев кэша в фоне и сразу возвращается. Прогрев не отменяется
+// вместе с запросом и ограничен WarmUpOptions.Timeout.
+func (s *OrderService) TriggerWarmUp(ctx context.Context) error {
+	const op = "OrderService.TriggerWarmUp"
+
+	if !s.startWarmUp() {
+		return ErrWarmUpRunning
+	}
+
+	warmUpCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.warmUpOpts.Timeout)
+	go func() {
+		defer cancel()
+		defer s.warmUp.running.Store(false)
+		if err := s.warmUpCache(warmUpCtx); err != nil {
+			logger.Log.Error(op, "Cache warm-up failed", err)
+		}
+	}()
+
+	// Прогрев уже запущен: ошибка записи в историю его не отменяет.
+	event := newHistoryEvent(ctx, "", EventCacheWarmUpTriggered, []models.FieldChange{
+		{Field: "strategy", New: string(s.warmUpOpts.Strategy)},
+	})
+	if err := s.addHistory(ctx, event); err != nil {
+		logger.Log.Warn(op, "Failed to record cache warm-up trigger", err)
+	}
+	return nil
+}
+
+func (s *OrderService) CacheStats(ctx context.Context) (*dto.CacheStatsResponse, error) {
+	const op = "OrderService.CacheStats"
+
+	stats, err := s.cache.Stats(ctx)
+	if err != nil {
+		logger.Log.Error(op, "Failed to get cache stats", err)
+		return nil, err
+	}
+	return cacheStatsToDTO(stats), nil
+}
+
+func cacheStatsToDTO(stats cache.Stats) *dto.CacheStatsResponse {
+	resp := &dto.CacheStatsResponse{
+		Keys:        stats.Keys,
+		MemoryBytes: stats.MemoryBytes,
+		Hits:        stats.Hits,
+		Misses:      stats.Misses,
+		HitRatio:    stats.HitRatio(),
+		RemoteError: stats.RemoteError,
+	}
+	if stats.Local != nil {
+		resp.Local = cacheStatsToDTO(*stats.Local)
+	}
+	return resp
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/audit"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+	"github.com/zhavkk/order-service/pkg/cache"
+)
+
+func TestOrderService_EvictOrder(t *testing.T) {
+	deps := newTestDeps(t)
+	orderCache := deps.useLRUCache()
+	ctx := audit.WithOrigin(context.Background(), audit.ActorAdmin+":ops", audit.HTTPSource("req-1"))
+
+	require.NoError(t, orderCache.Set(ctx, "order:a", cachedOrder{Order: &models.Order{OrderUID: "a"}}, 0))
+	require.NoError(t, orderCache.Set(ctx, "order-missing:a", true, 0))
+	require.NoError(t, orderCache.Set(ctx, "order:b", cachedOrder{Order: &models.Order{OrderUID: "b"}}, 0))
+
+	deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), "a").Return(&models.OrderVersion{Version: 1}, nil)
+	deps.historyRepo.EXPECT().AddEvents(gomock.Any(), []*models.OrderHistoryEvent{{
+		OrderUID: "a", EventType: EventCacheInvalidated, Actor: "admin:ops", Source: "http:req-1",
+	}}).Return(nil)
+
+	require.NoError(t, deps.service.EvictOrder(ctx, "a"))
+	assert.Equal(t, 1, orderCache.Len())
+
+	// Заказа нет в базе: ключи удаляются, но в истории несуществующего заказа события нет.
+	deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), "b").Return(nil, postgres.ErrOrderNotFound)
+
+	require.NoError(t, deps.service.EvictOrder(ctx, "b"))
+	assert.Equal(t, 0, orderCache.Len())
+}
+
+func TestOrderService_EvictCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("pattern", func(t *testing.T) {
+		deps := newTestDeps(t)
+		orderCache := deps.useLRUCache()
+		for _, key := range []string{"order:test-1", "order:test-2", "order:prod-1", "session:test-1"} {
+			require.NoError(t, orderCache.Set(ctx, key, 1, 0))
+		}
+
+		event := deps.expectHistoryEvent(t, "", EventCachePatternEvicted)
+
+		resp, err := deps.service.EvictCache(ctx, &dto.EvictCacheRequest{Pattern: "order:test-*"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), resp.Deleted)
+		assert.Equal(t, 2, orderCache.Len())
+		assert.Equal(t, []models.FieldChange{
+			{Field: "pattern", New: "order:test-*"},
+			{Field: "deleted", New: int64(2)},
+		}, event.Changes)
+
+		_, err = deps.service.EvictCache(ctx, &dto.EvictCacheRequest{Pattern: "*"})
+		assert.True(t, apperrors.IsInvalid(err), "only order keys may be evicted")
+		_, err = deps.service.EvictCache(ctx, &dto.EvictCacheRequest{Pattern: "order:*", CustomerID: "c1"})
+		assert.True(t, apperrors.IsInvalid(err))
+		assert.Equal(t, 2, orderCache.Len())
+	})
+
+	t.Run("customer pages through orders", func(t *testing.T) {
+		deps := newTestDeps(t)
+		orderCache := deps.useLRUCache()
+
+		orders := make([]*models.Order, evictPageSize+1)
+		for i := range orders {
+			orders[i] = &models.Order{OrderUID: fmt.Sprintf("c1-%d", i), DateCreated: time.Unix(int64(1000-i), 0)}
+			require.NoError(t, orderCache.Set(ctx, orderCacheKey(orders[i].OrderUID), 1, 0))
+		}
+		require.NoError(t, orderCache.Set(ctx, "order:other", 1, 0))
+
+		last := orders[evictPageSize-1]
+		gomock.InOrder(
+			deps.orderRepo.EXPECT().ListOrders(gomock.Any(), models.OrderFilter{CustomerID: "c1", Limit: evictPageSize}).
+				Return(orders[:evictPageSize], nil),
+			deps.orderRepo.EXPECT().ListOrders(gomock.Any(), models.OrderFilter{
+				CustomerID: "c1", Limit: evictPageSize,
+				After: &models.OrderCursor{DateCreated: last.DateCreated, OrderUID: last.OrderUID},
+			}).Return(orders[evictPageSize:], nil),
+		)
+		var events int
+		deps.historyRepo.EXPECT().AddEvents(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, batch []*models.OrderHistoryEvent) error {
+				events += len(batch)
+				return nil
+			}).Times(2)
+
+		resp, err := deps.service.EvictCache(ctx, &dto.EvictCacheRequest{CustomerID: "c1"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(evictPageSize+1), resp.Deleted)
+		assert.Equal(t, evictPageSize+1, events)
+		assert.Equal(t, 1, orderCache.Len())
+	})
+}
+
+func TestOrderService_TriggerWarmUp(t *testing.T) {
+	deps := newTestDeps(t)
+	deps.service.SetWarmUpOptions(WarmUpOptions{ReadyThreshold: 1})
+
+	deps.orderRepo.EXPECT().GetRecentOrderUIDs(gomock.Any(), 1000).Return(orderUIDs(1), nil)
+	expectOrdersByIDs(deps.orderRepo)
+	deps.cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	require.NoError(t, deps.service.WarmUpCache(context.Background()))
+	require.True(t, deps.service.WarmUpReady())
+
+	release := make(chan struct{})
+	deps.orderRepo.EXPECT().GetRecentOrderUIDs(gomock.Any(), 1000).DoAndReturn(
+		func(context.Context, int) ([]string, error) {
+			<-release
+			return orderUIDs(2), nil
+		})
+	deps.cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	event := deps.expectHistoryEvent(t, "", EventCacheWarmUpTriggered)
+
+	require.NoError(t, deps.service.TriggerWarmUp(context.Background()))
+	assert.Equal(t, []models.FieldChange{{Field: "strategy", New: string(WarmUpRecent)}}, event.Changes)
+	assert.ErrorIs(t, deps.service.TriggerWarmUp(context.Background()), ErrWarmUpRunning)
+	assert.ErrorIs(t, deps.service.WarmUpCache(context.Background()), ErrWarmUpRunning)
+
+	progress := deps.service.WarmUpProgress()
+	assert.False(t, progress.Done)
+	assert.True(t, progress.Ready, "a repeated warm-up keeps the service ready")
+
+	close(release)
+	require.Eventually(t, func() bool { return deps.service.WarmUpProgress().Done }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(2), deps.service.WarmUpProgress().Cached)
+	require.Eventually(t, func() bool { return !deps.service.warmUp.running.Load() }, time.Second, 10*time.Millisecond)
+}
+
+func TestOrderService_CacheStats(t *testing.T) {
+	deps := newTestDeps(t)
+
+	deps.cache.EXPECT().Stats(gomock.Any()).Return(cache.Stats{
+		Keys: 10, MemoryBytes: 2048, Hits: 3, Misses: 1,
+		Local: &cache.Stats{Keys: 2},
+	}, nil)
+	deps.cache.EXPECT().Stats(gomock.Any()).Return(cache.Stats{
+		Local: &cache.Stats{Keys: 2}, RemoteError: "cache is unavailable",
+	}, nil)
+
+	resp, err := deps.service.CacheStats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &dto.CacheStatsResponse{
+		Keys: 10, MemoryBytes: 2048, Hits: 3, Misses: 1, HitRatio: 0.75,
+		Local: &dto.CacheStatsResponse{Keys: 2},
+	}, resp)
+
+	resp, err = deps.service.CacheStats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &dto.CacheStatsResponse{
+		Local: &dto.CacheStatsResponse{Keys: 2}, RemoteError: "cache is unavailable",
+	}, resp)
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+	"github.com/zhavkk/order-service/pkg/cache"
+	"golang.org/x/time/rate"
+)
+
+// Виды расхождений кэша с базой.
+const (
+	CacheMismatchStale    = "stale"
+	CacheMismatchOrphaned = "orphaned"
+	CacheMismatchInvalid  = "invalid"
+)
+
+// maxReportedMismatches ограничивает список расхождений в отчете; счетчики считаются полностью.
+const maxReportedMismatches = 1000
+
+var errCacheCheckLimit = errors.New("cache check limit reached")
+
+// KeyScanner обходит ключи общего кэша (см. rediscache.Client.Scan).
+type KeyScanner interface {
+	Scan(ctx context.Context, match string, count int64, fn func(keys []string) error) error
+}
+
+type CacheCheckOptions struct {
+	// Rate — сколько ключей в секунду проверять; каждый ключ — чтение из Redis и из Postgres.
+	// 0 — без ограничения.
+	Rate float64
+	// ScanCount — подсказка COUNT для SCAN.
+	ScanCount int64
+	// Limit — сколько ключей проверить; 0 — все.
+	Limit int
+	// Repair удаляет устаревшие и нечитаемые копии.
+	Repair bool
+	// DeleteOrphans удаляет копии заказов, которых нет в базе.
+	DeleteOrphans bool
+}
+
+type CacheMismatch struct {
+	Key      string   `json:"key"`
+	OrderUID string   `json:"order_uid"`
+	Kind     string   `json:"kind"`
+	Fields   []string `json:"fields,omitempty"`
+}
+
+type CacheCheckReport struct {
+	Scanned    int             `json:"scanned"`
+	Consistent int             `json:"consistent"`
+	Expired    int             `json:"expired"`
+	Stale      int             `json:"stale"`
+	Orphaned   int             `json:"orphaned"`
+	Invalid    int             `json:"invalid"`
+	Repaired   int             `json:"repaired"`
+	Deleted    int             `json:"deleted"`
+	Errors     int             `json:"errors"`
+	Mismatches []CacheMismatch `json:"mismatches,omitempty"`
+}
+
+// Unresolved — сколько найденных расхождений осталось в кэше.
+func (r *CacheCheckReport) Unresolved() int {
+	return r.Stale + r.Orphaned + r.Invalid - r.Repaired - r.Deleted
+}
+
+func (r *CacheCheckReport) addMismatch(m CacheMismatch) {
+	switch m.Kind {
+	case CacheMismatchStale:
+		r.Stale++
+	case CacheMismatchOrphaned:
+		r.Orphaned++
+	case CacheMismatchInvalid:
+		r.Invalid++
+	}
+	if len(r.Mismatches) < maxReportedMismatches {
+		r.Mismatches = append(r.Mismatches, m)
+	}
+}
+
+// CheckCache сравнивает копии заказов в кэше (ключи order:*) с заказами в базе и, если
+// это разрешено opts, исправляет расхождения. Ошибки отдельных ключей учитываются в отчете
+// и не прерывают проверку.
+func (s *OrderService) CheckCache(ctx context.Context, keys KeyScanner, opts CacheCheckOptions) (*CacheCheckReport, error) {
+	const op = "OrderService.CheckCache"
+
+	limit := rate.Inf
+	if opts.Rate > 0 {
+		limit = rate.Limit(opts.Rate)
+	}
+	limiter := rate.NewLimiter(limit, 1)
+	if opts.ScanCount <= 0 {
+		opts.ScanCount = 100
+	}
+
+	report := &CacheCheckReport{}
+	err := keys.Scan(ctx, orderCacheKey("*"), opts.ScanCount, func(batch []string) error {
+		for _, key := range batch {
+			if opts.Limit > 0 && report.Scanned >= opts.Limit {
+				return errCacheCheckLimit
+			}
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+			report.Scanned++
+			s.checkCachedOrder(ctx, key, opts, report)
+		}
+		return nil
+	})
+	if errors.Is(err, errCacheCheckLimit) {
+		err = nil
+	}
+
+	logger.Log.Info(op, "Cache check finished, scanned: ", report.Scanned,
+		"stale", report.Stale, "orphaned", report.Orphaned, "invalid", report.Invalid,
+		"repaired", report.Repaired, "deleted", report.Deleted, "errors", report.Errors)
+
+	return report, err
+}
+
+func (s *OrderService) checkCachedOrder(ctx context.Context, key string, opts CacheCheckOptions, report *CacheCheckReport) {
+	const op = "OrderService.checkCachedOrder"
+
+	orderUID := strings.TrimPrefix(key, orderCacheKey(""))
+	mismatch := CacheMismatch{Key: key, OrderUID: orderUID}
+
+	var cached cachedOrder
+	err := s.cache.Get(ctx, key, &cached)
+	switch {
+	case errors.Is(err, cache.ErrCacheMiss):
+		// Истек между SCAN и GET.
+		report.Expired++
+		return
+	case errors.Is(err, cache.ErrInvalidValue), err == nil && cached.Order == nil:
+		mismatch.Kind = CacheMismatchInvalid
+		report.addMismatch(mismatch)
+		logger.Log.Warn(op, "Cached order is unreadable, key: ", key)
+		if opts.Repair {
+			s.deleteCheckedKey(ctx, key, report)
+		}
+		return
+	case err != nil:
+		logger.Log.Error(op, "Failed to read cached order", err)
+		report.Errors++
+		return
+	}
+
+	stored, err := s.orderRepo.GetOrderByID(ctx, orderUID)
+	if errors.Is(err, postgres.ErrOrderNotFound) {
+		mismatch.Kind = CacheMismatchOrphaned
+		report.addMismatch(mismatch)
+		logger.Log.Warn(op, "Cached order is missing in database, order_id: ", orderUID)
+		if opts.DeleteOrphans {
+			s.deleteCheckedKey(ctx, key, report)
+		}
+		return
+	}
+	if err != nil {
+		logger.Log.Error(op, "Failed to get order from repository", err)
+		report.Errors++
+		return
+	}
+
+	changes, err := s.diffCachedOrder(cached.Order, stored)
+	if err != nil {
+		logger.Log.Error(op, "Failed to compare cached order", err)
+		report.Errors++
+		return
+	}
+	if len(changes) == 0 {
+		report.Consistent++
+		return
+	}
+
+	mismatch.Kind = CacheMismatchStale
+	for _, change := range changes {
+		mismatch.Fields = append(mismatch.Fields, change.Field)
+	}
+	report.addMismatch(mismatch)
+	logger.Log.Warn(op, "Cached order differs from database, order_id: ", orderUID, "fields", mismatch.Fields)
+
+	// Копия удаляется, а не перезаписывается прочитанным заказом: он мог обновиться после
+	// чтения, и запись затерла бы более свежую копию. Следующее чтение загрузит заказ из базы.
+	if opts.Repair {
+		if err := s.cache.Delete(ctx, key); err != nil {
+			logger.Log.Error(op, "Failed to repair cached order", err)
+			report.Errors++
+			return
+		}
+		report.Repaired++
+	}
+}
+
+func (s *OrderService) deleteCheckedKey(ctx context.Context, key string, report *CacheCheckReport) {
+	const op = "OrderService.deleteCheckedKey"
+
+	if err := s.cache.Delete(ctx, key); err != nil {
+		logger.Log.Error(op, "Failed to delete cached order", err)
+		report.Errors++
+		return
+	}
+	report.Deleted++
+}
+
+// diffCachedOrder сравнивает копию из кэша с заказом из базы в представлении ответа API.
+// Время сравнивается в UTC с точностью Postgres: в кэш заказ мог попасть до записи в базу.
+func (s *OrderService) diffCachedOrder(cached, stored *models.Order) ([]models.FieldChange, error) {
+	normalize := func(order models.Order) *models.Order {
+		order.DateCreated = order.DateCreated.UTC().Truncate(time.Microsecond)
+		order.UpdatedAt = order.UpdatedAt.UTC().Truncate(time.Microsecond)
+		return &order
+	}
+	return diffOrderResponses(s.modelToDTO(normalize(*cached)), s.modelToDTO(normalize(*stored)), nil)
+}
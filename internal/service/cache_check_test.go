@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+	"github.com/zhavkk/order-service/pkg/cache"
+	lrucache "github.com/zhavkk/order-service/pkg/cache/lru"
+)
+
+// fakeKeys отдает ключи пачками по count, как SCAN.
+type fakeKeys []string
+
+func (k fakeKeys) Scan(_ context.Context, match string, count int64, fn func(keys []string) error) error {
+	if match != "order:*" {
+		return fmt.Errorf("unexpected match %q", match)
+	}
+	for start := 0; start < len(k); start += int(count) {
+		if err := fn(k[start:min(start+int(count), len(k))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unreadableCache имитирует значение, записанное в неизвестном формате.
+type unreadableCache struct {
+	*lrucache.Cache
+	key string
+}
+
+func (c unreadableCache) Get(ctx context.Context, key string, destination any) error {
+	if key == c.key {
+		var raw any
+		if err := c.Cache.Get(ctx, key, &raw); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: unknown codec", cache.ErrInvalidValue)
+	}
+	return c.Cache.Get(ctx, key, destination)
+}
+
+type cacheCheckFixture struct {
+	s     *OrderService
+	cache *lrucache.Cache
+	keys  fakeKeys
+	fresh models.Order
+	stale models.Order
+}
+
+// newCacheCheckFixture кладет в кэш по одному заказу каждого вида: актуальный, устаревший,
+// удаленный из базы, нечитаемый и истекший (есть в SCAN, но не в кэше).
+func newCacheCheckFixture(t *testing.T) *cacheCheckFixture {
+	deps := newTestDeps(t)
+	orderCache := deps.useLRUCache()
+	s := deps.service
+	s.cache = unreadableCache{Cache: orderCache, key: "order:broken"}
+	ctx := context.Background()
+
+	fresh := generateRandomOrder()
+	require.NoError(t, s.cacheOrder(ctx, &fresh, 0))
+	// В кэше время с наносекундами и в другой зоне, в базе — с точностью до микросекунд.
+	storedFresh := fresh
+	storedFresh.DateCreated = fresh.DateCreated.In(time.FixedZone("MSK", 3*3600)).Truncate(time.Microsecond)
+
+	stale := generateRandomOrder()
+	stale.Status = models.OrderStatusCreated
+	require.NoError(t, s.cacheOrder(ctx, &stale, 0))
+	storedStale := stale
+	storedStale.Status = models.OrderStatusPaid
+	storedStale.Version = 2
+
+	orphan := generateRandomOrder()
+	require.NoError(t, s.cacheOrder(ctx, &orphan, 0))
+	require.NoError(t, orderCache.Set(ctx, "order:broken", "garbage", time.Minute))
+
+	deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), fresh.OrderUID).Return(&storedFresh, nil).AnyTimes()
+	deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), stale.OrderUID).Return(&storedStale, nil).AnyTimes()
+	deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), orphan.OrderUID).Return(nil, postgres.ErrOrderNotFound).AnyTimes()
+
+	return &cacheCheckFixture{
+		s:     s,
+		cache: orderCache,
+		keys: fakeKeys{
+			orderCacheKey(fresh.OrderUID), orderCacheKey(stale.OrderUID), orderCacheKey(orphan.OrderUID),
+			"order:broken", "order:expired",
+		},
+		fresh: fresh,
+		stale: storedStale,
+	}
+}
+
+func TestOrderService_CheckCache_Report(t *testing.T) {
+	f := newCacheCheckFixture(t)
+
+	report, err := f.s.CheckCache(context.Background(), f.keys, CacheCheckOptions{ScanCount: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, report.Scanned)
+	assert.Equal(t, 1, report.Consistent)
+	assert.Equal(t, 1, report.Expired)
+	assert.Equal(t, 1, report.Stale)
+	assert.Equal(t, 1, report.Orphaned)
+	assert.Equal(t, 1, report.Invalid)
+	assert.Equal(t, 3, report.Unresolved())
+	require.Len(t, report.Mismatches, 3)
+	assert.Equal(t, CacheMismatch{
+		Key: orderCacheKey(f.stale.OrderUID), OrderUID: f.stale.OrderUID,
+		Kind: CacheMismatchStale, Fields: []string{"status", "version"},
+	}, report.Mismatches[0])
+
+	// Без -repair и -delete-orphans кэш не меняется.
+	var cached cachedOrder
+	require.NoError(t, f.cache.Get(context.Background(), orderCacheKey(f.stale.OrderUID), &cached))
+	assert.Equal(t, models.OrderStatusCreated, cached.Order.Status)
+}
+
+func TestOrderService_CheckCache_Repair(t *testing.T) {
+	f := newCacheCheckFixture(t)
+	ctx := context.Background()
+
+	report, err := f.s.CheckCache(ctx, f.keys, CacheCheckOptions{Repair: true, DeleteOrphans: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Repaired)
+	assert.Equal(t, 2, report.Deleted)
+	assert.Zero(t, report.Unresolved())
+
+	var cached cachedOrder
+	assert.ErrorIs(t, f.cache.Get(ctx, orderCacheKey(f.stale.OrderUID), &cached), cache.ErrCacheMiss)
+	assert.ErrorIs(t, f.cache.Get(ctx, "order:broken", &cached), cache.ErrCacheMiss)
+
+	report, err = f.s.CheckCache(ctx, f.keys, CacheCheckOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Consistent)
+	assert.Equal(t, 4, report.Expired)
+	assert.Zero(t, report.Unresolved())
+}
+
+func TestOrderService_CheckCache_RateAndLimit(t *testing.T) {
+	f := newCacheCheckFixture(t)
+
+	start := time.Now()
+	report, err := f.s.CheckCache(context.Background(), f.keys, CacheCheckOptions{Rate: 20, Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Scanned)
+	// Первый ключ проверяется сразу, следующие — раз в 50ms.
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = f.s.CheckCache(ctx, f.keys, CacheCheckOptions{Rate: 1})
+	assert.ErrorIs(t, err, context.Canceled)
+}
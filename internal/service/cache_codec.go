@@ -0,0 +1,234 @@
+package service
+
+import (
+	"time"
+
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/pkg/cache/codec"
+)
+
+// Бинарное представление записи кэша для codec.Binary. Номера полей менять нельзя:
+// по ним читаются уже записанные значения. Новые поля — только с новыми номерами.
+// ContentHash, как и в JSON, в кэш не попадает.
+
+func (e cachedOrder) EncodeBinary(w *codec.Writer) {
+	if e.Order != nil {
+		w.Message(1, func(w *codec.Writer) { writeOrder(w, e.Order) })
+	}
+	w.Time(2, e.ExpiresAt)
+	w.Int(3, int64(e.Delta))
+}
+
+func (e *cachedOrder) DecodeBinary(r *codec.Reader) error {
+	*e = cachedOrder{}
+	for r.Next() {
+		switch r.Field() {
+		case 1:
+			e.Order = &models.Order{}
+			r.Message(func(r *codec.Reader) error { return readOrder(r, e.Order) })
+		case 2:
+			e.ExpiresAt = r.Time()
+		case 3:
+			e.Delta = time.Duration(r.Int())
+		}
+	}
+	return r.Err()
+}
+
+func writeOrder(w *codec.Writer, o *models.Order) {
+	w.String(1, o.OrderUID)
+	w.String(2, o.TrackNumber)
+	w.String(3, o.Entry)
+	w.Message(4, func(w *codec.Writer) { writeDelivery(w, &o.Delivery) })
+	w.Message(5, func(w *codec.Writer) { writePayment(w, &o.Payment) })
+	for i := range o.Items {
+		w.Message(6, func(w *codec.Writer) { writeItem(w, &o.Items[i]) })
+	}
+	w.String(7, o.Locale)
+	w.String(8, o.InternalSignature)
+	w.String(9, o.CustomerID)
+	w.String(10, o.DeliveryService)
+	w.String(11, o.ShardKey)
+	w.Int(12, int64(o.SmID))
+	w.Time(13, o.DateCreated)
+	w.String(14, o.OofShard)
+	w.String(15, string(o.Status))
+	w.Int(16, int64(o.Version))
+	w.Time(17, o.UpdatedAt)
+}
+
+func readOrder(r *codec.Reader, o *models.Order) error {
+	for r.Next() {
+		switch r.Field() {
+		case 1:
+			o.OrderUID = r.String()
+		case 2:
+			o.TrackNumber = r.String()
+		case 3:
+			o.Entry = r.String()
+		case 4:
+			r.Message(func(r *codec.Reader) error { return readDelivery(r, &o.Delivery) })
+		case 5:
+			r.Message(func(r *codec.Reader) error { return readPayment(r, &o.Payment) })
+		case 6:
+			var item models.Item
+			r.Message(func(r *codec.Reader) error { return readItem(r, &item) })
+			o.Items = append(o.Items, item)
+		case 7:
+			o.Locale = r.String()
+		case 8:
+			o.InternalSignature = r.String()
+		case 9:
+			o.CustomerID = r.String()
+		case 10:
+			o.DeliveryService = r.String()
+		case 11:
+			o.ShardKey = r.String()
+		case 12:
+			o.SmID = int(r.Int())
+		case 13:
+			o.DateCreated = r.Time()
+		case 14:
+			o.OofShard = r.String()
+		case 15:
+			o.Status = models.OrderStatus(r.String())
+		case 16:
+			o.Version = int(r.Int())
+		case 17:
+			o.UpdatedAt = r.Time()
+		}
+	}
+	return r.Err()
+}
+
+func writeDelivery(w *codec.Writer, d *models.Delivery) {
+	w.Int(1, int64(d.ID))
+	w.String(2, d.OrderID)
+	w.String(3, d.Name)
+	w.String(4, d.Phone)
+	w.String(5, d.Zip)
+	w.String(6, d.City)
+	w.String(7, d.Address)
+	w.String(8, d.Region)
+	w.String(9, d.Email)
+}
+
+func readDelivery(r *codec.Reader, d *models.Delivery) error {
+	for r.Next() {
+		switch r.Field() {
+		case 1:
+			d.ID = int(r.Int())
+		case 2:
+			d.OrderID = r.String()
+		case 3:
+			d.Name = r.String()
+		case 4:
+			d.Phone = r.String()
+		case 5:
+			d.Zip = r.String()
+		case 6:
+			d.City = r.String()
+		case 7:
+			d.Address = r.String()
+		case 8:
+			d.Region = r.String()
+		case 9:
+			d.Email = r.String()
+		}
+	}
+	return r.Err()
+}
+
+func writePayment(w *codec.Writer, p *models.Payment) {
+	w.String(1, p.Transaction)
+	w.String(2, p.OrderID)
+	w.String(3, p.RequestID)
+	w.String(4, p.Currency)
+	w.String(5, p.Provider)
+	w.Int(6, int64(p.Amount))
+	w.Int(7, p.PaymentDt)
+	w.String(8, p.Bank)
+	w.Int(9, int64(p.DeliveryCost))
+	w.Int(10, int64(p.GoodsTotal))
+	w.Int(11, int64(p.CustomFee))
+}
+
+func readPayment(r *codec.Reader, p *models.Payment) error {
+	for r.Next() {
+		switch r.Field() {
+		case 1:
+			p.Transaction = r.String()
+		case 2:
+			p.OrderID = r.String()
+		case 3:
+			p.RequestID = r.String()
+		case 4:
+			p.Currency = r.String()
+		case 5:
+			p.Provider = r.String()
+		case 6:
+			p.Amount = int(r.Int())
+		case 7:
+			p.PaymentDt = r.Int()
+		case 8:
+			p.Bank = r.String()
+		case 9:
+			p.DeliveryCost = int(r.Int())
+		case 10:
+			p.GoodsTotal = int(r.Int())
+		case 11:
+			p.CustomFee = int(r.Int())
+		}
+	}
+	return r.Err()
+}
+
+func writeItem(w *codec.Writer, it *models.Item) {
+	w.Int(1, int64(it.ID))
+	w.String(2, it.OrderID)
+	w.Int(3, it.ChrtID)
+	w.String(4, it.TrackNumber)
+	w.Int(5, int64(it.Price))
+	w.String(6, it.Rid)
+	w.String(7, it.Name)
+	w.Int(8, int64(it.Sale))
+	w.String(9, it.Size)
+	w.Int(10, int64(it.TotalPrice))
+	w.Int(11, it.NmId)
+	w.String(12, it.Brand)
+	w.Int(13, int64(it.Status))
+}
+
+func readItem(r *codec.Reader, it *models.Item) error {
+	for r.Next() {
+		switch r.Field() {
+		case 1:
+			it.ID = int(r.Int())
+		case 2:
+			it.OrderID = r.String()
+		case 3:
+			it.ChrtID = r.Int()
+		case 4:
+			it.TrackNumber = r.String()
+		case 5:
+			it.Price = int(r.Int())
+		case 6:
+			it.Rid = r.String()
+		case 7:
+			it.Name = r.String()
+		case 8:
+			it.Sale = int(r.Int())
+		case 9:
+			it.Size = r.String()
+		case 10:
+			it.TotalPrice = int(r.Int())
+		case 11:
+			it.NmId = r.Int()
+		case 12:
+			it.Brand = r.String()
+		case 13:
+			it.Status = int(r.Int())
+		}
+	}
+	return r.Err()
+}
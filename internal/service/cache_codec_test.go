@@ -0,0 +1,107 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/pkg/cache/codec"
+)
+
+// cachedOrderWithItems — запись кэша с заказом из n товаров.
+func cachedOrderWithItems(n int) cachedOrder {
+	order := generateRandomOrder()
+	order.Status = models.OrderStatusPaid
+	order.Version = 3
+	order.UpdatedAt = order.DateCreated.Add(time.Hour)
+	order.Delivery.ID = 7
+	item := order.Items[0]
+	order.Items = make([]models.Item, n)
+	for i := range order.Items {
+		order.Items[i] = item
+		order.Items[i].ID = i + 1
+		order.Items[i].Rid = fmt.Sprintf("rid-%d", i)
+	}
+	return cachedOrder{
+		Order:     &order,
+		ExpiresAt: time.Now().Add(5 * time.Minute),
+		Delta:     3 * time.Millisecond,
+	}
+}
+
+func TestCachedOrder_BinaryRoundTrip(t *testing.T) {
+	want := cachedOrderWithItems(3)
+	format, err := codec.NewFormat(codec.NameBinary, codec.CompressionNone, 0)
+	require.NoError(t, err)
+
+	data, err := format.Encode(want)
+	require.NoError(t, err)
+	var got cachedOrder
+	require.NoError(t, format.Decode(data, &got))
+
+	// Время хранится как момент в UTC.
+	assert.True(t, want.ExpiresAt.Equal(got.ExpiresAt))
+	assert.True(t, want.Order.DateCreated.Equal(got.Order.DateCreated))
+	assert.True(t, want.Order.UpdatedAt.Equal(got.Order.UpdatedAt))
+	got.ExpiresAt, got.Order.DateCreated, got.Order.UpdatedAt = want.ExpiresAt, want.Order.DateCreated, want.Order.UpdatedAt
+	assert.Equal(t, want, got)
+}
+
+var benchmarkFormats = []struct{ codec, compression string }{
+	{codec.NameJSON, codec.CompressionNone},
+	{codec.NameJSON, codec.CompressionZstd},
+	{codec.NameJSON, codec.CompressionSnappy},
+	{codec.NameMsgPack, codec.CompressionNone},
+	{codec.NameMsgPack, codec.CompressionZstd},
+	{codec.NameMsgPack, codec.CompressionSnappy},
+	{codec.NameBinary, codec.CompressionNone},
+	{codec.NameBinary, codec.CompressionZstd},
+	{codec.NameBinary, codec.CompressionSnappy},
+}
+
+// go test ./internal/service -run '^$' -bench CachedOrder -benchmem
+func BenchmarkCachedOrder_Encode(b *testing.B) {
+	for _, items := range []int{1, 50} {
+		entry := cachedOrderWithItems(items)
+		for _, f := range benchmarkFormats {
+			b.Run(fmt.Sprintf("items=%d/%s+%s", items, f.codec, f.compression), func(b *testing.B) {
+				format, err := codec.NewFormat(f.codec, f.compression, 1024)
+				require.NoError(b, err)
+
+				var size int
+				for b.Loop() {
+					data, err := format.Encode(entry)
+					if err != nil {
+						b.Fatal(err)
+					}
+					size = len(data)
+				}
+				b.ReportMetric(float64(size), "bytes")
+			})
+		}
+	}
+}
+
+func BenchmarkCachedOrder_Decode(b *testing.B) {
+	for _, items := range []int{1, 50} {
+		entry := cachedOrderWithItems(items)
+		for _, f := range benchmarkFormats {
+			b.Run(fmt.Sprintf("items=%d/%s+%s", items, f.codec, f.compression), func(b *testing.B) {
+				format, err := codec.NewFormat(f.codec, f.compression, 1024)
+				require.NoError(b, err)
+				data, err := format.Encode(entry)
+				require.NoError(b, err)
+
+				for b.Loop() {
+					var got cachedOrder
+					if err := format.Decode(data, &got); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/models"
+)
+
+const defaultListLimit = 20
+
+// Курсор непрозрачен для клиента: base64url от "date_created|order_uid". У заказа без
+// date_created дата в курсоре пустая.
+func encodeCursor(c *models.OrderCursor) string {
+	var date string
+	if !c.DateCreated.IsZero() {
+		date = c.DateCreated.UTC().Format(time.RFC3339Nano)
+	}
+	raw := date + "|" + c.OrderUID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (*models.OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, apperrors.Invalid("malformed cursor", err)
+	}
+
+	date, uid, ok := strings.Cut(string(raw), "|")
+	if !ok || uid == "" {
+		return nil, apperrors.Invalid("malformed cursor", nil)
+	}
+	cursor := &models.OrderCursor{OrderUID: uid}
+	if date != "" {
+		if cursor.DateCreated, err = time.Parse(time.RFC3339Nano, date); err != nil {
+			return nil, apperrors.Invalid("malformed cursor", err)
+		}
+	}
+
+	return cursor, nil
+}
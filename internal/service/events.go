@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/pkg/pgstorage"
+)
+
+const EventOrderCreated = "order.created"
+
+// newOrderEvent формирует событие для outbox; payload совпадает с ответом GET /orders/{id}.
+func (s *OrderService) newOrderEvent(order *models.Order, eventType string) (*models.OutboxEvent, error) {
+	payload, err := json.Marshal(s.modelToDTO(order))
+	if err != nil {
+		return nil, apperrors.Invalid("failed to encode order event", err)
+	}
+
+	return &models.OutboxEvent{
+		AggregateID: order.OrderUID,
+		EventType:   eventType,
+		Payload:     payload,
+	}, nil
+}
+
+// SetEventNotifier задает функцию, которая будит отправку outbox после коммита новых событий.
+func (s *OrderService) SetEventNotifier(notify func()) {
+	s.notifyEvents = notify
+}
+
+// addOutboxEvents пишет события в outbox в текущей транзакции и после коммита будит отправку.
+func (s *OrderService) addOutboxEvents(ctx context.Context, events []*models.OutboxEvent) error {
+	if err := s.outboxRepo.AddEvents(ctx, events); err != nil {
+		return err
+	}
+	if s.notifyEvents != nil {
+		pgstorage.AfterCommit(ctx, func(context.Context) { s.notifyEvents() })
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/repository/mocks"
+	lrucache "github.com/zhavkk/order-service/pkg/cache/lru"
+	"github.com/zhavkk/order-service/pkg/pgstorage"
+)
+
+const testCacheTTL = 5 * time.Minute
+
+// testDeps — OrderService, собранный на моках, и сами моки для ожиданий в тестах.
+type testDeps struct {
+	ctrl            *gomock.Controller
+	service         *OrderService
+	orderRepo       *mocks.MockOrderRepository
+	deliveryRepo    *mocks.MockDeliveryRepository
+	paymentRepo     *mocks.MockPaymentRepository
+	itemsRepo       *mocks.MockItemsRepository
+	outboxRepo      *mocks.MockOutboxRepository
+	historyRepo     *mocks.MockHistoryRepository
+	accessRepo      *mocks.MockAccessRepository
+	idempotencyRepo *mocks.MockIdempotencyRepository
+	txManager       *mocks.MockTxManagerInterface
+	cache           *mocks.MockCache
+}
+
+// newTestDeps собирает сервис со всеми зависимостями-моками. Транзакция выполняется сразу,
+// хуки AfterCommit — после ее успешного завершения, как в pgstorage.TxManager.
+func newTestDeps(t *testing.T) *testDeps {
+	ctrl := gomock.NewController(t)
+	logger.Init("local")
+
+	d := &testDeps{
+		ctrl:            ctrl,
+		orderRepo:       mocks.NewMockOrderRepository(ctrl),
+		deliveryRepo:    mocks.NewMockDeliveryRepository(ctrl),
+		paymentRepo:     mocks.NewMockPaymentRepository(ctrl),
+		itemsRepo:       mocks.NewMockItemsRepository(ctrl),
+		outboxRepo:      mocks.NewMockOutboxRepository(ctrl),
+		historyRepo:     mocks.NewMockHistoryRepository(ctrl),
+		accessRepo:      mocks.NewMockAccessRepository(ctrl),
+		idempotencyRepo: mocks.NewMockIdempotencyRepository(ctrl),
+		txManager:       mocks.NewMockTxManagerInterface(ctrl),
+		cache:           mocks.NewMockCache(ctrl),
+	}
+	d.txManager.EXPECT().RunSerializableWithRetry(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error) error {
+			txCtx, commit := pgstorage.WithCommitHooks(ctx)
+			if err := fn(txCtx); err != nil {
+				return err
+			}
+			commit(ctx)
+			return nil
+		},
+	).AnyTimes()
+
+	d.service = NewOrderService(
+		d.orderRepo, d.deliveryRepo, d.paymentRepo, d.itemsRepo, d.outboxRepo, d.historyRepo,
+		d.txManager, d.cache, testCacheTTL,
+	)
+	d.service.SetIdempotencyStore(NewIdempotencyStore(d.idempotencyRepo, time.Hour))
+	return d
+}
+
+// useLRUCache подменяет мок кэша настоящим LRU, когда тесту важно содержимое кэша.
+func (d *testDeps) useLRUCache() *lrucache.Cache {
+	orderCache := lrucache.New(2000, time.Hour)
+	d.service.cache = orderCache
+	return orderCache
+}
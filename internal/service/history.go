@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/zhavkk/order-service/internal/audit"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/models"
+)
+
+const EventCacheInvalidated = "cache.invalidated"
+
+// События админских операций над кэшем целиком. Они не относятся к одному заказу, поэтому
+// order_uid у них пустой, а параметры операции записаны в changes.
+const (
+	EventCachePatternEvicted  = "cache.pattern_evicted"
+	EventCacheWarmUpTriggered = "cache.warmup_triggered"
+)
+
+// Поля ответа, которые меняются не через содержимое заказа и не попадают в diff обновления.
+var historyIgnoredFields = map[string]struct{}{
+	"status":     {},
+	"version":    {},
+	"updated_at": {},
+}
+
+// newHistoryEvent заполняет инициатора события из контекста (см. пакет audit).
+func newHistoryEvent(ctx context.Context, orderUID, eventType string, changes []models.FieldChange) *models.OrderHistoryEvent {
+	origin := audit.FromContext(ctx)
+	return &models.OrderHistoryEvent{
+		OrderUID:  orderUID,
+		EventType: eventType,
+		Actor:     origin.Actor,
+		Source:    origin.Source,
+		Changes:   changes,
+	}
+}
+
+// withOrderOrigin возвращает контекст с инициатором i-го заказа пачки. Без origins
+// остается инициатор из ctx.
+func withOrderOrigin(ctx context.Context, origins []audit.Origin, i int) context.Context {
+	if origins == nil {
+		return ctx
+	}
+	return audit.WithOrigin(ctx, origins[i].Actor, origins[i].Source)
+}
+
+func (s *OrderService) addHistory(ctx context.Context, events ...*models.OrderHistoryEvent) error {
+	const op = "OrderService.addHistory"
+
+	if err := s.historyRepo.AddEvents(ctx, events); err != nil {
+		logger.Log.Error(op, "Failed to add order history", err)
+		return err
+	}
+	return nil
+}
+
+// invalidateCachedOrder удаляет заказ из кэша и отмечает это в истории заказа. Ошибка кэша
+// не возвращается: недоступный кэш не должен откатывать запись заказа.
+func (s *OrderService) invalidateCachedOrder(ctx context.Context, orderUID string) error {
+	const op = "OrderService.invalidateCachedOrder"
+
+	s.forgetMissingOrder(ctx, orderUID)
+	if err := s.cache.Delete(ctx, orderCacheKey(orderUID)); err != nil {
+		logger.Log.Warn(op, "Failed to delete cached order", err)
+		return nil
+	}
+	return s.addHistory(ctx, newHistoryEvent(ctx, orderUID, EventCacheInvalidated, nil))
+}
+
+// GetOrderHistory возвращает все события заказа в порядке их записи.
+func (s *OrderService) GetOrderHistory(ctx context.Context, orderUID string) (*dto.OrderHistoryResponse, error) {
+	const op = "OrderService.GetOrderHistory"
+
+	events, err := s.historyRepo.GetHistory(ctx, orderUID)
+	if err != nil {
+		logger.Log.Error(op, "Failed to get order history", err)
+		return nil, err
+	}
+	if len(events) == 0 {
+		// Пустая история бывает у заказов, сохраненных до появления order_events.
+		if _, err := s.orderRepo.GetOrderVersion(ctx, orderUID); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &dto.OrderHistoryResponse{
+		OrderUID: orderUID,
+		Events:   make([]dto.OrderHistoryEventDTO, len(events)),
+	}
+	for i, e := range events {
+		changes := make([]dto.FieldChangeDTO, len(e.Changes))
+		for j, c := range e.Changes {
+			changes[j] = dto.FieldChangeDTO{Field: c.Field, Old: c.Old, New: c.New}
+		}
+		resp.Events[i] = dto.OrderHistoryEventDTO{
+			ID:        e.ID,
+			EventType: e.EventType,
+			Actor:     e.Actor,
+			Source:    e.Source,
+			Changes:   changes,
+			CreatedAt: e.CreatedAt,
+		}
+	}
+
+	return resp, nil
+}
+
+// diffOrders сравнивает заказы в представлении ответа API и возвращает измененные поля,
+// отсортированные по пути.
+func (s *OrderService) diffOrders(before, after *models.Order) ([]models.FieldChange, error) {
+	return diffOrderResponses(s.modelToDTO(before), s.modelToDTO(after), historyIgnoredFields)
+}
+
+// diffOrderResponses возвращает различающиеся поля, кроме ignored, отсортированные по пути.
+func diffOrderResponses(before, after dto.OrderResponse, ignored map[string]struct{}) ([]models.FieldChange, error) {
+	old, err := flattenOrder(before, ignored)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := flattenOrder(after, ignored)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]struct{}, len(old))
+	for field := range old {
+		fields[field] = struct{}{}
+	}
+	for field := range updated {
+		fields[field] = struct{}{}
+	}
+
+	var changes []models.FieldChange
+	for field := range fields {
+		if !reflect.DeepEqual(old[field], updated[field]) {
+			changes = append(changes, models.FieldChange{Field: field, Old: old[field], New: updated[field]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes, nil
+}
+
+func flattenOrder(order dto.OrderResponse, ignored map[string]struct{}) (map[string]any, error) {
+	data, err := json.Marshal(order)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for field := range ignored {
+		delete(doc, field)
+	}
+
+	out := make(map[string]any)
+	flatten("", doc, out)
+	return out, nil
+}
+
+func flatten(prefix string, v any, out map[string]any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flatten(path, value, out)
+		}
+	case []any:
+		for i, value := range v {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), value, out)
+		}
+	default:
+		out[prefix] = v
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/audit"
+	"github.com/zhavkk/order-service/internal/models"
+)
+
+func TestOrderService_DiffOrders(t *testing.T) {
+	s := &OrderService{}
+	before := generateRandomOrder()
+	after := before
+	after.Items = append([]models.Item{}, before.Items...)
+	after.Payment.Amount = 1200
+	after.Payment.DeliveryCost = 300
+	after.Items[0].Price = 1100
+	after.Status = models.OrderStatusPaid
+	after.Version = 7
+
+	changes, err := s.diffOrders(&before, &after)
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.FieldChange{
+		{Field: "items[0].price", Old: float64(1000), New: float64(1100)},
+		{Field: "payment.amount", Old: float64(1000), New: float64(1200)},
+		{Field: "payment.delivery_cost", Old: float64(100), New: float64(300)},
+	}, changes)
+
+	after.Items = append(after.Items, before.Items[0])
+	changes, err = s.diffOrders(&before, &after)
+
+	require.NoError(t, err)
+	assert.Contains(t, changes, models.FieldChange{Field: "items[1].name", Old: nil, New: "Test Item"})
+}
+
+func TestOrderService_ProcessMessages_HistoryOrigins(t *testing.T) {
+	deps := newTestDeps(t)
+	first, second := generateRandomOrder(), generateRandomOrder()
+	messages := [][]byte{orderMessage(t, first), []byte("broken"), orderMessage(t, second)}
+	origins := []audit.Origin{
+		{Actor: audit.ActorConsumer, Source: "kafka:orders/0/10"},
+		{Actor: audit.ActorConsumer, Source: "kafka:orders/0/11"},
+		{Actor: audit.ActorConsumer, Source: "kafka:orders/0/12"},
+	}
+
+	deps.orderRepo.EXPECT().CreateOrders(gomock.Any(), gomock.Len(2)).Return(nil)
+	deps.itemsRepo.EXPECT().AddItemsBatch(gomock.Any(), gomock.Any()).Return(nil)
+	deps.deliveryRepo.EXPECT().CreateDeliveries(gomock.Any(), gomock.Len(2)).Return(nil)
+	deps.paymentRepo.EXPECT().CreatePayments(gomock.Any(), gomock.Len(2)).Return(nil)
+	deps.outboxRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(2)).Return(nil)
+	deps.historyRepo.EXPECT().AddEvents(gomock.Any(), []*models.OrderHistoryEvent{
+		{OrderUID: first.OrderUID, EventType: EventOrderCreated, Actor: audit.ActorConsumer, Source: "kafka:orders/0/10"},
+		{OrderUID: second.OrderUID, EventType: EventOrderCreated, Actor: audit.ActorConsumer, Source: "kafka:orders/0/12"},
+	}).Return(nil)
+	deps.cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), testCacheTTL).Return(nil).Times(2)
+
+	errs := deps.service.ProcessMessages(context.Background(), messages, origins)
+
+	assert.NoError(t, errs[0])
+	assert.True(t, apperrors.IsInvalid(errs[1]))
+	assert.NoError(t, errs[2])
+}
+
+// expectHistoryEvent ожидает запись одного события истории и возвращает его для проверок.
+func (d *testDeps) expectHistoryEvent(t *testing.T, orderUID, eventType string) *models.OrderHistoryEvent {
+	t.Helper()
+	event := &models.OrderHistoryEvent{}
+	d.historyRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).DoAndReturn(
+		func(_ context.Context, events []*models.OrderHistoryEvent) error {
+			assert.Equal(t, orderUID, events[0].OrderUID)
+			assert.Equal(t, eventType, events[0].EventType)
+			*event = *events[0]
+			return nil
+		},
+	)
+	return event
+}
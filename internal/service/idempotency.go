@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+)
+
+var ErrIdempotencyStoreRunning = errors.New("idempotency store is already running")
+
+// idempotencyCleanupInterval — как часто удаляются истекшие ключи.
+const idempotencyCleanupInterval = time.Hour
+
+// IdempotencyStore хранит ответы на запросы приема заказов с Idempotency-Key в течение ttl
+// и периодически удаляет истекшие.
+type IdempotencyStore struct {
+	repo IdempotencyRepository
+	ttl  time.Duration
+
+	running  atomic.Bool
+	stopOnce sync.Once
+	stopped  chan struct{}
+	done     chan struct{}
+}
+
+func NewIdempotencyStore(repo IdempotencyRepository, ttl time.Duration) *IdempotencyStore {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &IdempotencyStore{
+		repo:    repo,
+		ttl:     ttl,
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Get возвращает действующую запись по ключу или nil, если ее нет.
+func (s *IdempotencyStore) Get(ctx context.Context, key string) (*models.IdempotencyRecord, error) {
+	record, err := s.repo.GetIdempotencyRecord(ctx, key, time.Now().Add(-s.ttl))
+	if errors.Is(err, postgres.ErrIdempotencyKeyNotFound) {
+		return nil, nil
+	}
+	return record, err
+}
+
+func (s *IdempotencyStore) Save(ctx context.Context, record *models.IdempotencyRecord) error {
+	return s.repo.SaveIdempotencyRecord(ctx, record, time.Now().Add(-s.ttl))
+}
+
+// Run удаляет истекшие ключи раз в час до Shutdown или отмены ctx.
+func (s *IdempotencyStore) Run(ctx context.Context) error {
+	const op = "IdempotencyStore.Run"
+
+	if !s.running.CompareAndSwap(false, true) {
+		return ErrIdempotencyStoreRunning
+	}
+	defer close(s.done)
+
+	ticker := time.NewTicker(idempotencyCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopped:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		deleted, err := s.repo.DeleteIdempotencyRecordsBefore(ctx, time.Now().Add(-s.ttl))
+		if err != nil {
+			logger.Log.Error(op, "Failed to delete expired idempotency keys", err)
+			continue
+		}
+		logger.Log.Info(op, "Expired idempotency keys deleted: ", deleted)
+	}
+}
+
+// Shutdown останавливает Run, но не ждет дольше дедлайна ctx.
+func (s *IdempotencyStore) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stopped) })
+
+	if s.running.Load() {
+		select {
+		case <-s.done:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for idempotency store: %w", ctx.Err())
+		}
+	}
+	return nil
+}
+
+// SetIdempotencyStore включает поддержку Idempotency-Key в SubmitOrders.
+func (s *OrderService) SetIdempotencyStore(store *IdempotencyStore) {
+	s.idempotency = store
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/models"
+	prometheusmetrics "github.com/zhavkk/order-service/pkg/metrics/prometheus"
+)
+
+var ErrIdempotencyKeyReused = apperrors.Invalid("idempotency key was already used for a different request", nil)
+
+// SubmitOrders принимает заказы по HTTP тем же путем, что и пачку сообщений Kafka: разбор,
+// проверка DTO и бизнес-правил, затем ProcessOrders. Результат возвращается по каждому заказу.
+// Повтор запроса с тем же idempotencyKey получает сохраненный ответ, не обрабатывая заказы
+// заново; тот же ключ с другими заказами — ErrIdempotencyKeyReused.
+func (s *OrderService) SubmitOrders(ctx context.Context, idempotencyKey string, messages [][]byte) (*dto.SubmitOrdersResponse, error) {
+	const op = "OrderService.SubmitOrders"
+
+	var requestHash string
+	if idempotencyKey != "" && s.idempotency != nil {
+		requestHash = hashMessages(messages)
+		record, err := s.idempotency.Get(ctx, idempotencyKey)
+		if err != nil {
+			logger.Log.Error(op, "Failed to get idempotency key", err)
+			return nil, err
+		}
+		if record != nil {
+			if record.RequestHash != requestHash {
+				return nil, ErrIdempotencyKeyReused
+			}
+			var resp dto.SubmitOrdersResponse
+			if err := json.Unmarshal(record.Response, &resp); err != nil {
+				return nil, fmt.Errorf("failed to decode stored response: %w", err)
+			}
+			resp.Replayed = true
+			logger.Log.Info(op, "Replaying stored response, idempotency key: ", idempotencyKey)
+			return &resp, nil
+		}
+	}
+
+	resp := s.submitOrders(ctx, messages)
+	logger.Log.Info(op, "Orders submitted, accepted: ", resp.Accepted, "rejected", resp.Rejected, "failed", resp.Failed)
+
+	// Ответ с временными сбоями не сохраняется: повтор с тем же ключом должен дообработать заказы.
+	if requestHash != "" && resp.Failed == 0 {
+		s.saveSubmitResponse(ctx, idempotencyKey, requestHash, resp)
+	}
+	return resp, nil
+}
+
+func (s *OrderService) submitOrders(ctx context.Context, messages [][]byte) *dto.SubmitOrdersResponse {
+	results := make([]dto.SubmitOrderResult, len(messages))
+	reqs := make([]*dto.ProcessOrderRequest, 0, len(messages))
+	positions := make([]int, 0, len(messages))
+	for i, message := range messages {
+		results[i] = dto.SubmitOrderResult{Index: i, OrderUID: peekOrderUID(message)}
+		in, err := s.decodeMessage(message)
+		if err != nil {
+			setSubmitResult(&results[i], err)
+			continue
+		}
+		reqs = append(reqs, &dto.ProcessOrderRequest{Order: *in})
+		positions = append(positions, i)
+	}
+
+	for i, err := range s.ProcessOrders(ctx, reqs, nil) {
+		setSubmitResult(&results[positions[i]], err)
+	}
+
+	resp := &dto.SubmitOrdersResponse{Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case dto.SubmitStatusAccepted:
+			resp.Accepted++
+		case dto.SubmitStatusRejected:
+			resp.Rejected++
+		default:
+			resp.Failed++
+		}
+		prometheusmetrics.OrdersSubmittedTotal.WithLabelValues(result.Status).Inc()
+	}
+	return resp
+}
+
+// setSubmitResult заполняет результат по ошибке обработки. Текст внутренних ошибок
+// клиенту не отдается.
+func setSubmitResult(result *dto.SubmitOrderResult, err error) {
+	switch {
+	case err == nil:
+		result.Status = dto.SubmitStatusAccepted
+	case apperrors.IsInvalid(err), apperrors.IsConflict(err):
+		result.Status = dto.SubmitStatusRejected
+		result.Kind = apperrors.Kind(err)
+		result.Error = err.Error()
+	default:
+		result.Status = dto.SubmitStatusFailed
+		result.Kind = apperrors.Kind(err)
+		result.Error = "temporary failure, retry later"
+	}
+}
+
+func (s *OrderService) saveSubmitResponse(ctx context.Context, key, requestHash string, resp *dto.SubmitOrdersResponse) {
+	const op = "OrderService.saveSubmitResponse"
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		logger.Log.Error(op, "Failed to encode response", err)
+		return
+	}
+	// Заказы уже сохранены: без записи ключа повтор запроса пройдет как повтор сообщений.
+	record := &models.IdempotencyRecord{Key: key, RequestHash: requestHash, Response: data}
+	if err := s.idempotency.Save(ctx, record); err != nil {
+		logger.Log.Error(op, "Failed to save idempotency key", err)
+	}
+}
+
+// peekOrderUID достает order_uid для результата, даже если заказ не пройдет проверку.
+func peekOrderUID(message []byte) string {
+	var order struct {
+		OrderUID string `json:"order_uid"`
+	}
+	_ = json.Unmarshal(message, &order)
+	return order.OrderUID
+}
+
+// hashMessages считает хеш заказов запроса с длиной каждого, чтобы разбиение на заказы
+// влияло на результат.
+func hashMessages(messages [][]byte) string {
+	h := sha256.New()
+	var size [8]byte
+	for _, message := range messages {
+		binary.BigEndian.PutUint64(size[:], uint64(len(message)))
+		h.Write(size[:])
+		h.Write(message)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+)
+
+// expectBatchCreated ожидает запись пачки из n новых заказов.
+func (d *testDeps) expectBatchCreated(n int) {
+	d.orderRepo.EXPECT().CreateOrders(gomock.Any(), gomock.Len(n)).Return(nil)
+	d.itemsRepo.EXPECT().AddItemsBatch(gomock.Any(), gomock.Any()).Return(nil)
+	d.deliveryRepo.EXPECT().CreateDeliveries(gomock.Any(), gomock.Len(n)).Return(nil)
+	d.paymentRepo.EXPECT().CreatePayments(gomock.Any(), gomock.Len(n)).Return(nil)
+	d.outboxRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(n)).Return(nil)
+	d.historyRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(n)).Return(nil)
+	d.cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), testCacheTTL).Return(nil).Times(n)
+}
+
+func orderMessage(t *testing.T, order models.Order) []byte {
+	data, err := json.Marshal(orderRequest(t, order))
+	require.NoError(t, err)
+	return data
+}
+
+func TestOrderService_SubmitOrders(t *testing.T) {
+	deps := newTestDeps(t)
+	ctx := context.Background()
+
+	valid := generateRandomOrder()
+	invalid := generateRandomOrder()
+	invalid.TrackNumber = ""
+	messages := [][]byte{orderMessage(t, valid), []byte(`{"order_uid": "broken"`), orderMessage(t, invalid)}
+
+	deps.idempotencyRepo.EXPECT().GetIdempotencyRecord(gomock.Any(), "key-1", gomock.Any()).
+		Return(nil, postgres.ErrIdempotencyKeyNotFound)
+	deps.expectBatchCreated(1)
+	var saved *models.IdempotencyRecord
+	deps.idempotencyRepo.EXPECT().SaveIdempotencyRecord(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, record *models.IdempotencyRecord, _ time.Time) error {
+			saved = record
+			return nil
+		})
+
+	resp, err := deps.service.SubmitOrders(ctx, "key-1", messages)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, resp.Accepted)
+	assert.Equal(t, 2, resp.Rejected)
+	assert.False(t, resp.Replayed)
+	require.Len(t, resp.Results, 3)
+	assert.Equal(t, dto.SubmitOrderResult{Index: 0, OrderUID: valid.OrderUID, Status: dto.SubmitStatusAccepted}, resp.Results[0])
+	assert.Equal(t, dto.SubmitStatusRejected, resp.Results[1].Status)
+	assert.Equal(t, "invalid", resp.Results[1].Kind)
+	assert.Equal(t, invalid.OrderUID, resp.Results[2].OrderUID)
+	assert.Contains(t, resp.Results[2].Error, "TrackNumber")
+
+	require.NotNil(t, saved)
+	assert.Equal(t, "key-1", saved.Key)
+
+	t.Run("replay", func(t *testing.T) {
+		deps.idempotencyRepo.EXPECT().GetIdempotencyRecord(gomock.Any(), "key-1", gomock.Any()).Return(saved, nil)
+
+		replayed, err := deps.service.SubmitOrders(ctx, "key-1", messages)
+		require.NoError(t, err)
+		assert.True(t, replayed.Replayed)
+		replayed.Replayed = false
+		assert.Equal(t, resp, replayed)
+	})
+
+	t.Run("key reused for other orders", func(t *testing.T) {
+		deps.idempotencyRepo.EXPECT().GetIdempotencyRecord(gomock.Any(), "key-1", gomock.Any()).Return(saved, nil)
+
+		_, err := deps.service.SubmitOrders(ctx, "key-1", messages[:1])
+		assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+	})
+}
+
+func TestOrderService_SubmitOrders_FailedResponseIsNotStored(t *testing.T) {
+	deps := newTestDeps(t)
+	order := generateRandomOrder()
+	dbDown := apperrors.Transient("connection refused", nil)
+
+	deps.idempotencyRepo.EXPECT().GetIdempotencyRecord(gomock.Any(), "key-2", gomock.Any()).
+		Return(nil, postgres.ErrIdempotencyKeyNotFound)
+	deps.orderRepo.EXPECT().CreateOrders(gomock.Any(), gomock.Any()).Return(dbDown)
+	deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), order.OrderUID).Return(nil, dbDown)
+
+	resp, err := deps.service.SubmitOrders(context.Background(), "key-2", [][]byte{orderMessage(t, order)})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, resp.Failed)
+	assert.Equal(t, dto.SubmitOrderResult{
+		Index: 0, OrderUID: order.OrderUID, Status: dto.SubmitStatusFailed,
+		Kind: "transient", Error: "temporary failure, retry later",
+	}, resp.Results[0])
+}
+
+func TestHashMessages(t *testing.T) {
+	assert.Equal(t, hashMessages([][]byte{[]byte("ab"), []byte("c")}), hashMessages([][]byte{[]byte("ab"), []byte("c")}))
+	assert.NotEqual(t, hashMessages([][]byte{[]byte("ab"), []byte("c")}), hashMessages([][]byte{[]byte("a"), []byte("bc")}))
+}
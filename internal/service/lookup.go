@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+	prometheusmetrics "github.com/zhavkk/order-service/pkg/metrics/prometheus"
+)
+
+// LookupOptions настраивают чтение заказа по ID. Нулевые значения выключают соответствующую защиту.
+type LookupOptions struct {
+	// NegativeTTL — сколько помнить, что заказа нет, чтобы перебор случайных ID не доходил до базы.
+	NegativeTTL time.Duration
+	// EarlyRefreshBeta — коэффициент вероятностного раннего обновления (XFetch): чем больше,
+	// тем раньше до истечения TTL запись перечитывается из базы. 1 — стандартное значение.
+	EarlyRefreshBeta float64
+}
+
+// cachedOrder — запись кэша заказа. ExpiresAt и Delta (время загрузки из базы) нужны
+// для раннего обновления.
+type cachedOrder struct {
+	Order     *models.Order `json:"order"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	Delta     time.Duration `json:"delta"`
+}
+
+func orderCacheKey(orderUID string) string {
+	return "order:" + orderUID
+}
+
+func missingOrderCacheKey(orderUID string) string {
+	return "order-missing:" + orderUID
+}
+
+func (s *OrderService) SetLookupOptions(opts LookupOptions) {
+	s.lookup = opts
+}
+
+// cacheOrder кладет заказ в кэш на cacheTTL. delta — сколько заняла загрузка заказа.
+func (s *OrderService) cacheOrder(ctx context.Context, order *models.Order, delta time.Duration) error {
+	entry := cachedOrder{
+		Order:     order,
+		ExpiresAt: time.Now().Add(s.cacheTTL),
+		Delta:     delta,
+	}
+	return s.cache.Set(ctx, orderCacheKey(order.OrderUID), entry, s.cacheTTL)
+}
+
+// forgetMissingOrder снимает отметку об отсутствии заказа после его записи: иначе после
+// сброса кэша заказ считался бы отсутствующим до истечения NegativeTTL.
+func (s *OrderService) forgetMissingOrder(ctx context.Context, orderUID string) {
+	const op = "OrderService.forgetMissingOrder"
+
+	if s.lookup.NegativeTTL <= 0 {
+		return
+	}
+	if err := s.cache.Delete(ctx, missingOrderCacheKey(orderUID)); err != nil {
+		logger.Log.Warn(op, "Failed to delete missing order mark", err)
+	}
+}
+
+// lookupOrder читает заказ из кэша, а при промахе — из базы. Одновременные промахи по одному
+// ID объединяются в один запрос к базе.
+func (s *OrderService) lookupOrder(ctx context.Context, orderUID string) (*models.Order, error) {
+	const op = "OrderService.lookupOrder"
+
+	var cached cachedOrder
+	if err := s.cache.Get(ctx, orderCacheKey(orderUID), &cached); err == nil && cached.Order != nil {
+		prometheusmetrics.OrderLookupsTotal.WithLabelValues("cache_hit").Inc()
+		if s.shouldRefreshEarly(cached) {
+			prometheusmetrics.OrderLookupsTotal.WithLabelValues("early_refresh").Inc()
+			s.loads.DoChan(orderUID, func() (any, error) {
+				return s.loadOrder(context.WithoutCancel(ctx), orderUID)
+			})
+		}
+		return cached.Order, nil
+	}
+
+	if s.lookup.NegativeTTL > 0 {
+		var missing bool
+		if err := s.cache.Get(ctx, missingOrderCacheKey(orderUID), &missing); err == nil && missing {
+			prometheusmetrics.OrderLookupsTotal.WithLabelValues("negative_hit").Inc()
+			return nil, postgres.ErrOrderNotFound
+		}
+	}
+
+	logger.Log.Warn(op, "Cache miss order_id: ", orderUID)
+
+	// Загрузка не привязана к отмене первого запроса: ее результат ждут и другие запросы.
+	result := s.loads.DoChan(orderUID, func() (any, error) {
+		return s.loadOrder(context.WithoutCancel(ctx), orderUID)
+	})
+	select {
+	case res := <-result:
+		if res.Shared {
+			prometheusmetrics.OrderLookupsTotal.WithLabelValues("coalesced").Inc()
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*models.Order), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *OrderService) loadOrder(ctx context.Context, orderUID string) (*models.Order, error) {
+	const op = "OrderService.loadOrder"
+
+	start := time.Now()
+	order, err := s.orderRepo.GetOrderByID(ctx, orderUID)
+	if errors.Is(err, postgres.ErrOrderNotFound) {
+		prometheusmetrics.OrderLookupsTotal.WithLabelValues("not_found").Inc()
+		if s.lookup.NegativeTTL > 0 {
+			if err := s.cache.Set(ctx, missingOrderCacheKey(orderUID), true, s.lookup.NegativeTTL); err != nil {
+				logger.Log.Warn(op, "Failed to cache missing order", err)
+			}
+		}
+		return nil, err
+	}
+	if err != nil {
+		logger.Log.Error(op, "Failed to get order from repository", err)
+		return nil, err
+	}
+	prometheusmetrics.OrderLookupsTotal.WithLabelValues("loaded").Inc()
+
+	if err := s.cacheOrder(ctx, order, time.Since(start)); err != nil {
+		logger.Log.Warn(op, "Failed to cache order", err)
+	}
+	return order, nil
+}
+
+// shouldRefreshEarly реализует XFetch: запись обновляется с вероятностью, растущей по мере
+// приближения к ExpiresAt, и тем раньше, чем дольше она загружалась.
+func (s *OrderService) shouldRefreshEarly(entry cachedOrder) bool {
+	if s.lookup.EarlyRefreshBeta <= 0 || entry.Delta <= 0 {
+		return false
+	}
+	gap := float64(entry.Delta) * s.lookup.EarlyRefreshBeta * -math.Log(1-rand.Float64())
+	return !time.Now().Add(time.Duration(gap)).Before(entry.ExpiresAt)
+}
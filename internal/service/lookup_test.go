@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+)
+
+func TestOrderService_GetByID_NegativeCache(t *testing.T) {
+	deps := newTestDeps(t)
+	deps.useLRUCache()
+	deps.service.SetLookupOptions(LookupOptions{NegativeTTL: time.Minute})
+	req := &dto.GetOrderByIDRequest{OrderID: "missing"}
+
+	deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), "missing").Return(nil, postgres.ErrOrderNotFound).Times(1)
+
+	for range 3 {
+		_, err := deps.service.GetByID(context.Background(), req)
+		assert.ErrorIs(t, err, postgres.ErrOrderNotFound)
+	}
+}
+
+func TestOrderService_GetByID_NegativeCacheClearedOnWrite(t *testing.T) {
+	deps := newTestDeps(t)
+	orderCache := deps.useLRUCache()
+	deps.service.SetLookupOptions(LookupOptions{NegativeTTL: time.Minute})
+	ctx := context.Background()
+	order := generateRandomOrder()
+	req := &dto.GetOrderByIDRequest{OrderID: order.OrderUID}
+
+	deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), order.OrderUID).Return(nil, postgres.ErrOrderNotFound)
+	_, err := deps.service.GetByID(ctx, req)
+	require.ErrorIs(t, err, postgres.ErrOrderNotFound)
+
+	deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), order.OrderUID).Return(nil, postgres.ErrOrderNotFound)
+	deps.orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil)
+	deps.itemsRepo.EXPECT().AddItems(gomock.Any(), order.OrderUID, gomock.Any()).Return(nil)
+	deps.deliveryRepo.EXPECT().CreateDelivery(gomock.Any(), gomock.Any()).Return(nil)
+	deps.paymentRepo.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).Return(nil)
+	deps.outboxRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).Return(nil)
+	deps.historyRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).Return(nil).Times(2)
+	require.NoError(t, deps.service.ProcessOrder(ctx, &dto.ProcessOrderRequest{Order: orderRequest(t, order)}))
+
+	// После сброса кэша заказ читается из базы, а не считается отсутствующим.
+	require.NoError(t, deps.service.invalidateCachedOrder(ctx, order.OrderUID))
+	assert.Zero(t, orderCache.Len())
+
+	deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), order.OrderUID).Return(&order, nil)
+	resp, err := deps.service.GetByID(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, order.OrderUID, resp.Order.OrderUID)
+}
+
+func TestOrderService_GetByID_CoalescesConcurrentMisses(t *testing.T) {
+	deps := newTestDeps(t)
+	deps.useLRUCache()
+	order := generateRandomOrder()
+
+	release := make(chan struct{})
+	deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), order.OrderUID).DoAndReturn(
+		func(context.Context, string) (*models.Order, error) {
+			<-release
+			return &order, nil
+		},
+	).Times(1)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := deps.service.GetByID(context.Background(), &dto.GetOrderByIDRequest{OrderID: order.OrderUID})
+			if err == nil && resp.Order.OrderUID != order.OrderUID {
+				t.Errorf("unexpected order %s", resp.Order.OrderUID)
+			}
+			errs <- err
+		}()
+	}
+	// Опоздавшие вызовы попадут в кэш, поэтому задержка влияет только на то, сколько
+	// вызовов объединится, но не на число запросов к базе.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+}
+
+func TestOrderService_GetByID_EarlyRefresh(t *testing.T) {
+	deps := newTestDeps(t)
+	orderCache := deps.useLRUCache()
+	deps.service.SetLookupOptions(LookupOptions{EarlyRefreshBeta: 1000})
+	order := generateRandomOrder()
+	order.Version = 1
+	fresh := order
+	fresh.Version = 2
+
+	// Запись истекает через секунду, а загружалась секунду: с beta=1000 обновление неизбежно.
+	require.NoError(t, orderCache.Set(context.Background(), orderCacheKey(order.OrderUID), cachedOrder{
+		Order:     &order,
+		ExpiresAt: time.Now().Add(time.Second),
+		Delta:     time.Second,
+	}, time.Minute))
+	deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), order.OrderUID).Return(&fresh, nil).Times(1)
+
+	resp, err := deps.service.GetByID(context.Background(), &dto.GetOrderByIDRequest{OrderID: order.OrderUID})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Order.Version)
+	assert.Eventually(t, func() bool {
+		var cached cachedOrder
+		err := orderCache.Get(context.Background(), orderCacheKey(order.OrderUID), &cached)
+		return err == nil && cached.Order.Version == 2
+	}, time.Second, 10*time.Millisecond)
+}
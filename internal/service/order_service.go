@@ -3,37 +3,73 @@ package service
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-
 	"time"
 
 	"github.com/go-playground/validator"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/audit"
 	"github.com/zhavkk/order-service/internal/dto"
 	"github.com/zhavkk/order-service/internal/logger"
 	"github.com/zhavkk/order-service/internal/models"
 	"github.com/zhavkk/order-service/pkg/cache"
 	prometheusmetrics "github.com/zhavkk/order-service/pkg/metrics/prometheus"
 	"github.com/zhavkk/order-service/pkg/pgstorage"
+	"golang.org/x/sync/singleflight"
 )
 
 type OrderRepository interface {
 	GetOrderByID(ctx context.Context, orderID string) (*models.Order, error)
+	GetOrdersByIDs(ctx context.Context, orderIDs []string) ([]*models.Order, error)
 	GetRecentOrders(ctx context.Context, limit int) ([]*models.Order, error)
+	GetRecentOrderUIDs(ctx context.Context, limit int) ([]string, error)
+	GetOrderUIDsCreatedSince(ctx context.Context, since time.Time, limit int) ([]string, error)
+	ListOrders(ctx context.Context, filter models.OrderFilter) ([]*models.Order, error)
 	CreateOrder(ctx context.Context, order *models.Order) error
+	CreateOrders(ctx context.Context, orders []*models.Order) error
+	GetOrderVersion(ctx context.Context, orderUID string) (*models.OrderVersion, error)
+	SetContentHash(ctx context.Context, orderUID, contentHash string) error
+	UpdateOrder(ctx context.Context, order *models.Order, expectedVersion int) error
+	GetStatusForUpdate(ctx context.Context, orderUID string) (models.OrderStatus, error)
+	UpdateStatus(ctx context.Context, change *models.StatusChange) error
 }
 
 type DeliveryRepository interface {
 	GetDeliveryByOrderID(ctx context.Context, orderID string) (*models.Delivery, error)
 	CreateDelivery(ctx context.Context, delivery *models.Delivery) error
+	CreateDeliveries(ctx context.Context, deliveries []*models.Delivery) error
 }
 
 type PaymentRepository interface {
 	CreatePayment(ctx context.Context, payment *models.Payment) error
+	ReplacePayment(ctx context.Context, payment *models.Payment) error
+	CreatePayments(ctx context.Context, payments []*models.Payment) error
 }
 
 type ItemsRepository interface {
 	GetItemsByOrderID(ctx context.Context, orderID string) ([]*models.Item, error)
 	AddItems(ctx context.Context, orderID string, items []*models.Item) error
+	AddItemsBatch(ctx context.Context, items []*models.Item) error
+}
+
+type OutboxRepository interface {
+	AddEvents(ctx context.Context, events []*models.OutboxEvent) error
+}
+
+type HistoryRepository interface {
+	AddEvents(ctx context.Context, events []*models.OrderHistoryEvent) error
+	GetHistory(ctx context.Context, orderUID string) ([]*models.OrderHistoryEvent, error)
+}
+
+type AccessRepository interface {
+	AddAccessCounts(ctx context.Context, bucket time.Time, counts map[string]int64) error
+	GetMostAccessedOrderUIDs(ctx context.Context, since time.Time, limit int) ([]string, error)
+	DeleteAccessCountsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type IdempotencyRepository interface {
+	GetIdempotencyRecord(ctx context.Context, key string, since time.Time) (*models.IdempotencyRecord, error)
+	SaveIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord, expiredBefore time.Time) error
+	DeleteIdempotencyRecordsBefore(ctx context.Context, before time.Time) (int64, error)
 }
 
 type OrderService struct {
@@ -41,9 +77,21 @@ type OrderService struct {
 	deliveryRepo DeliveryRepository
 	paymentRepo  PaymentRepository
 	itemsRepo    ItemsRepository
+	outboxRepo   OutboxRepository
+	historyRepo  HistoryRepository
 	txManager    pgstorage.TxManagerInterface
 	cache        cache.Cache
 	cacheTTL     time.Duration
+
+	conflictPolicy ConflictPolicy
+	orderValidator *OrderValidator
+	lookup         LookupOptions
+	loads          singleflight.Group
+	notifyEvents   func()
+	access         *AccessTracker
+	idempotency    *IdempotencyStore
+	warmUpOpts     WarmUpOptions
+	warmUp         warmUpState
 }
 
 func NewOrderService(
@@ -51,6 +99,8 @@ func NewOrderService(
 	deliveryRepo DeliveryRepository,
 	paymentRepo PaymentRepository,
 	itemsRepo ItemsRepository,
+	outboxRepo OutboxRepository,
+	historyRepo HistoryRepository,
 	txManager pgstorage.TxManagerInterface,
 	cache cache.Cache,
 	cacheTTL time.Duration,
@@ -60,79 +110,275 @@ func NewOrderService(
 		deliveryRepo: deliveryRepo,
 		paymentRepo:  paymentRepo,
 		itemsRepo:    itemsRepo,
+		outboxRepo:   outboxRepo,
+		historyRepo:  historyRepo,
 		txManager:    txManager,
 		cache:        cache,
 		cacheTTL:     cacheTTL,
+
+		conflictPolicy: defaultConflictPolicy,
+		orderValidator: NewOrderValidator(defaultValidationMode, DefaultValidationRules()...),
+		warmUpOpts:     defaultWarmUpOptions,
 	}
 }
 func (s *OrderService) ProcessMessage(ctx context.Context, message []byte) error {
 	const op = "OrderService.ProcessMessage"
 	logger.Log.Info(op, "Processing message from Kafka", nil)
 
+	in, err := s.decodeMessage(message)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ProcessOrder(ctx, &dto.ProcessOrderRequest{Order: *in}); err != nil {
+		logger.Log.Error(op, "Failed to process order", err)
+		return err
+	}
+	prometheusmetrics.MessageProcessedTotal.WithLabelValues("success").Inc()
+
+	return nil
+}
+
+// ProcessMessages обрабатывает пачку сообщений из Kafka. origins — инициатор каждого
+// сообщения для истории заказа. Возвращает ошибку для каждого сообщения в том же порядке
+// (nil — сообщение сохранено).
+func (s *OrderService) ProcessMessages(ctx context.Context, messages [][]byte, origins []audit.Origin) []error {
+	const op = "OrderService.ProcessMessages"
+	logger.Log.Info(op, "Processing batch from Kafka, size: ", len(messages))
+
+	errs := make([]error, len(messages))
+	reqs := make([]*dto.ProcessOrderRequest, 0, len(messages))
+	reqOrigins := make([]audit.Origin, 0, len(messages))
+	positions := make([]int, 0, len(messages))
+	for i, message := range messages {
+		in, err := s.decodeMessage(message)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		reqs = append(reqs, &dto.ProcessOrderRequest{Order: *in})
+		reqOrigins = append(reqOrigins, origins[i])
+		positions = append(positions, i)
+	}
+
+	for i, err := range s.ProcessOrders(ctx, reqs, reqOrigins) {
+		errs[positions[i]] = err
+		if err == nil {
+			prometheusmetrics.MessageProcessedTotal.WithLabelValues("success").Inc()
+		}
+	}
+
+	return errs
+}
+
+func (s *OrderService) decodeMessage(message []byte) (*dto.OrderRequest, error) {
+	const op = "OrderService.decodeMessage"
+
 	var in dto.OrderRequest
 
 	if err := json.Unmarshal(message, &in); err != nil {
 		logger.Log.Error(op, "Failed to unmarshal order", err)
-		return nil
+		return nil, apperrors.Invalid("malformed order payload", err)
 	}
 
 	if err := validator.New().Struct(in); err != nil {
 		logger.Log.Warn(op, "Invalid order DTO ", err)
-		return nil
+		return nil, apperrors.Invalid("order validation failed", err)
 	}
 
-	if err := s.ProcessOrder(ctx, &dto.ProcessOrderRequest{Order: in}); err != nil {
-		logger.Log.Error(op, "Failed to process order", err)
-		return err
+	if err := s.orderValidator.Validate(&in); err != nil {
+		logger.Log.Warn(op, "Order violates business rules ", err)
+		return nil, err
 	}
-	prometheusmetrics.MessageProcessedTotal.WithLabelValues("success").Inc()
 
-	return nil
+	return &in, nil
 }
 
+// ProcessOrder сохраняет заказ. Повтор того же содержимого — no-op, измененный заказ
+// обрабатывается по политике конфликтов (см. ConflictPolicy).
 func (s *OrderService) ProcessOrder(ctx context.Context, req *dto.ProcessOrderRequest) error {
 	const op = "OrderService.ProcessOrder"
 	logger.Log.Info(op, "Processing order with ID:", req.Order.OrderUID)
 
 	modelOrder := s.dtoToModel(req.Order)
+	modelOrder.ContentHash = contentHash(req.Order)
 
-	return s.txManager.RunSerializable(ctx, func(ctx context.Context) error {
-		if err := s.orderRepo.CreateOrder(ctx, modelOrder); err != nil {
-			logger.Log.Error(op, "Failed to create order", err)
+	var result string
+	err := s.txManager.RunSerializableWithRetry(ctx, func(ctx context.Context) error {
+		var err error
+		if result, err = s.upsertOrder(ctx, modelOrder); err != nil {
 			return err
 		}
+		// Кэш и метрики — только после коммита: откат или перезапуск транзакции их не затронет.
+		committed := result
+		pgstorage.AfterCommit(ctx, func(ctx context.Context) {
+			s.orderCommitted(ctx, modelOrder, committed)
+		})
+		return nil
+	})
+	if err != nil {
+		if result == upsertRejected {
+			prometheusmetrics.OrderUpsertsTotal.WithLabelValues(upsertRejected).Inc()
+			// Транзакция откатилась, поэтому отказ пишется в историю уже вне ее.
+			event := newHistoryEvent(ctx, modelOrder.OrderUID, EventOrderRejected, nil)
+			if herr := s.addHistory(ctx, event); herr != nil {
+				logger.Log.Warn(op, "Failed to record rejected order", herr)
+			}
+		}
+		return err
+	}
 
-		items := make([]*models.Item, len(modelOrder.Items))
-		for i := range modelOrder.Items {
-			items[i] = &modelOrder.Items[i]
+	return nil
+}
+
+func (s *OrderService) orderCommitted(ctx context.Context, order *models.Order, result string) {
+	const op = "OrderService.orderCommitted"
+
+	prometheusmetrics.OrderUpsertsTotal.WithLabelValues(result).Inc()
+	switch result {
+	case upsertReplay:
+		logger.Log.Info(op, "Order replay ignored, order_id:", order.OrderUID)
+	case upsertCreated:
+		logger.Log.Info(op, "Order processed successfully, order_id:", order.OrderUID, "result", result)
+		prometheusmetrics.OrdersCreatedTotal.Inc()
+		s.forgetMissingOrder(ctx, order.OrderUID)
+		// Кэш не должен мешать записи: заказ попадет в кэш при первом чтении.
+		if err := s.cacheOrder(ctx, order, 0); err != nil {
+			logger.Log.Warn(op, "Failed to cache order", err)
 		}
-		if err := s.itemsRepo.AddItems(ctx, modelOrder.OrderUID, items); err != nil {
-			logger.Log.Error(op, "Failed to add items to order", err)
-			return err
+	case upsertUpdated:
+		logger.Log.Info(op, "Order processed successfully, order_id:", order.OrderUID, "result", result)
+		// Статус хранится только в базе: обновленный заказ перечитается при следующем запросе.
+		if err := s.invalidateCachedOrder(ctx, order.OrderUID); err != nil {
+			logger.Log.Error(op, "Failed to invalidate cached order", err)
 		}
+	}
+}
 
-		if err := s.deliveryRepo.CreateDelivery(ctx, &modelOrder.Delivery); err != nil {
-			logger.Log.Error(op, "Failed to create delivery", err)
-			return err
+// ProcessOrders сохраняет пачку новых заказов в одной транзакции (pgx batch + COPY).
+// Если пачка не записалась (например, один из заказов уже сохранен), каждый заказ
+// обрабатывается отдельно через ProcessOrder. Ошибки возвращаются по заказам в том же порядке.
+// origins задает инициатора каждого заказа; при nil он берется из ctx.
+func (s *OrderService) ProcessOrders(ctx context.Context, reqs []*dto.ProcessOrderRequest, origins []audit.Origin) []error {
+	const op = "OrderService.ProcessOrders"
+
+	errs := make([]error, len(reqs))
+	if len(reqs) == 0 {
+		return errs
+	}
+
+	orders := make([]*models.Order, len(reqs))
+	for i, req := range reqs {
+		orders[i] = s.dtoToModel(req.Order)
+		orders[i].ContentHash = contentHash(req.Order)
+	}
+
+	if !hasDuplicateOrders(orders) {
+		err := s.txManager.RunSerializableWithRetry(ctx, func(ctx context.Context) error {
+			if err := s.persistOrders(ctx, orders, origins); err != nil {
+				return err
+			}
+			pgstorage.AfterCommit(ctx, func(ctx context.Context) {
+				s.ordersCommitted(ctx, orders)
+			})
+			return nil
+		})
+		if err == nil {
+			logger.Log.Info(op, "Batch processed successfully, size: ", len(orders))
+			return errs
 		}
+		logger.Log.Warn(op, "Batch failed, falling back to per-order processing", err, "size", len(orders))
+	}
 
-		if err := s.paymentRepo.CreatePayment(ctx, &modelOrder.Payment); err != nil {
-			logger.Log.Error(op, "Failed to create payment", err)
-			return err
+	prometheusmetrics.OrderBatchFallbacksTotal.Inc()
+	for i, req := range reqs {
+		errs[i] = s.ProcessOrder(withOrderOrigin(ctx, origins, i), req)
+	}
+
+	return errs
+}
+
+func (s *OrderService) persistOrders(ctx context.Context, orders []*models.Order, origins []audit.Origin) error {
+	const op = "OrderService.persistOrders"
+
+	var (
+		items      []*models.Item
+		deliveries = make([]*models.Delivery, len(orders))
+		payments   = make([]*models.Payment, len(orders))
+	)
+	for i, order := range orders {
+		for j := range order.Items {
+			items = append(items, &order.Items[j])
 		}
+		deliveries[i] = &order.Delivery
+		payments[i] = &order.Payment
+	}
+
+	if err := s.orderRepo.CreateOrders(ctx, orders); err != nil {
+		logger.Log.Error(op, "Failed to create orders", err)
+		return err
+	}
+	for _, order := range orders {
+		order.Status = models.OrderStatusCreated
+	}
 
-		logger.Log.Info(op, "Order processed successfully, order_id:", modelOrder.OrderUID)
+	if err := s.itemsRepo.AddItemsBatch(ctx, items); err != nil {
+		logger.Log.Error(op, "Failed to add items", err)
+		return err
+	}
+
+	if err := s.deliveryRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		logger.Log.Error(op, "Failed to create deliveries", err)
+		return err
+	}
+
+	if err := s.paymentRepo.CreatePayments(ctx, payments); err != nil {
+		logger.Log.Error(op, "Failed to create payments", err)
+		return err
+	}
 
-		cacheKey := fmt.Sprintf("order:%s", modelOrder.OrderUID)
-		if err := s.cache.Set(ctx, cacheKey, modelOrder, s.cacheTTL); err != nil {
-			logger.Log.Error(op, "Failed to cache order", err)
+	events := make([]*models.OutboxEvent, len(orders))
+	for i, order := range orders {
+		event, err := s.newOrderEvent(order, EventOrderCreated)
+		if err != nil {
 			return err
 		}
+		events[i] = event
+	}
+	if err := s.addOutboxEvents(ctx, events); err != nil {
+		logger.Log.Error(op, "Failed to add order events to outbox", err)
+		return err
+	}
 
-		prometheusmetrics.OrdersCreatedTotal.Inc()
+	history := make([]*models.OrderHistoryEvent, len(orders))
+	for i, order := range orders {
+		history[i] = newHistoryEvent(withOrderOrigin(ctx, origins, i), order.OrderUID, EventOrderCreated, nil)
+	}
+	return s.addHistory(ctx, history...)
+}
 
-		return nil
-	})
+func (s *OrderService) ordersCommitted(ctx context.Context, orders []*models.Order) {
+	const op = "OrderService.ordersCommitted"
+
+	prometheusmetrics.OrdersCreatedTotal.Add(float64(len(orders)))
+	prometheusmetrics.OrderUpsertsTotal.WithLabelValues(upsertCreated).Add(float64(len(orders)))
+	for _, order := range orders {
+		s.forgetMissingOrder(ctx, order.OrderUID)
+		if err := s.cacheOrder(ctx, order, 0); err != nil {
+			logger.Log.Warn(op, "Failed to cache order", err)
+		}
+	}
+}
+
+func hasDuplicateOrders(orders []*models.Order) bool {
+	seen := make(map[string]struct{}, len(orders))
+	for _, order := range orders {
+		if _, ok := seen[order.OrderUID]; ok {
+			return true
+		}
+		seen[order.OrderUID] = struct{}{}
+	}
+	return false
 }
 
 func (r *OrderService) GetByID(
@@ -142,50 +388,67 @@ func (r *OrderService) GetByID(
 	const op = "OrderService.GetByID"
 	logger.Log.Info(op, "Fetching order by ID:", req.OrderID)
 
-	cacheKey := fmt.Sprintf("order:%s", req.OrderID)
-	var cached models.Order
-	if err := r.cache.Get(ctx, cacheKey, &cached); err == nil {
-		logger.Log.Info(op, "Order found in cache with order_id: ", req.OrderID)
-		return &dto.GetOrderByIDResponse{Order: r.modelToDTO(&cached)}, nil
-	}
-
-	logger.Log.Warn(op, "Cache miss order_id: ", req.OrderID)
-
-	order, err := r.orderRepo.GetOrderByID(ctx, req.OrderID)
+	order, err := r.lookupOrder(ctx, req.OrderID)
 	if err != nil {
-		logger.Log.Error(op, "Failed to get order from repository", err)
 		return nil, err
 	}
-
-	_ = r.cache.Set(ctx, cacheKey, order, r.cacheTTL)
+	if r.access != nil {
+		r.access.Record(order.OrderUID)
+	}
 
 	return &dto.GetOrderByIDResponse{Order: r.modelToDTO(order)}, nil
 }
 
-func (s *OrderService) WarmUpCache(ctx context.Context) error {
-	const op = "OrderService.WarmUpCache"
-	logger.Log.Info(op, "Warming up cache with 1000 recent orders", nil)
+// ListOrders возвращает страницу заказов по фильтрам. NextCursor пуст на последней странице.
+func (s *OrderService) ListOrders(ctx context.Context, req *dto.ListOrdersRequest) (*dto.ListOrdersResponse, error) {
+	const op = "OrderService.ListOrders"
+
+	filter := models.OrderFilter{
+		CustomerID:      req.CustomerID,
+		TrackNumber:     req.TrackNumber,
+		DeliveryService: req.DeliveryService,
+		Locale:          req.Locale,
+		Currency:        req.Currency,
+		Provider:        req.Provider,
+		Limit:           req.Limit,
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if req.CreatedFrom != nil {
+		filter.CreatedFrom = *req.CreatedFrom
+	}
+	if req.CreatedTo != nil {
+		filter.CreatedTo = *req.CreatedTo
+	}
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = cursor
+	}
 
-	orders, err := s.orderRepo.GetRecentOrders(ctx, 1000)
+	// Запрашиваем на один заказ больше, чтобы понять, есть ли следующая страница.
+	limit := filter.Limit
+	filter.Limit++
+	orders, err := s.orderRepo.ListOrders(ctx, filter)
 	if err != nil {
-		logger.Log.Error(op, "Failed to get recent orders", err)
-		return err
+		logger.Log.Error(op, "Failed to list orders", err)
+		return nil, err
 	}
 
-	cnt := 0
-
+	resp := &dto.ListOrdersResponse{Orders: make([]dto.OrderResponse, 0, limit)}
+	if len(orders) > limit {
+		orders = orders[:limit]
+		last := orders[limit-1]
+		resp.NextCursor = encodeCursor(&models.OrderCursor{DateCreated: last.DateCreated, OrderUID: last.OrderUID})
+	}
 	for _, order := range orders {
-		cacheKey := fmt.Sprintf("order:%s", order.OrderUID)
-		if err := s.cache.Set(ctx, cacheKey, order, s.cacheTTL); err != nil {
-			logger.Log.Error(op, "Failed to cache order with order_id: ", order.OrderUID, "error", err)
-			continue
-		}
-		cnt++
+		resp.Orders = append(resp.Orders, s.modelToDTO(order))
 	}
 
-	logger.Log.Info(op, "cache warme-up completed with total orders : ", len(orders), "cached orders : ", cnt)
-
-	return nil
+	return resp, nil
 }
 
 func (s *OrderService) dtoToModel(in dto.OrderRequest) *models.Order {
@@ -257,6 +520,9 @@ func (s *OrderService) modelToDTO(in *models.Order) dto.OrderResponse {
 		SmID:              in.SmID,
 		DateCreated:       in.DateCreated,
 		OofShard:          in.OofShard,
+		Status:            string(in.Status),
+		Version:           in.Version,
+		UpdatedAt:         in.UpdatedAt,
 		Delivery: dto.DeliveryDTO{
 			Name:    in.Delivery.Name,
 			Phone:   in.Delivery.Phone,
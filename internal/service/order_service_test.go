@@ -2,16 +2,21 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/audit"
 	"github.com/zhavkk/order-service/internal/dto"
 	"github.com/zhavkk/order-service/internal/logger"
 	"github.com/zhavkk/order-service/internal/models"
 	"github.com/zhavkk/order-service/internal/repository/mocks"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
 	"github.com/zhavkk/order-service/pkg/cache"
 )
 
@@ -23,6 +28,8 @@ func TestOrderService_ProcessOrder(t *testing.T) {
 	mockDeliveryRepo := mocks.NewMockDeliveryRepository(ctrl)
 	mockPaymentRepo := mocks.NewMockPaymentRepository(ctrl)
 	mockItemsRepo := mocks.NewMockItemsRepository(ctrl)
+	mockOutboxRepo := mocks.NewMockOutboxRepository(ctrl)
+	mockHistoryRepo := mocks.NewMockHistoryRepository(ctrl)
 	mockTxManager := mocks.NewMockTxManagerInterface(ctrl)
 	mockCache := mocks.NewMockCache(ctrl)
 	logger.Init("local")
@@ -31,6 +38,8 @@ func TestOrderService_ProcessOrder(t *testing.T) {
 		mockDeliveryRepo,
 		mockPaymentRepo,
 		mockItemsRepo,
+		mockOutboxRepo,
+		mockHistoryRepo,
 		mockTxManager,
 		mockCache,
 		5*time.Minute,
@@ -89,16 +98,32 @@ func TestOrderService_ProcessOrder(t *testing.T) {
 		},
 	}
 
-	mockTxManager.EXPECT().RunSerializable(gomock.Any(), gomock.Any()).DoAndReturn(
+	mockTxManager.EXPECT().RunSerializableWithRetry(gomock.Any(), gomock.Any()).DoAndReturn(
 		func(ctx context.Context, fn func(ctx context.Context) error) error {
 			return fn(ctx)
 		},
 	)
 
+	mockOrderRepo.EXPECT().GetOrderVersion(gomock.Any(), randomOrder.OrderUID).Return(nil, postgres.ErrOrderNotFound)
 	mockOrderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil)
 	mockItemsRepo.EXPECT().AddItems(gomock.Any(), randomOrder.OrderUID, gomock.Any()).Return(nil)
 	mockDeliveryRepo.EXPECT().CreateDelivery(gomock.Any(), gomock.Any()).Return(nil)
 	mockPaymentRepo.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).Return(nil)
+	mockHistoryRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).DoAndReturn(
+		func(_ context.Context, events []*models.OrderHistoryEvent) error {
+			assert.Equal(t, EventOrderCreated, events[0].EventType)
+			assert.Equal(t, audit.ActorSystem, events[0].Actor)
+			return nil
+		},
+	)
+	mockOutboxRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).DoAndReturn(
+		func(_ context.Context, events []*models.OutboxEvent) error {
+			assert.Equal(t, randomOrder.OrderUID, events[0].AggregateID)
+			assert.Equal(t, EventOrderCreated, events[0].EventType)
+			assert.Contains(t, string(events[0].Payload), randomOrder.OrderUID)
+			return nil
+		},
+	)
 	mockCache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 
 	err := orderService.ProcessOrder(context.Background(), orderRequest)
@@ -106,6 +131,26 @@ func TestOrderService_ProcessOrder(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestOrderService_ProcessMessage_Invalid(t *testing.T) {
+	logger.Init("local")
+	orderService := NewOrderService(nil, nil, nil, nil, nil, nil, nil, nil, 5*time.Minute)
+
+	tests := []struct {
+		name    string
+		message []byte
+	}{
+		{name: "Malformed JSON", message: []byte(`{"order_uid":`)},
+		{name: "Validation failure", message: []byte(`{"order_uid":"order-1"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := orderService.ProcessMessage(context.Background(), tt.message)
+			assert.ErrorIs(t, err, apperrors.ErrInvalid)
+		})
+	}
+}
+
 func TestOrderService_GetByID(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -123,6 +168,8 @@ func TestOrderService_GetByID(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
+		nil,
 		mockCache,
 		5*time.Minute,
 	)
@@ -216,37 +263,97 @@ func TestOrderService_GetByID(t *testing.T) {
 
 }
 
-func TestOrderService_WarmUpCache(t *testing.T) {
+func TestOrderService_ListOrders(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockCache := mocks.NewMockCache(ctrl)
 	logger.Init("local")
 	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
-	orderService := NewOrderService(
-		mockOrderRepo,
-		nil,
-		nil,
-		nil,
-		nil,
-		mockCache,
-		5*time.Minute,
-	)
+	orderService := NewOrderService(mockOrderRepo, nil, nil, nil, nil, nil, nil, nil, 5*time.Minute)
 
-	order1 := models.Order{OrderUID: "order-1"}
-	order2 := models.Order{OrderUID: "order-2"}
-	order3 := models.Order{OrderUID: "order-3"}
-	recentOrders := []*models.Order{&order1, &order2, &order3}
+	now := time.Now().UTC()
+	order1 := models.Order{OrderUID: "order-1", CustomerID: "customer", DateCreated: now}
+	order2 := models.Order{OrderUID: "order-2", CustomerID: "customer", DateCreated: now.Add(-time.Minute)}
+	order3 := models.Order{OrderUID: "order-3", CustomerID: "customer", DateCreated: now.Add(-2 * time.Minute)}
 
-	mockOrderRepo.EXPECT().GetRecentOrders(gomock.Any(), 1000).Return(recentOrders, nil)
-	mockCache.EXPECT().Set(gomock.Any(), "order:order-1", gomock.Any(), 5*time.Minute).Return(nil)
-	mockCache.EXPECT().Set(gomock.Any(), "order:order-2", gomock.Any(), 5*time.Minute).Return(nil)
-	mockCache.EXPECT().Set(gomock.Any(), "order:order-3", gomock.Any(), 5*time.Minute).Return(nil)
+	mockOrderRepo.EXPECT().ListOrders(gomock.Any(), models.OrderFilter{CustomerID: "customer", Currency: "RUB", Limit: 3}).
+		Return([]*models.Order{&order1, &order2, &order3}, nil)
+
+	resp, err := orderService.ListOrders(context.Background(), &dto.ListOrdersRequest{
+		CustomerID: "customer",
+		Currency:   "RUB",
+		Limit:      2,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Orders, 2)
+	assert.NotEmpty(t, resp.NextCursor)
 
-	err := orderService.WarmUpCache(context.Background())
+	mockOrderRepo.EXPECT().ListOrders(gomock.Any(), models.OrderFilter{
+		CustomerID: "customer",
+		Limit:      3,
+		After:      &models.OrderCursor{DateCreated: order2.DateCreated, OrderUID: "order-2"},
+	}).Return([]*models.Order{&order3}, nil)
 
+	resp, err = orderService.ListOrders(context.Background(), &dto.ListOrdersRequest{
+		CustomerID: "customer",
+		Limit:      2,
+		Cursor:     resp.NextCursor,
+	})
 	assert.NoError(t, err)
+	assert.Len(t, resp.Orders, 1)
+	assert.Equal(t, "order-3", resp.Orders[0].OrderUID)
+	assert.Empty(t, resp.NextCursor)
 }
+
+func TestOrderService_ListOrders_NullDateCreated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger.Init("local")
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	orderService := NewOrderService(mockOrderRepo, nil, nil, nil, nil, nil, nil, nil, 5*time.Minute)
+
+	// Заказы без date_created идут в конце выдачи; курсор на таком заказе хранит нулевую дату.
+	order1 := models.Order{OrderUID: "order-1", DateCreated: time.Now().UTC()}
+	order2 := models.Order{OrderUID: "order-3"}
+	order3 := models.Order{OrderUID: "order-2"}
+
+	mockOrderRepo.EXPECT().ListOrders(gomock.Any(), models.OrderFilter{Limit: 3}).
+		Return([]*models.Order{&order1, &order2, &order3}, nil)
+
+	resp, err := orderService.ListOrders(context.Background(), &dto.ListOrdersRequest{Limit: 2})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.NextCursor)
+
+	mockOrderRepo.EXPECT().ListOrders(gomock.Any(), models.OrderFilter{
+		Limit: 3,
+		After: &models.OrderCursor{OrderUID: "order-3"},
+	}).Return([]*models.Order{&order3}, nil)
+
+	resp, err = orderService.ListOrders(context.Background(), &dto.ListOrdersRequest{Limit: 2, Cursor: resp.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, resp.Orders, 1)
+	assert.Equal(t, "order-2", resp.Orders[0].OrderUID)
+}
+
+func TestOrderService_ListOrders_InvalidCursor(t *testing.T) {
+	logger.Init("local")
+	orderService := NewOrderService(nil, nil, nil, nil, nil, nil, nil, nil, 5*time.Minute)
+
+	_, err := orderService.ListOrders(context.Background(), &dto.ListOrdersRequest{Cursor: "not a cursor"})
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalid)
+}
+
+func orderRequest(t *testing.T, order models.Order) dto.OrderRequest {
+	data, err := json.Marshal(order)
+	require.NoError(t, err)
+
+	var in dto.OrderRequest
+	require.NoError(t, json.Unmarshal(data, &in))
+	return in
+}
+
 func generateRandomOrder() models.Order {
 	return models.Order{
 		OrderUID:    uuid.NewString(),
@@ -279,9 +386,9 @@ func generateRandomOrder() models.Order {
 				Price:       1000,
 				Rid:         uuid.NewString(),
 				Name:        "Test Item",
-				Sale:        0,
+				Sale:        10,
 				Size:        "M",
-				TotalPrice:  1000,
+				TotalPrice:  900,
 				NmId:        2389212,
 				Brand:       "Test Brand",
 				Status:      1,
@@ -297,3 +404,112 @@ func generateRandomOrder() models.Order {
 		OofShard:          "1",
 	}
 }
+
+func TestOrderService_ProcessOrders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockDeliveryRepo := mocks.NewMockDeliveryRepository(ctrl)
+	mockPaymentRepo := mocks.NewMockPaymentRepository(ctrl)
+	mockItemsRepo := mocks.NewMockItemsRepository(ctrl)
+	mockOutboxRepo := mocks.NewMockOutboxRepository(ctrl)
+	mockHistoryRepo := mocks.NewMockHistoryRepository(ctrl)
+	mockTxManager := mocks.NewMockTxManagerInterface(ctrl)
+	mockCache := mocks.NewMockCache(ctrl)
+	logger.Init("local")
+	orderService := NewOrderService(
+		mockOrderRepo,
+		mockDeliveryRepo,
+		mockPaymentRepo,
+		mockItemsRepo,
+		mockOutboxRepo,
+		mockHistoryRepo,
+		mockTxManager,
+		mockCache,
+		5*time.Minute,
+	)
+
+	order1, order2 := generateRandomOrder(), generateRandomOrder()
+	reqs := []*dto.ProcessOrderRequest{
+		{Order: orderRequest(t, order1)},
+		{Order: orderRequest(t, order2)},
+	}
+
+	mockTxManager.EXPECT().RunSerializableWithRetry(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		},
+	)
+	mockOrderRepo.EXPECT().CreateOrders(gomock.Any(), gomock.Len(2)).Return(nil)
+	mockItemsRepo.EXPECT().AddItemsBatch(gomock.Any(), gomock.Len(2)).Return(nil)
+	mockDeliveryRepo.EXPECT().CreateDeliveries(gomock.Any(), gomock.Len(2)).Return(nil)
+	mockPaymentRepo.EXPECT().CreatePayments(gomock.Any(), gomock.Len(2)).Return(nil)
+	mockOutboxRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(2)).Return(nil)
+	mockHistoryRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(2)).Return(nil)
+	mockCache.EXPECT().Set(gomock.Any(), "order:"+order1.OrderUID, gomock.Any(), 5*time.Minute).Return(nil)
+	mockCache.EXPECT().Set(gomock.Any(), "order:"+order2.OrderUID, gomock.Any(), 5*time.Minute).Return(nil)
+
+	errs := orderService.ProcessOrders(context.Background(), reqs, nil)
+
+	assert.Equal(t, []error{nil, nil}, errs)
+}
+
+func TestOrderService_ProcessOrders_FallbackOnBatchFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockDeliveryRepo := mocks.NewMockDeliveryRepository(ctrl)
+	mockPaymentRepo := mocks.NewMockPaymentRepository(ctrl)
+	mockItemsRepo := mocks.NewMockItemsRepository(ctrl)
+	mockOutboxRepo := mocks.NewMockOutboxRepository(ctrl)
+	mockHistoryRepo := mocks.NewMockHistoryRepository(ctrl)
+	mockTxManager := mocks.NewMockTxManagerInterface(ctrl)
+	mockCache := mocks.NewMockCache(ctrl)
+	logger.Init("local")
+	orderService := NewOrderService(
+		mockOrderRepo,
+		mockDeliveryRepo,
+		mockPaymentRepo,
+		mockItemsRepo,
+		mockOutboxRepo,
+		mockHistoryRepo,
+		mockTxManager,
+		mockCache,
+		5*time.Minute,
+	)
+
+	order1, order2 := generateRandomOrder(), generateRandomOrder()
+	reqs := []*dto.ProcessOrderRequest{
+		{Order: orderRequest(t, order1)},
+		{Order: orderRequest(t, order2)},
+	}
+	conflict := apperrors.Conflict("payments_pkey", nil)
+
+	mockTxManager.EXPECT().RunSerializableWithRetry(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		},
+	).Times(3)
+	mockOrderRepo.EXPECT().CreateOrders(gomock.Any(), gomock.Any()).Return(nil)
+	mockItemsRepo.EXPECT().AddItemsBatch(gomock.Any(), gomock.Any()).Return(nil)
+	mockDeliveryRepo.EXPECT().CreateDeliveries(gomock.Any(), gomock.Any()).Return(nil)
+	mockPaymentRepo.EXPECT().CreatePayments(gomock.Any(), gomock.Any()).Return(conflict)
+
+	mockOrderRepo.EXPECT().GetOrderVersion(gomock.Any(), gomock.Any()).Return(nil, postgres.ErrOrderNotFound).Times(2)
+	mockOrderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	mockItemsRepo.EXPECT().AddItems(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	mockDeliveryRepo.EXPECT().CreateDelivery(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	mockPaymentRepo.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).Return(nil)
+	mockPaymentRepo.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).Return(conflict)
+	mockHistoryRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).Return(nil)
+	mockOutboxRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).Return(nil)
+	mockCache.EXPECT().Set(gomock.Any(), "order:"+order1.OrderUID, gomock.Any(), 5*time.Minute).Return(nil)
+
+	errs := orderService.ProcessOrders(context.Background(), reqs, nil)
+
+	assert.Len(t, errs, 2)
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], apperrors.ErrConflict)
+}
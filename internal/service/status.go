@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+	"github.com/zhavkk/order-service/pkg/pgstorage"
+)
+
+const EventOrderStatusChanged = "order.status_changed"
+
+var ErrInvalidStatusTransition = errors.New("invalid status transition")
+
+// statusTransitions — допустимые переходы статуса заказа. cancelled и returned конечные.
+var statusTransitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderStatusCreated:    {models.OrderStatusPaid, models.OrderStatusCancelled},
+	models.OrderStatusPaid:       {models.OrderStatusAssembling, models.OrderStatusCancelled},
+	models.OrderStatusAssembling: {models.OrderStatusShipped, models.OrderStatusCancelled},
+	models.OrderStatusShipped:    {models.OrderStatusDelivered, models.OrderStatusReturned},
+	models.OrderStatusDelivered:  {models.OrderStatusReturned},
+	models.OrderStatusCancelled:  nil,
+	models.OrderStatusReturned:   nil,
+}
+
+func isKnownStatus(status models.OrderStatus) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+func canTransition(from, to models.OrderStatus) bool {
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeStatus переводит заказ в новый статус по таблице переходов. Повторная установка
+// текущего статуса — no-op, поэтому повторно доставленные сообщения из Kafka безопасны.
+func (s *OrderService) ChangeStatus(ctx context.Context, req *dto.ChangeStatusRequest) (*dto.ChangeStatusResponse, error) {
+	const op = "OrderService.ChangeStatus"
+
+	to := models.OrderStatus(req.Status)
+	if !isKnownStatus(to) {
+		return nil, apperrors.Invalid(fmt.Sprintf("unknown status %q", req.Status), nil)
+	}
+
+	change := &models.StatusChange{
+		OrderUID:  req.OrderUID,
+		To:        to,
+		ChangedBy: req.ChangedBy,
+		Reason:    req.Reason,
+	}
+	changed := false
+	err := s.txManager.RunSerializableWithRetry(ctx, func(ctx context.Context) error {
+		changed = false
+
+		from, err := s.orderRepo.GetStatusForUpdate(ctx, req.OrderUID)
+		if err != nil {
+			return err
+		}
+		change.From = from
+		if from == to {
+			return nil
+		}
+		if !canTransition(from, to) {
+			return apperrors.Conflict(
+				fmt.Sprintf("cannot change status from %s to %s", from, to), ErrInvalidStatusTransition,
+			)
+		}
+
+		if err := s.orderRepo.UpdateStatus(ctx, change); err != nil {
+			logger.Log.Error(op, "Failed to update order status", err)
+			return err
+		}
+
+		payload, err := json.Marshal(change)
+		if err != nil {
+			return apperrors.Invalid("failed to encode status event", err)
+		}
+		event := &models.OutboxEvent{
+			AggregateID: change.OrderUID,
+			EventType:   EventOrderStatusChanged,
+			Payload:     payload,
+		}
+		if err := s.addOutboxEvents(ctx, []*models.OutboxEvent{event}); err != nil {
+			logger.Log.Error(op, "Failed to add status event to outbox", err)
+			return err
+		}
+
+		historyEvent := newHistoryEvent(ctx, change.OrderUID, EventOrderStatusChanged, []models.FieldChange{
+			{Field: "status", Old: string(change.From), New: string(change.To)},
+		})
+		historyEvent.Actor = change.ChangedBy
+		if err := s.addHistory(ctx, historyEvent); err != nil {
+			return err
+		}
+
+		pgstorage.AfterCommit(ctx, func(ctx context.Context) {
+			if err := s.invalidateCachedOrder(ctx, change.OrderUID); err != nil {
+				logger.Log.Warn(op, "Failed to invalidate cached order", err)
+			}
+		})
+
+		changed = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if changed {
+		logger.Log.Info(op, "Order status changed, order_id: ", change.OrderUID, "from", change.From, "to", change.To)
+	}
+
+	return &dto.ChangeStatusResponse{
+		OrderUID:       change.OrderUID,
+		PreviousStatus: string(change.From),
+		Status:         string(change.To),
+		Changed:        changed,
+	}, nil
+}
+
+// ProcessStatusMessage обрабатывает сообщение из топика смены статусов. Неизвестный заказ
+// считается невалидным сообщением: повторная обработка его не исправит.
+func (s *OrderService) ProcessStatusMessage(ctx context.Context, message []byte) error {
+	const op = "OrderService.ProcessStatusMessage"
+
+	var req dto.ChangeStatusRequest
+	if err := json.Unmarshal(message, &req); err != nil {
+		logger.Log.Error(op, "Failed to unmarshal status update", err)
+		return apperrors.Invalid("malformed status update payload", err)
+	}
+	if err := validator.New().Struct(req); err != nil {
+		logger.Log.Warn(op, "Invalid status update ", err)
+		return apperrors.Invalid("status update validation failed", err)
+	}
+
+	if _, err := s.ChangeStatus(ctx, &req); err != nil {
+		if errors.Is(err, postgres.ErrOrderNotFound) {
+			return apperrors.Invalid("status update for unknown order", err)
+		}
+		return err
+	}
+
+	return nil
+}
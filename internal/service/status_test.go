@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/audit"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to models.OrderStatus
+		want     bool
+	}{
+		{models.OrderStatusCreated, models.OrderStatusPaid, true},
+		{models.OrderStatusCreated, models.OrderStatusShipped, false},
+		{models.OrderStatusPaid, models.OrderStatusAssembling, true},
+		{models.OrderStatusAssembling, models.OrderStatusShipped, true},
+		{models.OrderStatusShipped, models.OrderStatusCancelled, false},
+		{models.OrderStatusShipped, models.OrderStatusDelivered, true},
+		{models.OrderStatusDelivered, models.OrderStatusReturned, true},
+		{models.OrderStatusCancelled, models.OrderStatusPaid, false},
+		{models.OrderStatusReturned, models.OrderStatusDelivered, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.want, canTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestOrderService_ChangeStatus(t *testing.T) {
+	deps := newTestDeps(t)
+
+	deps.orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), "order-1").Return(models.OrderStatusCreated, nil)
+	deps.orderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, change *models.StatusChange) error {
+			assert.Equal(t, models.OrderStatusCreated, change.From)
+			assert.Equal(t, models.OrderStatusPaid, change.To)
+			assert.Equal(t, "billing", change.ChangedBy)
+			assert.Equal(t, "payment captured", change.Reason)
+			return nil
+		},
+	)
+	deps.outboxRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).Return(nil)
+	deps.historyRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).DoAndReturn(
+		func(_ context.Context, events []*models.OrderHistoryEvent) error {
+			assert.Equal(t, EventOrderStatusChanged, events[0].EventType)
+			assert.Equal(t, "billing", events[0].Actor)
+			assert.Equal(t, "http:req-1", events[0].Source)
+			assert.Equal(t, []models.FieldChange{{Field: "status", Old: "created", New: "paid"}}, events[0].Changes)
+			return nil
+		},
+	)
+	deps.cache.EXPECT().Delete(gomock.Any(), "order:order-1").Return(nil)
+	deps.historyRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).DoAndReturn(
+		func(_ context.Context, events []*models.OrderHistoryEvent) error {
+			assert.Equal(t, EventCacheInvalidated, events[0].EventType)
+			assert.Equal(t, audit.ActorAPI, events[0].Actor)
+			return nil
+		},
+	)
+
+	ctx := audit.WithOrigin(context.Background(), audit.ActorAPI, audit.HTTPSource("req-1"))
+	resp, err := deps.service.ChangeStatus(ctx, &dto.ChangeStatusRequest{
+		OrderUID:  "order-1",
+		Status:    "paid",
+		ChangedBy: "billing",
+		Reason:    "payment captured",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &dto.ChangeStatusResponse{
+		OrderUID:       "order-1",
+		PreviousStatus: "created",
+		Status:         "paid",
+		Changed:        true,
+	}, resp)
+}
+
+func TestOrderService_ChangeStatus_SameStatus(t *testing.T) {
+	deps := newTestDeps(t)
+
+	deps.orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), "order-1").Return(models.OrderStatusPaid, nil)
+
+	resp, err := deps.service.ChangeStatus(context.Background(), &dto.ChangeStatusRequest{
+		OrderUID: "order-1", Status: "paid", ChangedBy: "billing",
+	})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Changed)
+}
+
+func TestOrderService_ChangeStatus_InvalidTransition(t *testing.T) {
+	deps := newTestDeps(t)
+
+	deps.orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), "order-1").Return(models.OrderStatusCancelled, nil)
+
+	_, err := deps.service.ChangeStatus(context.Background(), &dto.ChangeStatusRequest{
+		OrderUID: "order-1", Status: "shipped", ChangedBy: "warehouse",
+	})
+
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+	assert.ErrorIs(t, err, apperrors.ErrConflict)
+	assert.Equal(t, "cannot change status from cancelled to shipped", apperrors.Reason(err))
+}
+
+func TestOrderService_ChangeStatus_UnknownStatus(t *testing.T) {
+	deps := newTestDeps(t)
+
+	_, err := deps.service.ChangeStatus(context.Background(), &dto.ChangeStatusRequest{
+		OrderUID: "order-1", Status: "lost", ChangedBy: "warehouse",
+	})
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalid)
+}
+
+func TestOrderService_ProcessStatusMessage(t *testing.T) {
+	deps := newTestDeps(t)
+
+	err := deps.service.ProcessStatusMessage(context.Background(), []byte(`{"order_uid":"order-1"`))
+	assert.ErrorIs(t, err, apperrors.ErrInvalid)
+
+	err = deps.service.ProcessStatusMessage(context.Background(), []byte(`{"order_uid":"order-1","status":"paid"}`))
+	assert.ErrorIs(t, err, apperrors.ErrInvalid)
+
+	deps.orderRepo.EXPECT().GetStatusForUpdate(gomock.Any(), "missing").Return(models.OrderStatus(""), postgres.ErrOrderNotFound)
+	err = deps.service.ProcessStatusMessage(context.Background(),
+		[]byte(`{"order_uid":"missing","status":"paid","changed_by":"billing"}`))
+	assert.ErrorIs(t, err, apperrors.ErrInvalid)
+}
+
+func TestOrderService_GetOrderHistory(t *testing.T) {
+	deps := newTestDeps(t)
+
+	createdAt := time.Now()
+	deps.historyRepo.EXPECT().GetHistory(gomock.Any(), "order-1").Return([]*models.OrderHistoryEvent{
+		{ID: 1, OrderUID: "order-1", EventType: EventOrderCreated, Actor: audit.ActorConsumer, Source: "kafka:orders/0/42", CreatedAt: createdAt},
+		{
+			ID: 2, OrderUID: "order-1", EventType: EventOrderStatusChanged, Actor: "billing", Source: "http:req-1",
+			Changes:   []models.FieldChange{{Field: "status", Old: "created", New: "paid"}},
+			CreatedAt: createdAt,
+		},
+	}, nil)
+
+	resp, err := deps.service.GetOrderHistory(context.Background(), "order-1")
+
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 2)
+	assert.Equal(t, "kafka:orders/0/42", resp.Events[0].Source)
+	assert.Empty(t, resp.Events[0].Changes)
+	assert.Equal(t, []dto.FieldChangeDTO{{Field: "status", Old: "created", New: "paid"}}, resp.Events[1].Changes)
+
+	deps.historyRepo.EXPECT().GetHistory(gomock.Any(), "missing").Return(nil, nil)
+	deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), "missing").Return(nil, postgres.ErrOrderNotFound)
+
+	_, err = deps.service.GetOrderHistory(context.Background(), "missing")
+
+	assert.ErrorIs(t, err, postgres.ErrOrderNotFound)
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+)
+
+const (
+	EventOrderUpdated = "order.updated"
+	// EventOrderReplayed — пришел повтор уже сохраненного содержимого, заказ не изменился.
+	EventOrderReplayed = "order.replayed"
+	// EventOrderRejected — измененный заказ отклонен политикой конфликтов.
+	EventOrderRejected = "order.rejected"
+)
+
+// ConflictPolicy определяет, что делать с сообщением, если заказ с тем же order_uid уже
+// сохранен с другим содержимым. Полные повторы (тот же хэш) всегда игнорируются.
+type ConflictPolicy string
+
+const (
+	ConflictPolicyReject        ConflictPolicy = "reject"
+	ConflictPolicyLastWriteWins ConflictPolicy = "last_write_wins"
+	ConflictPolicyNewerDateWins ConflictPolicy = "newer_date_created_wins"
+
+	defaultConflictPolicy = ConflictPolicyReject
+)
+
+// Результаты upsertOrder, они же значения метки result метрики order_upserts_total.
+const (
+	upsertCreated  = "created"
+	upsertUpdated  = "updated"
+	upsertReplay   = "replay"
+	upsertRejected = "rejected"
+)
+
+var (
+	ErrOrderContentMismatch = errors.New("order already exists with different content")
+	ErrStaleOrder           = errors.New("order is not newer than the stored version")
+)
+
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case ConflictPolicyReject, ConflictPolicyLastWriteWins, ConflictPolicyNewerDateWins:
+		return p, nil
+	case "":
+		return defaultConflictPolicy, nil
+	default:
+		return "", fmt.Errorf("unknown order conflict policy %q", s)
+	}
+}
+
+func (s *OrderService) SetConflictPolicy(policy ConflictPolicy) {
+	s.conflictPolicy = policy
+}
+
+// contentHash — хэш содержимого заказа из сообщения. Сериализация структуры детерминирована,
+// поэтому одинаковые сообщения дают одинаковый хэш независимо от порядка полей в исходном JSON.
+func contentHash(in dto.OrderRequest) string {
+	data, _ := json.Marshal(in)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// upsertOrder сохраняет заказ с учетом уже записанной версии и возвращает, что было сделано.
+// Вызывается внутри транзакции.
+func (s *OrderService) upsertOrder(ctx context.Context, order *models.Order) (string, error) {
+	existing, err := s.orderRepo.GetOrderVersion(ctx, order.OrderUID)
+	if errors.Is(err, postgres.ErrOrderNotFound) {
+		return upsertCreated, s.insertOrder(ctx, order)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if existing.ContentHash == order.ContentHash {
+		return upsertReplay, s.addHistory(ctx, newHistoryEvent(ctx, order.OrderUID, EventOrderReplayed, nil))
+	}
+	if existing.ContentHash == "" {
+		// Заказ сохранен до появления хэшей: считаем хэш по сохраненному содержимому и
+		// запоминаем его, только если сообщение действительно повтор.
+		replay, err := s.matchesStoredOrder(ctx, order)
+		if err != nil {
+			return "", err
+		}
+		if replay {
+			if err := s.orderRepo.SetContentHash(ctx, order.OrderUID, order.ContentHash); err != nil {
+				return "", err
+			}
+			return upsertReplay, s.addHistory(ctx, newHistoryEvent(ctx, order.OrderUID, EventOrderReplayed, nil))
+		}
+	}
+
+	switch s.conflictPolicy {
+	case ConflictPolicyLastWriteWins:
+	case ConflictPolicyNewerDateWins:
+		if !order.DateCreated.After(existing.DateCreated) {
+			return upsertRejected, apperrors.Conflict(
+				fmt.Sprintf("stored order %s is newer (version %d)", order.OrderUID, existing.Version), ErrStaleOrder,
+			)
+		}
+	default:
+		return upsertRejected, apperrors.Conflict(
+			fmt.Sprintf("order %s already exists with different content", order.OrderUID), ErrOrderContentMismatch,
+		)
+	}
+
+	return upsertUpdated, s.updateOrder(ctx, order, existing.Version)
+}
+
+// matchesStoredOrder сравнивает хэш сообщения с хэшем заказа, сохраненного без content_hash.
+func (s *OrderService) matchesStoredOrder(ctx context.Context, order *models.Order) (bool, error) {
+	const op = "OrderService.matchesStoredOrder"
+
+	stored, err := s.orderRepo.GetOrderByID(ctx, order.OrderUID)
+	if err != nil {
+		logger.Log.Error(op, "Failed to load stored order", err)
+		return false, err
+	}
+	return storedContentHash(s.modelToDTO(stored)) == order.ContentHash, nil
+}
+
+// storedContentHash — contentHash для заказа, прочитанного из базы. Время из Postgres приходит
+// в локальной зоне, а в сообщениях — в UTC, поэтому дата приводится к UTC.
+func storedContentHash(in dto.OrderResponse) string {
+	return contentHash(dto.OrderRequest{
+		OrderUID:          in.OrderUID,
+		TrackNumber:       in.TrackNumber,
+		Entry:             in.Entry,
+		Delivery:          in.Delivery,
+		Payment:           in.Payment,
+		Items:             in.Items,
+		Locale:            in.Locale,
+		InternalSignature: in.InternalSignature,
+		CustomerID:        in.CustomerID,
+		DeliveryService:   in.DeliveryService,
+		ShardKey:          in.ShardKey,
+		SmID:              in.SmID,
+		DateCreated:       in.DateCreated.UTC(),
+		OofShard:          in.OofShard,
+	})
+}
+
+func (s *OrderService) insertOrder(ctx context.Context, order *models.Order) error {
+	const op = "OrderService.insertOrder"
+
+	if err := s.orderRepo.CreateOrder(ctx, order); err != nil {
+		if errors.Is(err, postgres.ErrOrderExists) {
+			// Заказ вставили параллельно: перезапуск транзакции пойдет по ветке обновления.
+			return apperrors.Transient("order inserted concurrently", err)
+		}
+		logger.Log.Error(op, "Failed to create order", err)
+		return err
+	}
+	order.Status = models.OrderStatusCreated
+
+	if err := s.saveOrderParts(ctx, order, s.paymentRepo.CreatePayment); err != nil {
+		return err
+	}
+
+	if err := s.addHistory(ctx, newHistoryEvent(ctx, order.OrderUID, EventOrderCreated, nil)); err != nil {
+		return err
+	}
+	return s.addOrderEvent(ctx, order, EventOrderCreated)
+}
+
+func (s *OrderService) updateOrder(ctx context.Context, order *models.Order, version int) error {
+	const op = "OrderService.updateOrder"
+
+	previous, err := s.orderRepo.GetOrderByID(ctx, order.OrderUID)
+	if err != nil {
+		logger.Log.Error(op, "Failed to load stored order", err)
+		return err
+	}
+	changes, err := s.diffOrders(previous, order)
+	if err != nil {
+		return apperrors.Invalid("failed to diff order", err)
+	}
+
+	if err := s.orderRepo.UpdateOrder(ctx, order, version); err != nil {
+		if errors.Is(err, postgres.ErrVersionConflict) {
+			return apperrors.Transient("order updated concurrently", err)
+		}
+		logger.Log.Error(op, "Failed to update order", err)
+		return err
+	}
+
+	if err := s.saveOrderParts(ctx, order, s.paymentRepo.ReplacePayment); err != nil {
+		return err
+	}
+
+	logger.Log.Info(op, "Order updated, order_id: ", order.OrderUID, "version", order.Version)
+	if err := s.addHistory(ctx, newHistoryEvent(ctx, order.OrderUID, EventOrderUpdated, changes)); err != nil {
+		return err
+	}
+	return s.addOrderEvent(ctx, order, EventOrderUpdated)
+}
+
+func (s *OrderService) saveOrderParts(
+	ctx context.Context,
+	order *models.Order,
+	savePayment func(ctx context.Context, payment *models.Payment) error,
+) error {
+	const op = "OrderService.saveOrderParts"
+
+	items := make([]*models.Item, len(order.Items))
+	for i := range order.Items {
+		items[i] = &order.Items[i]
+	}
+	if err := s.itemsRepo.AddItems(ctx, order.OrderUID, items); err != nil {
+		logger.Log.Error(op, "Failed to add items to order", err)
+		return err
+	}
+
+	if err := s.deliveryRepo.CreateDelivery(ctx, &order.Delivery); err != nil {
+		logger.Log.Error(op, "Failed to create delivery", err)
+		return err
+	}
+
+	if err := savePayment(ctx, &order.Payment); err != nil {
+		logger.Log.Error(op, "Failed to save payment", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *OrderService) addOrderEvent(ctx context.Context, order *models.Order, eventType string) error {
+	const op = "OrderService.addOrderEvent"
+
+	event, err := s.newOrderEvent(order, eventType)
+	if err != nil {
+		return err
+	}
+	if err := s.addOutboxEvents(ctx, []*models.OutboxEvent{event}); err != nil {
+		logger.Log.Error(op, "Failed to add order event to outbox", err)
+		return err
+	}
+	return nil
+}
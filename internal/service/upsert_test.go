@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/mocks"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+	"github.com/zhavkk/order-service/pkg/pgstorage"
+)
+
+func TestOrderService_ProcessOrder_Replay(t *testing.T) {
+	deps := newTestDeps(t)
+	deps.service.SetConflictPolicy(ConflictPolicyReject)
+
+	order := generateRandomOrder()
+	req := &dto.ProcessOrderRequest{Order: orderRequest(t, order)}
+	deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), order.OrderUID).Return(&models.OrderVersion{
+		ContentHash: contentHash(req.Order),
+		Version:     3,
+		DateCreated: order.DateCreated,
+	}, nil)
+	deps.expectHistoryEvent(t, order.OrderUID, EventOrderReplayed)
+
+	err := deps.service.ProcessOrder(context.Background(), req)
+
+	assert.NoError(t, err)
+}
+
+func TestOrderService_ProcessOrder_LegacyOrderWithoutHash(t *testing.T) {
+	deps := newTestDeps(t)
+	deps.service.SetConflictPolicy(ConflictPolicyReject)
+
+	order := generateRandomOrder()
+	req := &dto.ProcessOrderRequest{Order: orderRequest(t, order)}
+	// Postgres возвращает время в локальной зоне: на хэш это влиять не должно.
+	stored := order
+	stored.DateCreated = order.DateCreated.In(time.FixedZone("MSK", 3*60*60))
+	deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), order.OrderUID).Return(&models.OrderVersion{
+		Version:     1,
+		DateCreated: order.DateCreated,
+	}, nil)
+	deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), order.OrderUID).Return(&stored, nil)
+	deps.orderRepo.EXPECT().SetContentHash(gomock.Any(), order.OrderUID, contentHash(req.Order)).Return(nil)
+	deps.expectHistoryEvent(t, order.OrderUID, EventOrderReplayed)
+
+	err := deps.service.ProcessOrder(context.Background(), req)
+
+	assert.NoError(t, err)
+}
+
+func TestOrderService_ProcessOrder_LegacyOrderWithoutHashChanged(t *testing.T) {
+	deps := newTestDeps(t)
+	deps.service.SetConflictPolicy(ConflictPolicyReject)
+
+	order := generateRandomOrder()
+	stored := order
+	stored.TrackNumber = "WBILMOLDTRACK"
+	deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), order.OrderUID).Return(&models.OrderVersion{
+		Version:     1,
+		DateCreated: order.DateCreated,
+	}, nil)
+	deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), order.OrderUID).Return(&stored, nil)
+	deps.expectHistoryEvent(t, order.OrderUID, EventOrderRejected)
+
+	err := deps.service.ProcessOrder(context.Background(), &dto.ProcessOrderRequest{Order: orderRequest(t, order)})
+
+	require.ErrorIs(t, err, ErrOrderContentMismatch)
+	assert.ErrorIs(t, err, apperrors.ErrConflict)
+}
+
+func TestOrderService_ProcessOrder_ConflictPolicies(t *testing.T) {
+	order := generateRandomOrder()
+	stored := &models.OrderVersion{ContentHash: "previous", Version: 2, DateCreated: order.DateCreated}
+
+	t.Run("reject", func(t *testing.T) {
+		deps := newTestDeps(t)
+		deps.service.SetConflictPolicy(ConflictPolicyReject)
+		deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), order.OrderUID).Return(stored, nil)
+		deps.expectHistoryEvent(t, order.OrderUID, EventOrderRejected)
+
+		err := deps.service.ProcessOrder(context.Background(), &dto.ProcessOrderRequest{Order: orderRequest(t, order)})
+
+		require.ErrorIs(t, err, ErrOrderContentMismatch)
+		assert.ErrorIs(t, err, apperrors.ErrConflict)
+	})
+
+	t.Run("newer date_created wins, stale message", func(t *testing.T) {
+		deps := newTestDeps(t)
+		deps.service.SetConflictPolicy(ConflictPolicyNewerDateWins)
+		deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), order.OrderUID).Return(stored, nil)
+		deps.expectHistoryEvent(t, order.OrderUID, EventOrderRejected)
+
+		err := deps.service.ProcessOrder(context.Background(), &dto.ProcessOrderRequest{Order: orderRequest(t, order)})
+
+		require.ErrorIs(t, err, ErrStaleOrder)
+		assert.ErrorIs(t, err, apperrors.ErrConflict)
+	})
+
+	for _, policy := range []ConflictPolicy{ConflictPolicyLastWriteWins, ConflictPolicyNewerDateWins} {
+		t.Run(string(policy)+", update", func(t *testing.T) {
+			deps := newTestDeps(t)
+			deps.service.SetConflictPolicy(policy)
+			newer := order
+			newer.DateCreated = order.DateCreated.Add(time.Hour)
+
+			deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), order.OrderUID).Return(stored, nil)
+			previous := order
+			deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), order.OrderUID).Return(&previous, nil)
+			deps.orderRepo.EXPECT().UpdateOrder(gomock.Any(), gomock.Any(), 2).DoAndReturn(
+				func(_ context.Context, o *models.Order, _ int) error {
+					o.Version = 3
+					return nil
+				},
+			)
+			deps.itemsRepo.EXPECT().AddItems(gomock.Any(), order.OrderUID, gomock.Any()).Return(nil)
+			deps.deliveryRepo.EXPECT().CreateDelivery(gomock.Any(), gomock.Any()).Return(nil)
+			deps.paymentRepo.EXPECT().ReplacePayment(gomock.Any(), gomock.Any()).Return(nil)
+			deps.outboxRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).DoAndReturn(
+				func(_ context.Context, events []*models.OutboxEvent) error {
+					assert.Equal(t, EventOrderUpdated, events[0].EventType)
+					return nil
+				},
+			)
+			deps.historyRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).DoAndReturn(
+				func(_ context.Context, events []*models.OrderHistoryEvent) error {
+					assert.Equal(t, EventOrderUpdated, events[0].EventType)
+					assert.Equal(t, []models.FieldChange{{
+						Field: "date_created",
+						Old:   order.DateCreated.Format(time.RFC3339Nano),
+						New:   newer.DateCreated.Format(time.RFC3339Nano),
+					}}, events[0].Changes)
+					return nil
+				},
+			)
+			deps.cache.EXPECT().Delete(gomock.Any(), "order:"+order.OrderUID).Return(nil)
+			deps.historyRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).Return(nil)
+
+			err := deps.service.ProcessOrder(context.Background(), &dto.ProcessOrderRequest{Order: orderRequest(t, newer)})
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseConflictPolicy(t *testing.T) {
+	policy, err := ParseConflictPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, ConflictPolicyReject, policy)
+
+	policy, err = ParseConflictPolicy("newer_date_created_wins")
+	require.NoError(t, err)
+	assert.Equal(t, ConflictPolicyNewerDateWins, policy)
+
+	_, err = ParseConflictPolicy("first_write_wins")
+	assert.Error(t, err)
+}
+
+func TestOrderService_ProcessOrder_CacheUnavailable(t *testing.T) {
+	errCacheDown := errors.New("redis: connection refused")
+	order := generateRandomOrder()
+
+	t.Run("insert", func(t *testing.T) {
+		deps := newTestDeps(t)
+		deps.service.SetConflictPolicy(ConflictPolicyReject)
+		deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), order.OrderUID).Return(nil, postgres.ErrOrderNotFound)
+		deps.orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil)
+		deps.itemsRepo.EXPECT().AddItems(gomock.Any(), order.OrderUID, gomock.Any()).Return(nil)
+		deps.deliveryRepo.EXPECT().CreateDelivery(gomock.Any(), gomock.Any()).Return(nil)
+		deps.paymentRepo.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).Return(nil)
+		deps.historyRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).Return(nil)
+		deps.outboxRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).Return(nil)
+		deps.cache.EXPECT().Set(gomock.Any(), "order:"+order.OrderUID, gomock.Any(), gomock.Any()).Return(errCacheDown)
+
+		err := deps.service.ProcessOrder(context.Background(), &dto.ProcessOrderRequest{Order: orderRequest(t, order)})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		deps := newTestDeps(t)
+		deps.service.SetConflictPolicy(ConflictPolicyLastWriteWins)
+		deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), order.OrderUID).Return(
+			&models.OrderVersion{ContentHash: "previous", Version: 1, DateCreated: order.DateCreated}, nil,
+		)
+		previous := order
+		deps.orderRepo.EXPECT().GetOrderByID(gomock.Any(), order.OrderUID).Return(&previous, nil)
+		deps.orderRepo.EXPECT().UpdateOrder(gomock.Any(), gomock.Any(), 1).Return(nil)
+		deps.itemsRepo.EXPECT().AddItems(gomock.Any(), order.OrderUID, gomock.Any()).Return(nil)
+		deps.deliveryRepo.EXPECT().CreateDelivery(gomock.Any(), gomock.Any()).Return(nil)
+		deps.paymentRepo.EXPECT().ReplacePayment(gomock.Any(), gomock.Any()).Return(nil)
+		deps.outboxRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).Return(nil)
+		// Только событие обновления: инвалидация не удалась и в историю не попадает.
+		deps.historyRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).Return(nil).Times(1)
+		deps.cache.EXPECT().Delete(gomock.Any(), "order:"+order.OrderUID).Return(errCacheDown)
+
+		err := deps.service.ProcessOrder(context.Background(), &dto.ProcessOrderRequest{Order: orderRequest(t, order)})
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestOrderService_ProcessOrder_CacheWrittenAfterCommit(t *testing.T) {
+	deps := newTestDeps(t)
+
+	// Первая попытка не закоммитилась (например, serialization_failure), вторая прошла.
+	txManager := mocks.NewMockTxManagerInterface(deps.ctrl)
+	txManager.EXPECT().RunSerializableWithRetry(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error) error {
+			failedCtx, _ := pgstorage.WithCommitHooks(ctx)
+			require.NoError(t, fn(failedCtx))
+
+			txCtx, commit := pgstorage.WithCommitHooks(ctx)
+			if err := fn(txCtx); err != nil {
+				return err
+			}
+			commit(ctx)
+			return nil
+		},
+	)
+	deps.service.txManager = txManager
+	notified := 0
+	deps.service.SetEventNotifier(func() { notified++ })
+
+	order := generateRandomOrder()
+	deps.orderRepo.EXPECT().GetOrderVersion(gomock.Any(), order.OrderUID).Return(nil, postgres.ErrOrderNotFound).Times(2)
+	deps.orderRepo.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	deps.itemsRepo.EXPECT().AddItems(gomock.Any(), order.OrderUID, gomock.Any()).Return(nil).Times(2)
+	deps.deliveryRepo.EXPECT().CreateDelivery(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	deps.paymentRepo.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	deps.historyRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).Return(nil).Times(2)
+	deps.outboxRepo.EXPECT().AddEvents(gomock.Any(), gomock.Len(1)).Return(nil).Times(2)
+	deps.cache.EXPECT().Set(gomock.Any(), "order:"+order.OrderUID, gomock.Any(), testCacheTTL).Return(nil).Times(1)
+
+	err := deps.service.ProcessOrder(context.Background(), &dto.ProcessOrderRequest{Order: orderRequest(t, order)})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, notified)
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/logger"
+	prometheusmetrics "github.com/zhavkk/order-service/pkg/metrics/prometheus"
+)
+
+// ValidationMode определяет, что делать с нарушением бизнес-правила: strict отклоняет заказ,
+// warn только пишет в лог и метрику, off выключает правило.
+type ValidationMode string
+
+const (
+	ValidationModeStrict ValidationMode = "strict"
+	ValidationModeWarn   ValidationMode = "warn"
+	ValidationModeOff    ValidationMode = "off"
+
+	// defaultValidationMode не отклоняет заказы, которые принимались до появления правил:
+	// strict включается в конфиге.
+	defaultValidationMode = ValidationModeWarn
+)
+
+// Имена встроенных правил, они же ключи в конфиге и значения метки rule.
+const (
+	RuleGoodsTotal      = "goods_total"
+	RuleAmount          = "amount"
+	RuleItemTrackNumber = "item_track_number"
+	RuleItemTotalPrice  = "item_total_price"
+)
+
+const (
+	maxSalePercent      = 100
+	totalPriceTolerance = 1
+)
+
+// Violation — нарушение правила в конкретном поле заказа. Field — путь в JSON сообщения.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ValidationRule struct {
+	Name  string
+	Check func(order *dto.OrderRequest) []Violation
+}
+
+// ValidationError содержит все нарушения строгих правил.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = fmt.Sprintf("%s: %s (%s)", v.Field, v.Message, v.Rule)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func ParseValidationMode(s string) (ValidationMode, error) {
+	switch m := ValidationMode(s); m {
+	case ValidationModeStrict, ValidationModeWarn, ValidationModeOff:
+		return m, nil
+	case "":
+		return defaultValidationMode, nil
+	default:
+		return "", fmt.Errorf("unknown validation mode %q", s)
+	}
+}
+
+// OrderValidator проверяет согласованность полей заказа набором правил.
+type OrderValidator struct {
+	rules       []ValidationRule
+	defaultMode ValidationMode
+	modes       map[string]ValidationMode
+}
+
+func NewOrderValidator(defaultMode ValidationMode, rules ...ValidationRule) *OrderValidator {
+	return &OrderValidator{
+		rules:       rules,
+		defaultMode: defaultMode,
+		modes:       make(map[string]ValidationMode),
+	}
+}
+
+// Register добавляет правило. Правило с тем же именем заменяется.
+func (v *OrderValidator) Register(rule ValidationRule) {
+	for i := range v.rules {
+		if v.rules[i].Name == rule.Name {
+			v.rules[i] = rule
+			return
+		}
+	}
+	v.rules = append(v.rules, rule)
+}
+
+// SetRuleMode переопределяет режим для одного правила.
+func (v *OrderValidator) SetRuleMode(name string, mode ValidationMode) error {
+	for _, rule := range v.rules {
+		if rule.Name == name {
+			v.modes[name] = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown validation rule %q", name)
+}
+
+func (v *OrderValidator) mode(rule string) ValidationMode {
+	if mode, ok := v.modes[rule]; ok {
+		return mode
+	}
+	return v.defaultMode
+}
+
+// Validate прогоняет все включенные правила. Нарушения warn-правил логируются,
+// нарушения strict-правил возвращаются как Invalid с *ValidationError внутри.
+func (v *OrderValidator) Validate(order *dto.OrderRequest) error {
+	const op = "OrderValidator.Validate"
+
+	var strict []Violation
+	for _, rule := range v.rules {
+		mode := v.mode(rule.Name)
+		if mode == ValidationModeOff {
+			continue
+		}
+		for _, violation := range rule.Check(order) {
+			violation.Rule = rule.Name
+			prometheusmetrics.OrderValidationViolationsTotal.WithLabelValues(rule.Name, string(mode)).Inc()
+			if mode == ValidationModeWarn {
+				logger.Log.Warn(op, "Order rule violated, order_id: ", order.OrderUID,
+					"field", violation.Field, "message", violation.Message)
+				continue
+			}
+			strict = append(strict, violation)
+		}
+	}
+
+	if len(strict) > 0 {
+		return apperrors.Invalid("order business validation failed", &ValidationError{Violations: strict})
+	}
+	return nil
+}
+
+func (s *OrderService) SetOrderValidator(v *OrderValidator) {
+	s.orderValidator = v
+}
+
+func DefaultValidationRules() []ValidationRule {
+	return []ValidationRule{
+		{Name: RuleGoodsTotal, Check: checkGoodsTotal},
+		{Name: RuleAmount, Check: checkAmount},
+		{Name: RuleItemTrackNumber, Check: checkItemTrackNumber},
+		{Name: RuleItemTotalPrice, Check: checkItemTotalPrice},
+	}
+}
+
+func checkGoodsTotal(order *dto.OrderRequest) []Violation {
+	sum := 0
+	for _, item := range order.Items {
+		sum += item.TotalPrice
+	}
+	if order.Payment.GoodsTotal != sum {
+		return []Violation{{
+			Field:   "payment.goods_total",
+			Message: fmt.Sprintf("expected sum of items total_price %d, got %d", sum, order.Payment.GoodsTotal),
+		}}
+	}
+	return nil
+}
+
+func checkAmount(order *dto.OrderRequest) []Violation {
+	p := order.Payment
+	want := p.GoodsTotal + p.DeliveryCost + p.CustomFee
+	if p.Amount != want {
+		return []Violation{{
+			Field:   "payment.amount",
+			Message: fmt.Sprintf("expected goods_total + delivery_cost + custom_fee = %d, got %d", want, p.Amount),
+		}}
+	}
+	return nil
+}
+
+func checkItemTrackNumber(order *dto.OrderRequest) []Violation {
+	var violations []Violation
+	for i, item := range order.Items {
+		if item.TrackNumber != order.TrackNumber {
+			violations = append(violations, Violation{
+				Field:   fmt.Sprintf("items[%d].track_number", i),
+				Message: fmt.Sprintf("expected order track_number %q, got %q", order.TrackNumber, item.TrackNumber),
+			})
+		}
+	}
+	return violations
+}
+
+// checkItemTotalPrice проверяет total_price = price * (100 - sale) / 100. Поставщики
+// округляют по-разному, поэтому допускается расхождение на единицу.
+func checkItemTotalPrice(order *dto.OrderRequest) []Violation {
+	var violations []Violation
+	for i, item := range order.Items {
+		if item.Sale > maxSalePercent {
+			violations = append(violations, Violation{
+				Field:   fmt.Sprintf("items[%d].sale", i),
+				Message: fmt.Sprintf("sale must be a percentage, got %d", item.Sale),
+			})
+			continue
+		}
+		want := item.Price * (maxSalePercent - item.Sale) / maxSalePercent
+		if diff := item.TotalPrice - want; diff > totalPriceTolerance || diff < -totalPriceTolerance {
+			violations = append(violations, Violation{
+				Field:   fmt.Sprintf("items[%d].total_price", i),
+				Message: fmt.Sprintf("expected price with sale applied %d, got %d", want, item.TotalPrice),
+			})
+		}
+	}
+	return violations
+}
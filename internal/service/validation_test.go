@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/apperrors"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/logger"
+)
+
+func validOrderRequest(t *testing.T) dto.OrderRequest {
+	t.Helper()
+	in := orderRequest(t, generateRandomOrder())
+	require.NoError(t, NewOrderValidator(ValidationModeStrict, DefaultValidationRules()...).Validate(&in))
+	return in
+}
+
+func TestOrderValidator_Rules(t *testing.T) {
+	logger.Init("local")
+
+	tests := []struct {
+		name   string
+		modify func(in *dto.OrderRequest)
+		want   []Violation
+	}{
+		{
+			name:   "goods total",
+			modify: func(in *dto.OrderRequest) { in.Payment.GoodsTotal = 800; in.Payment.Amount = 900 },
+			want: []Violation{{
+				Rule: RuleGoodsTotal, Field: "payment.goods_total",
+				Message: "expected sum of items total_price 900, got 800",
+			}},
+		},
+		{
+			name:   "amount",
+			modify: func(in *dto.OrderRequest) { in.Payment.CustomFee = 50 },
+			want: []Violation{{
+				Rule: RuleAmount, Field: "payment.amount",
+				Message: "expected goods_total + delivery_cost + custom_fee = 1050, got 1000",
+			}},
+		},
+		{
+			name:   "item track number",
+			modify: func(in *dto.OrderRequest) { in.Items[0].TrackNumber = "OTHER" },
+			want: []Violation{{
+				Rule: RuleItemTrackNumber, Field: "items[0].track_number",
+				Message: `expected order track_number "WBILMTESTTRACK", got "OTHER"`,
+			}},
+		},
+		{
+			name:   "item total price",
+			modify: func(in *dto.OrderRequest) { in.Items[0].Sale = 20 },
+			want: []Violation{{
+				Rule: RuleItemTotalPrice, Field: "items[0].total_price",
+				Message: "expected price with sale applied 800, got 900",
+			}},
+		},
+		{
+			name: "item total price rounding",
+			modify: func(in *dto.OrderRequest) {
+				in.Items[0].Price, in.Items[0].Sale, in.Items[0].TotalPrice = 453, 30, 318
+				in.Payment.GoodsTotal, in.Payment.Amount = 318, 418
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := validOrderRequest(t)
+			tt.modify(&in)
+
+			err := NewOrderValidator(ValidationModeStrict, DefaultValidationRules()...).Validate(&in)
+
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, apperrors.ErrInvalid)
+			var verr *ValidationError
+			require.True(t, errors.As(err, &verr))
+			assert.Equal(t, tt.want, verr.Violations)
+		})
+	}
+}
+
+func TestOrderValidator_Modes(t *testing.T) {
+	logger.Init("local")
+
+	in := validOrderRequest(t)
+	in.Items[0].TrackNumber = "OTHER"
+
+	v := NewOrderValidator(ValidationModeWarn, DefaultValidationRules()...)
+	assert.NoError(t, v.Validate(&in))
+
+	require.NoError(t, v.SetRuleMode(RuleItemTrackNumber, ValidationModeStrict))
+	assert.ErrorIs(t, v.Validate(&in), apperrors.ErrInvalid)
+
+	require.NoError(t, v.SetRuleMode(RuleItemTrackNumber, ValidationModeOff))
+	assert.NoError(t, v.Validate(&in))
+
+	assert.Error(t, v.SetRuleMode("unknown_rule", ValidationModeStrict))
+}
+
+func TestOrderValidator_Register(t *testing.T) {
+	logger.Init("local")
+
+	v := NewOrderValidator(ValidationModeStrict)
+	v.Register(ValidationRule{
+		Name: "ru_locale",
+		Check: func(order *dto.OrderRequest) []Violation {
+			if order.Locale != "ru" {
+				return []Violation{{Field: "locale", Message: "only ru is supported"}}
+			}
+			return nil
+		},
+	})
+
+	in := validOrderRequest(t)
+	assert.NoError(t, v.Validate(&in))
+
+	in.Locale = "en"
+	err := v.Validate(&in)
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Equal(t, []Violation{{Rule: "ru_locale", Field: "locale", Message: "only ru is supported"}}, verr.Violations)
+}
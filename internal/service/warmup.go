@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/zhavkk/order-service/internal/logger"
+	prometheusmetrics "github.com/zhavkk/order-service/pkg/metrics/prometheus"
+	"golang.org/x/sync/errgroup"
+)
+
+// WarmUpStrategy определяет, какие заказы загружаются в кэш при старте.
+type WarmUpStrategy string
+
+const (
+	// WarmUpRecent — последние Limit заказов по date_created.
+	WarmUpRecent WarmUpStrategy = "recent"
+	// WarmUpMostAccessed — до Limit заказов, которые чаще всего читали за Window.
+	// Пока счетчиков нет (первый запуск), используется WarmUpRecent.
+	WarmUpMostAccessed WarmUpStrategy = "most_accessed"
+	// WarmUpRecentDays — заказы за последние Days дней, не больше Limit.
+	WarmUpRecentDays WarmUpStrategy = "recent_days"
+	// WarmUpNone — без прогрева.
+	WarmUpNone WarmUpStrategy = "none"
+)
+
+func ParseWarmUpStrategy(s string) (WarmUpStrategy, error) {
+	switch st := WarmUpStrategy(s); st {
+	case WarmUpRecent, WarmUpMostAccessed, WarmUpRecentDays, WarmUpNone:
+		return st, nil
+	case "":
+		return WarmUpRecent, nil
+	default:
+		return "", fmt.Errorf("unknown cache warm-up strategy %q", s)
+	}
+}
+
+type WarmUpOptions struct {
+	Strategy WarmUpStrategy
+	Limit    int
+	Window   time.Duration
+	Days     int
+	// Concurrency — сколько пачек по BatchSize заказов загружается одновременно.
+	Concurrency int
+	BatchSize   int
+	// ReadyThreshold — доля (0..1) запланированных заказов, после загрузки которой
+	// сервис считается готовым. 0 — готов сразу, 1 — только после всего прогрева.
+	ReadyThreshold float64
+	// Timeout ограничивает прогрев, запущенный через TriggerWarmUp.
+	Timeout time.Duration
+}
+
+var defaultWarmUpOptions = WarmUpOptions{
+	Strategy:    WarmUpRecent,
+	Limit:       1000,
+	Window:      24 * time.Hour,
+	Days:        1,
+	Concurrency: 4,
+	BatchSize:   100,
+	Timeout:     30 * time.Second,
+}
+
+// WarmUpProgress — состояние прогрева для /ready.
+type WarmUpProgress struct {
+	Strategy WarmUpStrategy `json:"strategy"`
+	Planned  int64          `json:"planned"`
+	Cached   int64          `json:"cached"`
+	Failed   int64          `json:"failed"`
+	Done     bool           `json:"done"`
+	Ready    bool           `json:"ready"`
+}
+
+type warmUpState struct {
+	planned atomic.Int64
+	cached  atomic.Int64
+	failed  atomic.Int64
+	done    atomic.Bool
+	// ready запоминает, что сервис уже был готов: повторный прогрев не выводит его из работы.
+	ready   atomic.Bool
+	running atomic.Bool
+}
+
+func (s *OrderService) SetWarmUpOptions(opts WarmUpOptions) {
+	if opts.Strategy == "" {
+		opts.Strategy = defaultWarmUpOptions.Strategy
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = defaultWarmUpOptions.Limit
+	}
+	if opts.Window <= 0 {
+		opts.Window = defaultWarmUpOptions.Window
+	}
+	if opts.Days <= 0 {
+		opts.Days = defaultWarmUpOptions.Days
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultWarmUpOptions.Concurrency
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultWarmUpOptions.BatchSize
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultWarmUpOptions.Timeout
+	}
+	s.warmUpOpts = opts
+}
+
+// WarmUpProgress возвращает состояние прогрева. Прогрев, завершившийся ошибкой или
+// по таймауту, тоже считается завершенным: сервис не должен оставаться неготовым навсегда.
+func (s *OrderService) WarmUpProgress() WarmUpProgress {
+	p := WarmUpProgress{
+		Strategy: s.warmUpOpts.Strategy,
+		Planned:  s.warmUp.planned.Load(),
+		Cached:   s.warmUp.cached.Load(),
+		Failed:   s.warmUp.failed.Load(),
+		Done:     s.warmUp.done.Load(),
+	}
+	p.Ready = s.warmUp.ready.Load() || p.Done || s.warmUpOpts.ReadyThreshold <= 0 ||
+		(p.Planned > 0 && float64(p.Cached) >= s.warmUpOpts.ReadyThreshold*float64(p.Planned))
+	if p.Ready {
+		s.warmUp.ready.Store(true)
+	}
+	return p
+}
+
+func (s *OrderService) WarmUpReady() bool {
+	return s.WarmUpProgress().Ready
+}
+
+// WarmUpCache загружает в кэш заказы, выбранные стратегией. Заказы читаются из базы
+// пачками по BatchSize, одновременно не больше Concurrency пачек. Если прогрев уже идет,
+// возвращает ErrWarmUpRunning.
+func (s *OrderService) WarmUpCache(ctx context.Context) error {
+	if !s.startWarmUp() {
+		return ErrWarmUpRunning
+	}
+	defer s.warmUp.running.Store(false)
+	return s.warmUpCache(ctx)
+}
+
+// startWarmUp занимает прогрев и сбрасывает счетчики прошлого запуска. Готовность при этом
+// не сбрасывается.
+func (s *OrderService) startWarmUp() bool {
+	if !s.warmUp.running.CompareAndSwap(false, true) {
+		return false
+	}
+	s.warmUp.done.Store(false)
+	s.warmUp.planned.Store(0)
+	s.warmUp.cached.Store(0)
+	s.warmUp.failed.Store(0)
+	return true
+}
+
+func (s *OrderService) warmUpCache(ctx context.Context) error {
+	const op = "OrderService.WarmUpCache"
+	defer func() {
+		s.warmUp.done.Store(true)
+		s.warmUp.ready.Store(true)
+	}()
+
+	opts := s.warmUpOpts
+	logger.Log.Info(op, "Warming up cache, strategy: ", opts.Strategy, "limit", opts.Limit)
+
+	orderUIDs, err := s.warmUpCandidates(ctx)
+	if err != nil {
+		logger.Log.Error(op, "Failed to select orders for warm-up", err)
+		return err
+	}
+	s.warmUp.planned.Store(int64(len(orderUIDs)))
+	s.reportWarmUp()
+
+	var g errgroup.Group
+	g.SetLimit(opts.Concurrency)
+	for start := 0; start < len(orderUIDs); start += opts.BatchSize {
+		if ctx.Err() != nil {
+			break
+		}
+		batch := orderUIDs[start:min(start+opts.BatchSize, len(orderUIDs))]
+		g.Go(func() error {
+			s.warmUpBatch(ctx, batch)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	progress := s.WarmUpProgress()
+	logger.Log.Info(op, "Cache warm-up completed, planned: ", progress.Planned,
+		"cached", progress.Cached, "failed", progress.Failed)
+
+	return ctx.Err()
+}
+
+func (s *OrderService) warmUpCandidates(ctx context.Context) ([]string, error) {
+	const op = "OrderService.warmUpCandidates"
+
+	opts := s.warmUpOpts
+	switch opts.Strategy {
+	case WarmUpNone:
+		return nil, nil
+	case WarmUpRecentDays:
+		since := time.Now().AddDate(0, 0, -opts.Days)
+		return s.orderRepo.GetOrderUIDsCreatedSince(ctx, since, opts.Limit)
+	case WarmUpMostAccessed:
+		if s.access == nil {
+			logger.Log.Warn(op, "Access tracking is disabled, falling back to recent orders", nil)
+			break
+		}
+		orderUIDs, err := s.access.MostAccessed(ctx, opts.Window, opts.Limit)
+		if err != nil || len(orderUIDs) > 0 {
+			return orderUIDs, err
+		}
+		logger.Log.Warn(op, "No access counts recorded yet, falling back to recent orders", nil)
+	}
+	return s.orderRepo.GetRecentOrderUIDs(ctx, opts.Limit)
+}
+
+func (s *OrderService) warmUpBatch(ctx context.Context, orderUIDs []string) {
+	const op = "OrderService.warmUpBatch"
+	defer s.reportWarmUp()
+
+	orders, err := s.orderRepo.GetOrdersByIDs(ctx, orderUIDs)
+	if err != nil {
+		logger.Log.Error(op, "Failed to load orders for warm-up", err)
+		s.warmUp.failed.Add(int64(len(orderUIDs)))
+		return
+	}
+	// Заказы, которых уже нет в базе, тоже считаются неудачными.
+	s.warmUp.failed.Add(int64(len(orderUIDs) - len(orders)))
+
+	for _, order := range orders {
+		if err := s.cacheOrder(ctx, order, 0); err != nil {
+			logger.Log.Error(op, "Failed to cache order with order_id: ", order.OrderUID, "error", err)
+			s.warmUp.failed.Add(1)
+			continue
+		}
+		s.warmUp.cached.Add(1)
+	}
+}
+
+func (s *OrderService) reportWarmUp() {
+	planned, cached, failed := s.warmUp.planned.Load(), s.warmUp.cached.Load(), s.warmUp.failed.Load()
+	prometheusmetrics.CacheWarmUpOrders.WithLabelValues("planned").Set(float64(planned))
+	prometheusmetrics.CacheWarmUpOrders.WithLabelValues("cached").Set(float64(cached))
+	prometheusmetrics.CacheWarmUpOrders.WithLabelValues("failed").Set(float64(failed))
+	ratio := 1.0
+	if planned > 0 {
+		ratio = float64(cached+failed) / float64(planned)
+	}
+	prometheusmetrics.CacheWarmUpProgressRatio.Set(ratio)
+}
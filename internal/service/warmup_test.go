@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/models"
+	"github.com/zhavkk/order-service/internal/repository/mocks"
+)
+
+func orderUIDs(n int) []string {
+	uids := make([]string, n)
+	for i := range uids {
+		uids[i] = fmt.Sprintf("order-%d", i+1)
+	}
+	return uids
+}
+
+// expectOrdersByIDs отдает заказы по запрошенным ID и запоминает размеры пачек.
+func expectOrdersByIDs(orderRepo *mocks.MockOrderRepository) *[]int {
+	var (
+		mu      sync.Mutex
+		batches []int
+	)
+	orderRepo.EXPECT().GetOrdersByIDs(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, uids []string) ([]*models.Order, error) {
+			mu.Lock()
+			batches = append(batches, len(uids))
+			mu.Unlock()
+			orders := make([]*models.Order, len(uids))
+			for i, uid := range uids {
+				orders[i] = &models.Order{OrderUID: uid}
+			}
+			return orders, nil
+		}).AnyTimes()
+	return &batches
+}
+
+func TestOrderService_WarmUpCache(t *testing.T) {
+	deps := newTestDeps(t)
+
+	deps.orderRepo.EXPECT().GetRecentOrderUIDs(gomock.Any(), 1000).Return(orderUIDs(3), nil)
+	deps.orderRepo.EXPECT().GetOrdersByIDs(gomock.Any(), orderUIDs(3)).Return([]*models.Order{
+		{OrderUID: "order-1"}, {OrderUID: "order-2"}, {OrderUID: "order-3"},
+	}, nil)
+	deps.cache.EXPECT().Set(gomock.Any(), "order:order-1", gomock.Any(), testCacheTTL).Return(nil)
+	deps.cache.EXPECT().Set(gomock.Any(), "order:order-2", gomock.Any(), testCacheTTL).Return(nil)
+	deps.cache.EXPECT().Set(gomock.Any(), "order:order-3", gomock.Any(), testCacheTTL).Return(nil)
+
+	err := deps.service.WarmUpCache(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, WarmUpProgress{Strategy: WarmUpRecent, Planned: 3, Cached: 3, Done: true, Ready: true}, deps.service.WarmUpProgress())
+}
+
+func TestOrderService_WarmUpCache_Batches(t *testing.T) {
+	deps := newTestDeps(t)
+	deps.service.SetWarmUpOptions(WarmUpOptions{
+		Strategy: WarmUpRecentDays, Limit: 250, Days: 3, Concurrency: 2, BatchSize: 100,
+	})
+
+	deps.orderRepo.EXPECT().GetOrderUIDsCreatedSince(gomock.Any(), gomock.Any(), 250).DoAndReturn(
+		func(_ context.Context, since time.Time, _ int) ([]string, error) {
+			assert.WithinDuration(t, time.Now().AddDate(0, 0, -3), since, time.Minute)
+			return orderUIDs(250), nil
+		})
+	batches := expectOrdersByIDs(deps.orderRepo)
+	deps.cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), testCacheTTL).Return(nil).Times(250)
+
+	require.NoError(t, deps.service.WarmUpCache(context.Background()))
+	assert.ElementsMatch(t, []int{100, 100, 50}, *batches)
+	assert.Equal(t, int64(250), deps.service.WarmUpProgress().Cached)
+}
+
+func TestOrderService_WarmUpCache_MostAccessed(t *testing.T) {
+	t.Run("access counts", func(t *testing.T) {
+		deps := newTestDeps(t)
+		deps.service.SetWarmUpOptions(WarmUpOptions{
+			Strategy: WarmUpMostAccessed, Limit: 10, Window: 6 * time.Hour,
+		})
+		deps.service.SetAccessTracker(NewAccessTracker(deps.accessRepo, time.Second, time.Hour, 0))
+
+		deps.accessRepo.EXPECT().GetMostAccessedOrderUIDs(gomock.Any(), gomock.Any(), 10).DoAndReturn(
+			func(_ context.Context, since time.Time, _ int) ([]string, error) {
+				assert.WithinDuration(t, time.Now().Add(-6*time.Hour), since, time.Hour)
+				return []string{"hot"}, nil
+			})
+		expectOrdersByIDs(deps.orderRepo)
+		deps.cache.EXPECT().Set(gomock.Any(), "order:hot", gomock.Any(), testCacheTTL).Return(nil)
+
+		require.NoError(t, deps.service.WarmUpCache(context.Background()))
+	})
+
+	t.Run("falls back to recent without counts", func(t *testing.T) {
+		deps := newTestDeps(t)
+		deps.service.SetWarmUpOptions(WarmUpOptions{Strategy: WarmUpMostAccessed, Limit: 10})
+		deps.service.SetAccessTracker(NewAccessTracker(deps.accessRepo, time.Second, time.Hour, 0))
+
+		deps.accessRepo.EXPECT().GetMostAccessedOrderUIDs(gomock.Any(), gomock.Any(), 10).Return(nil, nil)
+		deps.orderRepo.EXPECT().GetRecentOrderUIDs(gomock.Any(), 10).Return([]string{"recent"}, nil)
+		expectOrdersByIDs(deps.orderRepo)
+		deps.cache.EXPECT().Set(gomock.Any(), "order:recent", gomock.Any(), testCacheTTL).Return(nil)
+
+		require.NoError(t, deps.service.WarmUpCache(context.Background()))
+	})
+}
+
+func TestOrderService_WarmUpReady(t *testing.T) {
+	deps := newTestDeps(t)
+	deps.service.SetWarmUpOptions(WarmUpOptions{
+		Limit: 4, Concurrency: 1, BatchSize: 1, ReadyThreshold: 0.5,
+	})
+	assert.False(t, deps.service.WarmUpReady(), "not ready before warm-up starts")
+
+	deps.orderRepo.EXPECT().GetRecentOrderUIDs(gomock.Any(), 4).Return(orderUIDs(4), nil)
+	expectOrdersByIDs(deps.orderRepo)
+
+	var readiness []bool
+	deps.cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, string, any, time.Duration) error {
+			readiness = append(readiness, deps.service.WarmUpReady())
+			return nil
+		}).Times(3)
+	deps.cache.EXPECT().Set(gomock.Any(), "order:order-4", gomock.Any(), gomock.Any()).Return(errors.New("redis down"))
+
+	require.NoError(t, deps.service.WarmUpCache(context.Background()))
+	// Перед записью третьего заказа прогрета половина.
+	assert.Equal(t, []bool{false, false, true}, readiness)
+
+	progress := deps.service.WarmUpProgress()
+	assert.Equal(t, int64(3), progress.Cached)
+	assert.Equal(t, int64(1), progress.Failed)
+	assert.True(t, progress.Ready)
+}
+
+func TestOrderService_WarmUpReady_AfterFailure(t *testing.T) {
+	deps := newTestDeps(t)
+	deps.service.SetWarmUpOptions(WarmUpOptions{ReadyThreshold: 1})
+
+	deps.orderRepo.EXPECT().GetRecentOrderUIDs(gomock.Any(), 1000).Return(nil, errors.New("db down"))
+
+	assert.Error(t, deps.service.WarmUpCache(context.Background()))
+	assert.True(t, deps.service.WarmUpReady(), "failed warm-up must not keep the service unready")
+}
+
+func TestParseWarmUpStrategy(t *testing.T) {
+	st, err := ParseWarmUpStrategy("")
+	require.NoError(t, err)
+	assert.Equal(t, WarmUpRecent, st)
+
+	st, err = ParseWarmUpStrategy("most_accessed")
+	require.NoError(t, err)
+	assert.Equal(t, WarmUpMostAccessed, st)
+
+	_, err = ParseWarmUpStrategy("random")
+	assert.Error(t, err)
+}
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- date_created может быть NULL: листинг сортирует такие заказы последними.
+CREATE INDEX idx_orders_date_created ON orders(date_created DESC NULLS LAST, order_uid DESC);
+CREATE INDEX idx_orders_customer_id ON orders(customer_id, date_created DESC NULLS LAST, order_uid DESC);
+CREATE INDEX idx_orders_track_number ON orders(track_number);
+CREATE INDEX idx_orders_delivery_service ON orders(delivery_service, date_created DESC NULLS LAST);
+CREATE INDEX idx_payments_currency_provider ON payments(currency, provider);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_payments_currency_provider;
+DROP INDEX IF EXISTS idx_orders_delivery_service;
+DROP INDEX IF EXISTS idx_orders_track_number;
+DROP INDEX IF EXISTS idx_orders_customer_id;
+DROP INDEX IF EXISTS idx_orders_date_created;
+-- +goose StatementEnd
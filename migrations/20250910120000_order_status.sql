@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders ADD COLUMN status VARCHAR NOT NULL DEFAULT 'created';
+
+CREATE TABLE status_history (
+    id BIGSERIAL PRIMARY KEY,
+    order_uid VARCHAR NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+    from_status VARCHAR NOT NULL,
+    to_status VARCHAR NOT NULL,
+    changed_by VARCHAR NOT NULL,
+    reason VARCHAR,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_status_history_order_uid ON status_history(order_uid, changed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS status_history;
+ALTER TABLE orders DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
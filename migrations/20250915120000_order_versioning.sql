@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders
+    ADD COLUMN content_hash VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now();
+
+-- Повторные сообщения раньше создавали лишние строки доставки: оставляем последнюю.
+DELETE FROM delivery a
+ USING delivery b
+ WHERE a.order_uid = b.order_uid
+   AND a.delivery_id < b.delivery_id;
+
+DROP INDEX IF EXISTS idx_delivery_order_uid;
+CREATE UNIQUE INDEX idx_delivery_order_uid ON delivery(order_uid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_delivery_order_uid;
+CREATE INDEX idx_delivery_order_uid ON delivery(order_uid);
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS content_hash;
+-- +goose StatementEnd
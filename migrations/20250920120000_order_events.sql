@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE order_events (
+    id BIGSERIAL PRIMARY KEY,
+    order_uid VARCHAR NOT NULL,
+    event_type VARCHAR NOT NULL,
+    actor VARCHAR NOT NULL,
+    source VARCHAR NOT NULL DEFAULT '',
+    changes JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_order_events_order_uid ON order_events(order_uid, id);
+
+-- История только дописывается: изменение и удаление строк запрещены на уровне базы.
+CREATE FUNCTION order_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'order_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER order_events_append_only
+    BEFORE UPDATE OR DELETE ON order_events
+    FOR EACH ROW EXECUTE FUNCTION order_events_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_events;
+DROP FUNCTION IF EXISTS order_events_append_only();
+-- +goose StatementEnd
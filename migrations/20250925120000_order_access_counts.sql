@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Сколько раз заказ читали через API, по часам. Нужна для стратегии прогрева most_accessed.
+CREATE TABLE order_access_counts (
+    order_uid VARCHAR NOT NULL,
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    hits BIGINT NOT NULL,
+    PRIMARY KEY (order_uid, bucket)
+);
+
+CREATE INDEX idx_order_access_counts_bucket ON order_access_counts(bucket);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_access_counts;
+-- +goose StatementEnd
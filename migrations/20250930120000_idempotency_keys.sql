@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Ответы на запросы приема заказов по HTTP с заголовком Idempotency-Key: повтор запроса
+-- с тем же ключом получает сохраненный ответ.
+CREATE TABLE idempotency_keys (
+    key VARCHAR PRIMARY KEY,
+    request_hash VARCHAR NOT NULL,
+    response JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
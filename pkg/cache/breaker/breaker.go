@@ -0,0 +1,272 @@
+// Package cachebreaker — предохранитель перед общим кэшем. После серии ошибок кэш считается
+// недоступным: вызовы сразу завершаются ErrUnavailable, а фоновая проверка ждет, пока
+// хранилище снова ответит.
+package cachebreaker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/zhavkk/order-service/pkg/cache"
+	prometheusmetrics "github.com/zhavkk/order-service/pkg/metrics/prometheus"
+)
+
+var ErrUnavailable = errors.New("cache is unavailable")
+
+const (
+	defaultFailureThreshold = 5
+	defaultProbeInterval    = 5 * time.Second
+	defaultMaxPending       = 10000
+)
+
+type Options struct {
+	// FailureThreshold — сколько ошибок подряд переводят кэш в деградированный режим.
+	FailureThreshold int
+	// ProbeInterval — как часто проверять соединение в деградированном режиме.
+	ProbeInterval time.Duration
+	// MaxPending — сколько ключей, запись которых не удалась, помнить для удаления после восстановления.
+	MaxPending int
+}
+
+type Cache struct {
+	next  cache.Cache
+	probe func(ctx context.Context) error
+	opts  Options
+	log   *slog.Logger
+
+	mu       sync.Mutex
+	failures int
+	open     bool
+	// Ключи, которые могли устареть, пока кэш был недоступен. После восстановления
+	// они удаляются, чтобы не отдавать заказ в версии до обновления.
+	pending  map[string]struct{}
+	overflow bool
+
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+// New оборачивает next. probe проверяет соединение (например, PING в Redis).
+func New(next cache.Cache, probe func(ctx context.Context) error, opts Options, logger *slog.Logger) *Cache {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = defaultFailureThreshold
+	}
+	if opts.ProbeInterval <= 0 {
+		opts.ProbeInterval = defaultProbeInterval
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = defaultMaxPending
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Cache{
+		next:    next,
+		probe:   probe,
+		opts:    opts,
+		log:     logger,
+		pending: make(map[string]struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (c *Cache) Get(ctx context.Context, key string, destination any) error {
+	if c.Degraded() {
+		return ErrUnavailable
+	}
+	err := c.next.Get(ctx, key, destination)
+	c.record(ctx, err)
+	return err
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	if c.Degraded() {
+		c.remember(key)
+		return ErrUnavailable
+	}
+	err := c.next.Set(ctx, key, value, ttl)
+	if c.record(ctx, err) {
+		c.remember(key)
+	}
+	return err
+}
+
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if c.Degraded() {
+		c.remember(key)
+		return ErrUnavailable
+	}
+	err := c.next.Delete(ctx, key)
+	if c.record(ctx, err) {
+		c.remember(key)
+	}
+	return err
+}
+
+// DeleteByPattern при недоступном хранилище возвращает ErrUnavailable: запомнить ключи
+// по шаблону для удаления после восстановления нельзя.
+func (c *Cache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	if c.Degraded() {
+		return 0, ErrUnavailable
+	}
+	deleted, err := c.next.DeleteByPattern(ctx, pattern)
+	c.record(ctx, err)
+	return deleted, err
+}
+
+func (c *Cache) Stats(ctx context.Context) (cache.Stats, error) {
+	if c.Degraded() {
+		return cache.Stats{}, ErrUnavailable
+	}
+	stats, err := c.next.Stats(ctx)
+	c.record(ctx, err)
+	return stats, err
+}
+
+// Degraded сообщает, что кэш сейчас считается недоступным.
+func (c *Cache) Degraded() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.open
+}
+
+// record учитывает результат вызова и возвращает true, если это была ошибка хранилища.
+// Промах, нечитаемое значение и отмена запроса вызывающим ошибками хранилища не считаются.
+func (c *Cache) record(ctx context.Context, err error) bool {
+	if err == nil || errors.Is(err, cache.ErrCacheMiss) || errors.Is(err, cache.ErrInvalidValue) {
+		c.mu.Lock()
+		c.failures = 0
+		c.mu.Unlock()
+		return false
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures++
+	if !c.open && c.failures >= c.opts.FailureThreshold {
+		c.trip(err)
+	}
+	return true
+}
+
+// trip вызывается под mu.
+func (c *Cache) trip(err error) {
+	c.open = true
+	prometheusmetrics.CacheDegraded.Set(1)
+	c.log.Warn("cache is unavailable, switching to degraded mode", slog.Any("error", err))
+}
+
+func (c *Cache) remember(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.pending[key]; ok {
+		return
+	}
+	if len(c.pending) >= c.opts.MaxPending {
+		c.overflow = true
+		return
+	}
+	c.pending[key] = struct{}{}
+}
+
+// Run проверяет соединение сразу и затем раз в ProbeInterval: переводит кэш в деградированный
+// режим, если проверка не прошла, и возвращает из него, когда хранилище снова отвечает.
+// Возвращается после Close или отмены ctx.
+func (c *Cache) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stopped:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(c.opts.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		c.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Cache) check(ctx context.Context) {
+	probeCtx, cancel := context.WithTimeout(ctx, c.opts.ProbeInterval)
+	defer cancel()
+
+	err := c.probe(probeCtx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	c.mu.Lock()
+	if err != nil {
+		if !c.open {
+			c.trip(err)
+		}
+		c.mu.Unlock()
+		return
+	}
+	if !c.open {
+		c.mu.Unlock()
+		return
+	}
+	pending, overflow := c.pending, c.overflow
+	c.pending, c.overflow = make(map[string]struct{}), false
+	c.mu.Unlock()
+
+	if !c.flush(ctx, pending) {
+		// Остаемся в деградированном режиме: оставшиеся ключи удалятся при следующей проверке.
+		c.mu.Lock()
+		c.overflow = c.overflow || overflow
+		c.mu.Unlock()
+		return
+	}
+	if overflow {
+		c.log.Warn("some keys written while the cache was unavailable were not tracked and may be stale until their TTL expires")
+	}
+
+	c.mu.Lock()
+	c.open = false
+	c.failures = 0
+	c.mu.Unlock()
+	prometheusmetrics.CacheDegraded.Set(0)
+	c.log.Info("cache is available again", slog.Int("invalidated_keys", len(pending)))
+}
+
+func (c *Cache) Close() error {
+	c.stopOnce.Do(func() { close(c.stopped) })
+	return nil
+}
+
+// flush удаляет ключи, которые не удалось обновить, пока кэш был недоступен. Новые записи
+// в это время еще отклоняются, поэтому удаление не затрет свежие значения. Неудаленные
+// ключи возвращаются в очередь.
+func (c *Cache) flush(ctx context.Context, keys map[string]struct{}) bool {
+	ok := true
+	for key := range keys {
+		if !ok {
+			c.remember(key)
+			continue
+		}
+		if err := c.next.Delete(ctx, key); err != nil {
+			c.log.Warn("failed to invalidate stale cache key", slog.String("key", key), slog.Any("error", err))
+			c.remember(key)
+			ok = false
+		}
+	}
+	return ok
+}
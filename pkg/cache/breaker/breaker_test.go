@@ -0,0 +1,133 @@
+package cachebreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/pkg/cache"
+	lrucache "github.com/zhavkk/order-service/pkg/cache/lru"
+)
+
+var errConnRefused = errors.New("connection refused")
+
+// flakyCache имитирует Redis, который можно «выключить».
+type flakyCache struct {
+	*lrucache.Cache
+	down    atomic.Bool
+	invalid atomic.Bool
+	calls   atomic.Int32
+}
+
+func newFlakyCache() *flakyCache {
+	return &flakyCache{Cache: lrucache.New(10, time.Minute)}
+}
+
+func (f *flakyCache) Get(ctx context.Context, key string, destination any) error {
+	f.calls.Add(1)
+	if f.down.Load() {
+		return errConnRefused
+	}
+	if f.invalid.Load() {
+		return fmt.Errorf("%w: unknown format", cache.ErrInvalidValue)
+	}
+	return f.Cache.Get(ctx, key, destination)
+}
+
+func (f *flakyCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	f.calls.Add(1)
+	if f.down.Load() {
+		return errConnRefused
+	}
+	return f.Cache.Set(ctx, key, value, ttl)
+}
+
+func (f *flakyCache) Delete(ctx context.Context, key string) error {
+	f.calls.Add(1)
+	if f.down.Load() {
+		return errConnRefused
+	}
+	return f.Cache.Delete(ctx, key)
+}
+
+func (f *flakyCache) ping(context.Context) error {
+	if f.down.Load() {
+		return errConnRefused
+	}
+	return nil
+}
+
+func TestCache_TripsAfterConsecutiveFailures(t *testing.T) {
+	ctx := context.Background()
+	remote := newFlakyCache()
+	c := New(remote, remote.ping, Options{FailureThreshold: 3}, nil)
+
+	var v int
+	require.ErrorIs(t, c.Get(ctx, "missing", &v), cache.ErrCacheMiss)
+	assert.False(t, c.Degraded(), "cache miss is not a failure")
+
+	remote.down.Store(true)
+	for range 3 {
+		assert.ErrorIs(t, c.Get(ctx, "a", &v), errConnRefused)
+	}
+	require.True(t, c.Degraded())
+
+	calls := remote.calls.Load()
+	assert.ErrorIs(t, c.Get(ctx, "a", &v), ErrUnavailable)
+	assert.Equal(t, calls, remote.calls.Load(), "degraded cache must not reach the store")
+}
+
+func TestCache_InvalidValueIsNotFailure(t *testing.T) {
+	remote := newFlakyCache()
+	remote.invalid.Store(true)
+	c := New(remote, remote.ping, Options{FailureThreshold: 1}, nil)
+
+	var v int
+	assert.ErrorIs(t, c.Get(context.Background(), "a", &v), cache.ErrInvalidValue)
+	assert.False(t, c.Degraded())
+}
+
+func TestCache_RecoversAndInvalidatesStaleKeys(t *testing.T) {
+	ctx := context.Background()
+	remote := newFlakyCache()
+	c := New(remote, remote.ping, Options{FailureThreshold: 1}, nil)
+	require.NoError(t, c.Set(ctx, "order:1", 1, time.Minute))
+
+	remote.down.Store(true)
+	c.check(ctx)
+	require.True(t, c.Degraded())
+
+	// Обновление, которое не дошло до Redis: старое значение нельзя отдавать после восстановления.
+	assert.ErrorIs(t, c.Set(ctx, "order:1", 2, time.Minute), ErrUnavailable)
+
+	c.check(ctx)
+	assert.True(t, c.Degraded(), "probe still fails")
+
+	remote.down.Store(false)
+	c.check(ctx)
+	require.False(t, c.Degraded())
+
+	var v int
+	assert.ErrorIs(t, c.Get(ctx, "order:1", &v), cache.ErrCacheMiss)
+}
+
+func TestCache_RunStopsOnClose(t *testing.T) {
+	remote := newFlakyCache()
+	c := New(remote, remote.ping, Options{ProbeInterval: 10 * time.Millisecond}, nil)
+
+	done := make(chan error)
+	go func() { done <- c.Run(context.Background()) }()
+	require.NoError(t, c.Close())
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop after Close")
+	}
+}
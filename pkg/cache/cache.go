@@ -8,10 +8,38 @@ import (
 
 var (
 	ErrCacheMiss = errors.New("cache miss")
+	// ErrInvalidValue — значение в кэше есть, но его не удалось прочитать.
+	ErrInvalidValue = errors.New("invalid cache value")
 )
 
 type Cache interface {
 	Get(ctx context.Context, key string, destination any) error
 	Set(ctx context.Context, key string, value any, ttl time.Duration) error
 	Delete(ctx context.Context, key string) error
+	// DeleteByPattern удаляет ключи по glob-шаблону в синтаксисе Redis (*, ?, [...])
+	// и возвращает число удаленных.
+	DeleteByPattern(ctx context.Context, pattern string) (int64, error)
+	Stats(ctx context.Context) (Stats, error)
+}
+
+// Stats — состояние хранилища кэша. Memory и счетчики попаданий, которые хранилище
+// не ведет, остаются нулевыми.
+type Stats struct {
+	Keys        int64 `json:"keys"`
+	MemoryBytes int64 `json:"memory_bytes"`
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	// Local — локальный уровень многоуровневого кэша.
+	Local *Stats `json:"local,omitempty"`
+	// RemoteError — почему не удалось получить статистику общего кэша многоуровневого
+	// кэша. Остальные поля тогда нулевые, Local заполнен.
+	RemoteError string `json:"remote_error,omitempty"`
+}
+
+// HitRatio — доля попаданий среди всех чтений; 0, если чтений не было.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
 }
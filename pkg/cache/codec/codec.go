@@ -0,0 +1,132 @@
+// Package codec — форматы хранения значений в общем кэше. Значение записывается с
+// заголовком (версия формата, кодек, сжатие), поэтому кодек и сжатие можно менять без
+// очистки Redis: старые записи читаются тем кодеком, которым были записаны.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	NameJSON    = "json"
+	NameMsgPack = "msgpack"
+	NameBinary  = "binary"
+)
+
+// Идентификаторы кодеков в заголовке значения. Менять нельзя: по ним читаются уже записанные значения.
+const (
+	idJSON    byte = 1
+	idMsgPack byte = 2
+	idBinary  byte = 3
+)
+
+var (
+	ErrUnknownCodec = errors.New("unknown cache codec")
+	// ErrUnsupported — значение нельзя закодировать этим кодеком; Format тогда пишет его в JSON.
+	ErrUnsupported = errors.New("value is not supported by codec")
+)
+
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+
+	id() byte
+}
+
+func ByName(name string) (Codec, error) {
+	switch name {
+	case "", NameJSON:
+		return JSON{}, nil
+	case NameMsgPack:
+		return MsgPack{}, nil
+	case NameBinary:
+		return Binary{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCodec, name)
+	}
+}
+
+func byID(id byte) (Codec, error) {
+	switch id {
+	case idJSON:
+		return JSON{}, nil
+	case idMsgPack:
+		return MsgPack{}, nil
+	case idBinary:
+		return Binary{}, nil
+	default:
+		return nil, fmt.Errorf("%w: id %d", ErrUnknownCodec, id)
+	}
+}
+
+type JSON struct{}
+
+func (JSON) Name() string                       { return NameJSON }
+func (JSON) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (JSON) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (JSON) id() byte                           { return idJSON }
+
+// MsgPack использует json-теги, чтобы поля назывались так же, как в JSON.
+type MsgPack struct{}
+
+func (MsgPack) Name() string { return NameMsgPack }
+
+func (MsgPack) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (MsgPack) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+func (MsgPack) id() byte { return idMsgPack }
+
+// BinaryEncoder и BinaryDecoder реализуют типы, которые умеют записываться кодеком Binary.
+// Свои интерфейсы вместо encoding.BinaryMarshaler: его использует и msgpack, и тогда
+// MsgPack незаметно писал бы тот же бинарный формат.
+type BinaryEncoder interface {
+	EncodeBinary(w *Writer)
+}
+
+type BinaryDecoder interface {
+	DecodeBinary(r *Reader) error
+}
+
+// Binary — компактный формат в духе protobuf (см. Writer и Reader).
+type Binary struct{}
+
+func (Binary) Name() string { return NameBinary }
+
+func (Binary) Marshal(v any) ([]byte, error) {
+	e, ok := v.(BinaryEncoder)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupported, v)
+	}
+	var w Writer
+	e.EncodeBinary(&w)
+	return w.Bytes(), nil
+}
+
+func (Binary) Unmarshal(data []byte, v any) error {
+	d, ok := v.(BinaryDecoder)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrUnsupported, v)
+	}
+	return d.DecodeBinary(NewReader(data))
+}
+
+func (Binary) id() byte { return idBinary }
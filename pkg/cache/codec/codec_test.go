@@ -0,0 +1,178 @@
+package codec
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sample struct {
+	Name    string    `json:"name"`
+	Count   int64     `json:"count"`
+	Tags    []string  `json:"tags"`
+	Created time.Time `json:"created"`
+}
+
+func (s sample) EncodeBinary(w *Writer) {
+	w.String(1, s.Name)
+	w.Int(2, s.Count)
+	for _, tag := range s.Tags {
+		w.String(3, tag)
+	}
+	w.Time(4, s.Created)
+}
+
+func (s *sample) DecodeBinary(r *Reader) error {
+	*s = sample{}
+	for r.Next() {
+		switch r.Field() {
+		case 1:
+			s.Name = r.String()
+		case 2:
+			s.Count = r.Int()
+		case 3:
+			s.Tags = append(s.Tags, r.String())
+		case 4:
+			s.Created = r.Time()
+		}
+	}
+	return r.Err()
+}
+
+func newSample() sample {
+	return sample{
+		Name:    strings.Repeat("order ", 50),
+		Count:   -42,
+		Tags:    []string{"a", "b", "c"},
+		Created: time.Date(2025, 9, 1, 12, 30, 0, 123, time.UTC),
+	}
+}
+
+// assertSample сравнивает время как момент: msgpack возвращает его в локальной зоне.
+func assertSample(t *testing.T, got sample) {
+	t.Helper()
+	want := newSample()
+	assert.True(t, want.Created.Equal(got.Created), "created: want %s, got %s", want.Created, got.Created)
+	got.Created = want.Created
+	assert.Equal(t, want, got)
+}
+
+func TestFormat_RoundTrip(t *testing.T) {
+	for _, codecName := range []string{NameJSON, NameMsgPack, NameBinary} {
+		for _, compression := range []string{CompressionNone, CompressionZstd, CompressionSnappy} {
+			t.Run(codecName+"/"+compression, func(t *testing.T) {
+				f, err := NewFormat(codecName, compression, 64)
+				require.NoError(t, err)
+
+				data, err := f.Encode(newSample())
+				require.NoError(t, err)
+				if codecName == NameJSON && compression == CompressionNone {
+					assert.Equal(t, byte('{'), data[0])
+				} else {
+					assert.Equal(t, formatV1, data[0])
+				}
+
+				var got sample
+				require.NoError(t, f.Decode(data, &got))
+				assertSample(t, got)
+			})
+		}
+	}
+}
+
+func TestFormat_CompressionThreshold(t *testing.T) {
+	f, err := NewFormat(NameJSON, CompressionZstd, 1024)
+	require.NoError(t, err)
+
+	small, err := f.Encode(sample{Name: "small"})
+	require.NoError(t, err)
+	assert.Equal(t, compressionNone, small[2])
+
+	large, err := f.Encode(sample{Name: strings.Repeat("x", 2048)})
+	require.NoError(t, err)
+	assert.Equal(t, compressionZstd, large[2])
+	assert.Less(t, len(large), 2048)
+}
+
+func TestFormat_ReadsValuesWrittenByOtherFormats(t *testing.T) {
+	current, err := NewFormat(NameBinary, CompressionSnappy, 0)
+	require.NoError(t, err)
+
+	// Значение без заголовка, записанное до появления кодеков.
+	legacy, err := json.Marshal(newSample())
+	require.NoError(t, err)
+	var got sample
+	require.NoError(t, current.Decode(legacy, &got))
+	assertSample(t, got)
+
+	previous, err := NewFormat(NameMsgPack, CompressionZstd, 0)
+	require.NoError(t, err)
+	data, err := previous.Encode(newSample())
+	require.NoError(t, err)
+	got = sample{}
+	require.NoError(t, current.Decode(data, &got))
+	assertSample(t, got)
+}
+
+func TestFormat_JSONReadableByPreviousVersions(t *testing.T) {
+	f, err := NewFormat(NameJSON, CompressionNone, 0)
+	require.NoError(t, err)
+
+	data, err := f.Encode(newSample())
+	require.NoError(t, err)
+
+	var got sample
+	require.NoError(t, json.Unmarshal(data, &got))
+	assertSample(t, got)
+}
+
+func TestFormat_BinaryFallsBackToJSON(t *testing.T) {
+	f, err := NewFormat(NameBinary, CompressionNone, 0)
+	require.NoError(t, err)
+
+	data, err := f.Encode(true)
+	require.NoError(t, err)
+	assert.Equal(t, idJSON, data[1])
+
+	var got bool
+	require.NoError(t, f.Decode(data, &got))
+	assert.True(t, got)
+}
+
+func TestNewFormat_Unknown(t *testing.T) {
+	_, err := NewFormat("protobuf", CompressionNone, 0)
+	assert.ErrorIs(t, err, ErrUnknownCodec)
+
+	_, err = NewFormat(NameJSON, "lz4", 0)
+	assert.ErrorIs(t, err, ErrUnknownCompression)
+}
+
+func TestReader_Malformed(t *testing.T) {
+	var w Writer
+	w.String(1, "hello")
+	data := w.Bytes()
+
+	var got sample
+	assert.ErrorIs(t, got.DecodeBinary(NewReader(data[:len(data)-1])), ErrMalformed)
+
+	// Поле с тем же номером, но другим типом.
+	w = Writer{}
+	w.Int(1, 5)
+	assert.ErrorIs(t, got.DecodeBinary(NewReader(w.Bytes())), ErrMalformed)
+}
+
+func TestReader_SkipsUnknownFields(t *testing.T) {
+	var w Writer
+	w.String(1, "name")
+	w.String(99, "added later")
+	w.Int(100, 7)
+	w.Int(2, 3)
+
+	var got sample
+	require.NoError(t, got.DecodeBinary(NewReader(w.Bytes())))
+	assert.Equal(t, sample{Name: "name", Count: 3}, got)
+}
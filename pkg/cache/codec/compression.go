@@ -0,0 +1,78 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	CompressionNone   = "none"
+	CompressionZstd   = "zstd"
+	CompressionSnappy = "snappy"
+)
+
+const (
+	compressionNone   byte = 0
+	compressionZstd   byte = 1
+	compressionSnappy byte = 2
+)
+
+var ErrUnknownCompression = errors.New("unknown cache compression")
+
+func compressionByName(name string) (byte, error) {
+	switch name {
+	case "", CompressionNone:
+		return compressionNone, nil
+	case CompressionZstd:
+		return compressionZstd, nil
+	case CompressionSnappy:
+		return compressionSnappy, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCompression, name)
+	}
+}
+
+// EncodeAll и DecodeAll безопасны для конкурентного использования, поэтому кодировщики общие.
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+func zstdCodecs() (*zstd.Encoder, *zstd.Decoder) {
+	zstdOnce.Do(func() {
+		zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+		zstdDecoder, _ = zstd.NewReader(nil)
+	})
+	return zstdEncoder, zstdDecoder
+}
+
+func compress(method byte, dst, src []byte) []byte {
+	switch method {
+	case compressionZstd:
+		enc, _ := zstdCodecs()
+		return enc.EncodeAll(src, dst)
+	case compressionSnappy:
+		return append(dst, snappy.Encode(nil, src)...)
+	default:
+		return append(dst, src...)
+	}
+}
+
+func decompress(method byte, src []byte) ([]byte, error) {
+	switch method {
+	case compressionNone:
+		return src, nil
+	case compressionZstd:
+		_, dec := zstdCodecs()
+		return dec.DecodeAll(src, nil)
+	case compressionSnappy:
+		return snappy.Decode(nil, src)
+	default:
+		return nil, fmt.Errorf("%w: id %d", ErrUnknownCompression, method)
+	}
+}
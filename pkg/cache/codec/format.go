@@ -0,0 +1,84 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+)
+
+// formatV1 — первый байт значения с заголовком. Валидный JSON так начинаться не может,
+// поэтому значения без заголовка (записанные до появления кодеков) читаются как JSON.
+const formatV1 byte = 0x01
+
+const headerSize = 3
+
+// Format кодирует значения для кэша: [formatV1, кодек, сжатие] + данные. Сжимаются
+// только данные длиннее Threshold байт. JSON без сжатия пишется без заголовка, как до
+// появления кодеков, чтобы его читали и инстансы предыдущих версий.
+type Format struct {
+	codec       Codec
+	compression byte
+	threshold   int
+}
+
+// NewFormat собирает формат по именам из конфига. Пустые имена — JSON без сжатия.
+func NewFormat(codecName, compressionName string, threshold int) (*Format, error) {
+	c, err := ByName(codecName)
+	if err != nil {
+		return nil, err
+	}
+	compression, err := compressionByName(compressionName)
+	if err != nil {
+		return nil, err
+	}
+	return &Format{codec: c, compression: compression, threshold: threshold}, nil
+}
+
+func (f *Format) Encode(v any) ([]byte, error) {
+	c := f.codec
+	data, err := c.Marshal(v)
+	if errors.Is(err, ErrUnsupported) {
+		c = JSON{}
+		data, err = c.Marshal(v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value with %s: %w", c.Name(), err)
+	}
+	if f.legacyJSON() {
+		return data, nil
+	}
+
+	compression := compressionNone
+	if f.compression != compressionNone && len(data) > f.threshold {
+		compression = f.compression
+	}
+
+	out := make([]byte, headerSize, headerSize+len(data))
+	out[0], out[1], out[2] = formatV1, c.id(), compression
+	return compress(compression, out, data), nil
+}
+
+func (f *Format) legacyJSON() bool {
+	_, isJSON := f.codec.(JSON)
+	return isJSON && f.compression == compressionNone
+}
+
+// Decode читает значение тем кодеком и сжатием, которые указаны в его заголовке, а не
+// текущими настройками формата.
+func (f *Format) Decode(data []byte, v any) error {
+	if len(data) == 0 || data[0] != formatV1 {
+		return JSON{}.Unmarshal(data, v)
+	}
+	if len(data) < headerSize {
+		return fmt.Errorf("cached value is too short: %d bytes", len(data))
+	}
+
+	c, err := byID(data[1])
+	if err != nil {
+		return err
+	}
+	payload, err := decompress(data[2], data[headerSize:])
+	if err != nil {
+		return fmt.Errorf("failed to decompress cached value: %w", err)
+	}
+	return c.Unmarshal(payload, v)
+}
@@ -0,0 +1,183 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// Формат Binary устроен как protobuf: каждое поле — тег (номер поля и тип) и значение.
+// Целые числа пишутся varint (знаковые — zigzag), строки и вложенные сообщения — длиной
+// и байтами. Нулевые значения не пишутся; неизвестные поля при чтении пропускаются, поэтому
+// поля можно добавлять, не меняя версию формата.
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+var ErrMalformed = errors.New("malformed binary value")
+
+type Writer struct {
+	buf []byte
+}
+
+func (w *Writer) Bytes() []byte {
+	return w.buf
+}
+
+func (w *Writer) tag(field, wireType int) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(field)<<3|uint64(wireType))
+}
+
+func (w *Writer) Uint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	w.tag(field, wireVarint)
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *Writer) Int(field int, v int64) {
+	if v == 0 {
+		return
+	}
+	w.tag(field, wireVarint)
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+func (w *Writer) String(field int, s string) {
+	if s == "" {
+		return
+	}
+	w.tag(field, wireBytes)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// Time пишет время с точностью до наносекунды в UTC.
+func (w *Writer) Time(field int, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	w.Int(field, t.UnixNano())
+}
+
+// Message пишет вложенное сообщение. Пустое сообщение тоже пишется, чтобы элементы
+// повторяющегося поля не терялись.
+func (w *Writer) Message(field int, fn func(w *Writer)) {
+	var nested Writer
+	fn(&nested)
+	w.tag(field, wireBytes)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(nested.buf)))
+	w.buf = append(w.buf, nested.buf...)
+}
+
+// Reader читает поля по одному:
+//
+//	for r.Next() {
+//		switch r.Field() {
+//		case 1:
+//			v.Name = r.String()
+//		}
+//	}
+//	return r.Err()
+type Reader struct {
+	data     []byte
+	field    int
+	wireType int
+	value    uint64
+	payload  []byte
+	err      error
+}
+
+func NewReader(data []byte) *Reader {
+	return &Reader{data: data}
+}
+
+func (r *Reader) Next() bool {
+	if r.err != nil || len(r.data) == 0 {
+		return false
+	}
+	tag, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = ErrMalformed
+		return false
+	}
+	r.data = r.data[n:]
+	r.field, r.wireType = int(tag>>3), int(tag&7)
+
+	switch r.wireType {
+	case wireVarint:
+		r.value, n = binary.Uvarint(r.data)
+		if n <= 0 {
+			r.err = ErrMalformed
+			return false
+		}
+		r.data = r.data[n:]
+	case wireBytes:
+		size, n := binary.Uvarint(r.data)
+		if n <= 0 || uint64(len(r.data)-n) < size {
+			r.err = ErrMalformed
+			return false
+		}
+		r.payload = r.data[n : n+int(size)]
+		r.data = r.data[n+int(size):]
+	default:
+		r.err = ErrMalformed
+		return false
+	}
+	return true
+}
+
+func (r *Reader) Field() int {
+	return r.field
+}
+
+func (r *Reader) Err() error {
+	return r.err
+}
+
+func (r *Reader) expect(wireType int) bool {
+	if r.wireType != wireType {
+		r.err = ErrMalformed
+		return false
+	}
+	return true
+}
+
+func (r *Reader) Uint() uint64 {
+	if !r.expect(wireVarint) {
+		return 0
+	}
+	return r.value
+}
+
+func (r *Reader) Int() int64 {
+	if !r.expect(wireVarint) {
+		return 0
+	}
+	// Обратное к binary.AppendVarint (zigzag).
+	return int64(r.value>>1) ^ -int64(r.value&1)
+}
+
+func (r *Reader) String() string {
+	if !r.expect(wireBytes) {
+		return ""
+	}
+	return string(r.payload)
+}
+
+func (r *Reader) Time() time.Time {
+	return time.Unix(0, r.Int()).UTC()
+}
+
+// Message читает вложенное сообщение через fn. Ошибка вложенного чтения становится ошибкой r.
+func (r *Reader) Message(fn func(r *Reader) error) {
+	if !r.expect(wireBytes) {
+		return
+	}
+	if err := fn(NewReader(r.payload)); err != nil {
+		r.err = err
+	}
+}
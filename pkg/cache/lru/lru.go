@@ -0,0 +1,174 @@
+// Package lrucache — ограниченный по размеру кэш в памяти процесса с TTL и вытеснением
+// давно не использованных ключей.
+package lrucache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/zhavkk/order-service/pkg/cache"
+)
+
+var ErrInvalidDestination = errors.New("destination must be a non-nil pointer")
+
+// Cache хранит значения как есть, без сериализации. Get отдает неглубокую копию
+// сохраненного значения, поэтому вложенные слайсы и указатели нельзя изменять.
+type Cache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	ll    *list.List
+	items map[string]*list.Element
+	now   func() time.Time
+
+	hits   int64
+	misses int64
+}
+
+type entry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+func New(size int, ttl time.Duration) *Cache {
+	return &Cache{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+		now:   time.Now,
+	}
+}
+
+func (c *Cache) Get(_ context.Context, key string, destination any) error {
+	value, ok := c.get(key)
+	if !ok {
+		return cache.ErrCacheMiss
+	}
+	return assign(destination, value)
+}
+
+func (c *Cache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expiresAt) {
+		c.removeElement(el)
+		c.misses++
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	c.hits++
+	return e.value, true
+}
+
+// Set сохраняет значение на min(ttl, TTL кэша). Для указателя сохраняется копия
+// значения, на которое он указывает.
+func (c *Cache) Set(_ context.Context, key string, value any, ttl time.Duration) error {
+	if ttl <= 0 || ttl > c.ttl {
+		ttl = c.ttl
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer && !v.IsNil() {
+		value = v.Elem().Interface()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := &entry{key: key, value: value, expiresAt: c.now().Add(ttl)}
+	if el, ok := c.items[key]; ok {
+		el.Value = e
+		c.ll.MoveToFront(el)
+		return nil
+	}
+	c.items[key] = c.ll.PushFront(e)
+	for c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+	}
+	return nil
+}
+
+func (c *Cache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+	return nil
+}
+
+// DeleteByPattern удаляет ключи, подходящие под шаблон path.Match. В отличие от Redis,
+// * не захватывает «/», но в ключах заказов его нет.
+func (c *Cache) DeleteByPattern(_ context.Context, pattern string) (int64, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var deleted int64
+	for key, el := range c.items {
+		if ok, _ := path.Match(pattern, key); ok {
+			c.removeElement(el)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Stats возвращает число ключей (включая истекшие, но еще не вытесненные) и попадания
+// с момента создания кэша.
+func (c *Cache) Stats(context.Context) (cache.Stats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return cache.Stats{Keys: int64(c.ll.Len()), Hits: c.hits, Misses: c.misses}, nil
+}
+
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *Cache) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
+
+// assign копирует value в destination без сериализации, если типы совместимы,
+// иначе через JSON.
+func assign(destination, value any) error {
+	dst := reflect.ValueOf(destination)
+	if dst.Kind() != reflect.Pointer || dst.IsNil() {
+		return ErrInvalidDestination
+	}
+	target := dst.Elem()
+
+	v := reflect.ValueOf(value)
+	if v.IsValid() && v.Type().AssignableTo(target.Type()) {
+		target.Set(v)
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cached value: %w", err)
+	}
+	return json.Unmarshal(data, destination)
+}
@@ -0,0 +1,95 @@
+package lrucache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/pkg/cache"
+)
+
+type order struct {
+	ID    string
+	Items []string
+}
+
+func TestCache_GetSet(t *testing.T) {
+	ctx := context.Background()
+	c := New(2, time.Minute)
+
+	src := &order{ID: "a", Items: []string{"x"}}
+	require.NoError(t, c.Set(ctx, "a", src, time.Minute))
+	src.ID = "changed"
+
+	var got order
+	require.NoError(t, c.Get(ctx, "a", &got))
+	assert.Equal(t, order{ID: "a", Items: []string{"x"}}, got)
+
+	var other struct{ ID string }
+	require.NoError(t, c.Get(ctx, "a", &other), "incompatible destination falls back to JSON")
+	assert.Equal(t, "a", other.ID)
+
+	assert.ErrorIs(t, c.Get(ctx, "missing", &got), cache.ErrCacheMiss)
+	assert.ErrorIs(t, c.Get(ctx, "a", got), ErrInvalidDestination)
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := New(2, time.Minute)
+
+	require.NoError(t, c.Set(ctx, "a", 1, 0))
+	require.NoError(t, c.Set(ctx, "b", 2, 0))
+	var v int
+	require.NoError(t, c.Get(ctx, "a", &v))
+	require.NoError(t, c.Set(ctx, "c", 3, 0))
+
+	assert.Equal(t, 2, c.Len())
+	assert.ErrorIs(t, c.Get(ctx, "b", &v), cache.ErrCacheMiss)
+	assert.NoError(t, c.Get(ctx, "a", &v))
+	assert.NoError(t, c.Get(ctx, "c", &v))
+}
+
+func TestCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := New(10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "short", 1, time.Second))
+	require.NoError(t, c.Set(ctx, "capped", 2, time.Hour))
+
+	now = now.Add(2 * time.Second)
+	var v int
+	assert.ErrorIs(t, c.Get(ctx, "short", &v), cache.ErrCacheMiss)
+	assert.NoError(t, c.Get(ctx, "capped", &v))
+
+	now = now.Add(time.Minute)
+	assert.ErrorIs(t, c.Get(ctx, "capped", &v), cache.ErrCacheMiss)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCache_DeleteByPatternAndStats(t *testing.T) {
+	ctx := context.Background()
+	c := New(10, time.Minute)
+
+	for _, key := range []string{"order:1", "order:2", "order:missing:3"} {
+		require.NoError(t, c.Set(ctx, key, 1, 0))
+	}
+	var v int
+	require.NoError(t, c.Get(ctx, "order:1", &v))
+	require.ErrorIs(t, c.Get(ctx, "order:4", &v), cache.ErrCacheMiss)
+
+	deleted, err := c.DeleteByPattern(ctx, "order:missing:*")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = c.DeleteByPattern(ctx, "order:[")
+	assert.Error(t, err)
+
+	stats, err := c.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, cache.Stats{Keys: 2, Hits: 1, Misses: 1}, stats)
+	assert.InDelta(t, 0.5, stats.HitRatio(), 1e-9)
+}
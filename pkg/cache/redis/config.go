@@ -0,0 +1,150 @@
+package rediscache
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Режимы подключения к Redis.
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+// Options — параметры подключения к Redis в любом из режимов.
+type Options struct {
+	// Mode — ModeStandalone (по умолчанию), ModeSentinel или ModeCluster.
+	Mode string
+	// Addrs — адрес Redis, адреса sentinel-ов или узлов кластера.
+	Addrs      []string
+	MasterName string
+	DB         int
+	// Пользователь ACL и пароль Redis; для sentinel-ов — отдельные, если они заданы.
+	Username         string
+	Password         string
+	SentinelUsername string
+	SentinelPassword string
+
+	TLS TLSOptions
+	// Нулевые значения — значения go-redis по умолчанию.
+	PoolSize     int
+	MinIdleConns int
+	PoolTimeout  time.Duration
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+type TLSOptions struct {
+	Enabled    bool
+	ServerName string
+	// CA для проверки сертификата сервера; пусто — системные корневые сертификаты.
+	CAFile string
+	// Клиентский сертификат для mTLS.
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// NewUniversalClient создает клиента для режима из opts: одиночный Redis, Sentinel
+// (клиент сам переключается на нового мастера) или Cluster.
+func NewUniversalClient(conf Options) (redis.UniversalClient, error) {
+	opts, err := newUniversalOptions(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	switch conf.Mode {
+	case ModeSentinel:
+		return redis.NewFailoverClient(opts.Failover()), nil
+	case ModeCluster:
+		return redis.NewClusterClient(opts.Cluster()), nil
+	default:
+		return redis.NewClient(opts.Simple()), nil
+	}
+}
+
+func newUniversalOptions(conf Options) (*redis.UniversalOptions, error) {
+	opts := &redis.UniversalOptions{
+		Addrs:            conf.Addrs,
+		DB:               conf.DB,
+		Username:         conf.Username,
+		Password:         conf.Password,
+		SentinelUsername: conf.SentinelUsername,
+		SentinelPassword: conf.SentinelPassword,
+		PoolSize:         conf.PoolSize,
+		MinIdleConns:     conf.MinIdleConns,
+		PoolTimeout:      conf.PoolTimeout,
+		DialTimeout:      conf.DialTimeout,
+		ReadTimeout:      conf.ReadTimeout,
+		WriteTimeout:     conf.WriteTimeout,
+	}
+
+	switch conf.Mode {
+	case ModeStandalone, "":
+		if len(conf.Addrs) == 0 {
+			return nil, errors.New("redis standalone mode requires an address")
+		}
+	case ModeSentinel:
+		if conf.MasterName == "" || len(conf.Addrs) == 0 {
+			return nil, errors.New("redis sentinel mode requires master_name and addrs")
+		}
+		opts.MasterName = conf.MasterName
+	case ModeCluster:
+		if len(conf.Addrs) == 0 {
+			return nil, errors.New("redis cluster mode requires addrs")
+		}
+		if conf.DB != 0 {
+			return nil, errors.New("redis cluster supports only db 0")
+		}
+	default:
+		return nil, fmt.Errorf("unknown redis mode: %q", conf.Mode)
+	}
+
+	if conf.TLS.Enabled {
+		tlsConfig, err := newTLSConfig(conf.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	return opts, nil
+}
+
+func newTLSConfig(conf TLSOptions) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         conf.ServerName,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+	}
+
+	if conf.CAFile != "" {
+		pem, err := os.ReadFile(conf.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in redis CA file %s", conf.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if conf.CertFile != "" || conf.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,109 @@
+package rediscache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUniversalClient_Modes(t *testing.T) {
+	tests := []struct {
+		name string
+		conf Options
+		want redis.UniversalClient
+	}{
+		{
+			name: "standalone",
+			conf: Options{Addrs: []string{"localhost:6379"}},
+			want: &redis.Client{},
+		},
+		{
+			name: "sentinel",
+			conf: Options{
+				Mode: ModeSentinel, MasterName: "mymaster",
+				Addrs: []string{"sentinel-1:26379", "sentinel-2:26379"},
+			},
+			want: &redis.Client{},
+		},
+		{
+			name: "cluster",
+			conf: Options{Mode: ModeCluster, Addrs: []string{"node-1:6379", "node-2:6379"}},
+			want: &redis.ClusterClient{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewUniversalClient(tt.conf)
+			require.NoError(t, err)
+			defer client.Close()
+			assert.IsType(t, tt.want, client)
+		})
+	}
+}
+
+func TestNewUniversalOptions(t *testing.T) {
+	opts, err := newUniversalOptions(Options{
+		Addrs: []string{"redis:6380"}, DB: 2,
+		Username: "orders", Password: "secret",
+		PoolSize: 50, MinIdleConns: 5,
+		PoolTimeout: time.Second, DialTimeout: 2 * time.Second,
+		ReadTimeout: 300 * time.Millisecond, WriteTimeout: 400 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	simple := opts.Simple()
+	assert.Equal(t, "redis:6380", simple.Addr)
+	assert.Equal(t, 2, simple.DB)
+	assert.Equal(t, "orders", simple.Username)
+	assert.Equal(t, "secret", simple.Password)
+	assert.Equal(t, 50, simple.PoolSize)
+	assert.Equal(t, 5, simple.MinIdleConns)
+	assert.Equal(t, time.Second, simple.PoolTimeout)
+	assert.Equal(t, 2*time.Second, simple.DialTimeout)
+	assert.Equal(t, 300*time.Millisecond, simple.ReadTimeout)
+	assert.Equal(t, 400*time.Millisecond, simple.WriteTimeout)
+	assert.Nil(t, simple.TLSConfig)
+}
+
+func TestNewUniversalOptions_Invalid(t *testing.T) {
+	for name, conf := range map[string]Options{
+		"unknown mode":            {Mode: "replica", Addrs: []string{"redis:6379"}},
+		"standalone without addr": {},
+		"sentinel without addrs":  {Mode: ModeSentinel, MasterName: "mymaster"},
+		"sentinel without name":   {Mode: ModeSentinel, Addrs: []string{"sentinel:26379"}},
+		"cluster without addrs":   {Mode: ModeCluster},
+		"cluster with db":         {Mode: ModeCluster, Addrs: []string{"node:6379"}, DB: 1},
+		"missing CA file": {
+			Addrs: []string{"redis:6379"},
+			TLS:   TLSOptions{Enabled: true, CAFile: filepath.Join(t.TempDir(), "ca.pem")},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newUniversalOptions(conf)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewUniversalOptions_TLS(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+	_, err := newUniversalOptions(Options{
+		Addrs: []string{"redis:6379"},
+		TLS:   TLSOptions{Enabled: true, CAFile: caFile},
+	})
+	assert.Error(t, err)
+
+	opts, err := newUniversalOptions(Options{
+		Mode: ModeCluster, Addrs: []string{"node:6379"},
+		TLS: TLSOptions{Enabled: true, ServerName: "redis.internal"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, opts.TLSConfig)
+	assert.Equal(t, "redis.internal", opts.Cluster().TLSConfig.ServerName)
+}
@@ -2,41 +2,61 @@ package rediscache
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/zhavkk/order-service/pkg/cache"
+	"github.com/zhavkk/order-service/pkg/cache/codec"
 )
 
+// deleteScanCount — подсказка COUNT для SCAN при удалении по шаблону.
+const deleteScanCount = 500
+
 type Client struct {
-	client *redis.Client
+	client redis.UniversalClient
 	log    *slog.Logger
+	format *codec.Format
 }
 
-func NewClient(client *redis.Client, logger *slog.Logger) (*Client, error) {
+// NewClient не проверяет соединение: сервис должен стартовать и без Redis. Доступность
+// проверяется через Ping.
+func NewClient(client redis.UniversalClient, logger *slog.Logger) *Client {
 	if logger == nil {
 		logger = slog.Default()
 	}
-	if err := client.Ping(context.Background()).Err(); err != nil {
-		logger.Error("redis ping failed", slog.Any("error", err))
-		return nil, fmt.Errorf("failed to ping redis: %w", err)
-	}
-	logger.Info("redis client initialized successfully")
+	format, _ := codec.NewFormat(codec.NameJSON, codec.CompressionNone, 0)
 	return &Client{
 		client: client,
 		log:    logger,
-	}, nil
+		format: format,
+	}
+}
+
+// SetFormat меняет кодек и сжатие для новых записей. Уже записанные значения читаются
+// форматом из их заголовка.
+func (c *Client) SetFormat(format *codec.Format) {
+	c.format = format
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
 }
 
 func (c *Client) Get(ctx context.Context, key string, destination any) error {
 	const op = "rediscache.Client.Get"
 	start := time.Now()
 
-	val, err := c.client.Get(ctx, key).Result()
+	val, err := c.client.Get(ctx, key).Bytes()
 
 	dur := time.Since(start)
 
@@ -50,13 +70,15 @@ func (c *Client) Get(ctx context.Context, key string, destination any) error {
 		return fmt.Errorf("failed to get from cache: %w", err)
 	}
 
-	return json.Unmarshal([]byte(val), destination)
-
+	if err := c.format.Decode(val, destination); err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrInvalidValue, err)
+	}
+	return nil
 }
 
 func (c *Client) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
 	const op = "rediscache.Client.Set"
-	data, err := json.Marshal(value)
+	data, err := c.format.Encode(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal value: %w", err)
 	}
@@ -79,3 +101,169 @@ func (c *Client) Delete(ctx context.Context, key string) error {
 	c.log.Info(op, "key", slog.String("key", key), "duration", dur)
 	return err
 }
+
+// Scan обходит ключи по шаблону match командой SCAN (в отличие от KEYS, она не блокирует
+// Redis) и передает их в fn пачками примерно по count. В кластере мастера обходятся
+// параллельно, но fn вызывается последовательно. Один ключ может прийти больше одного раза.
+func (c *Client) Scan(ctx context.Context, match string, count int64, fn func(keys []string) error) error {
+	var mu sync.Mutex
+	return c.forEachNode(ctx, func(ctx context.Context, node redis.Cmdable) error {
+		return scanNode(ctx, node, match, count, func(keys []string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return fn(keys)
+		})
+	})
+}
+
+// DeleteByPattern удаляет ключи по шаблону пачками: SCAN и UNLINK на каждом узле, так что
+// ключи разных слотов кластера не смешиваются в одной команде.
+func (c *Client) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	const op = "rediscache.Client.DeleteByPattern"
+	start := time.Now()
+
+	var deleted atomic.Int64
+	err := c.forEachNode(ctx, func(ctx context.Context, node redis.Cmdable) error {
+		return scanNode(ctx, node, pattern, deleteScanCount, func(keys []string) error {
+			n, err := node.Unlink(ctx, keys...).Result()
+			if err != nil {
+				return fmt.Errorf("failed to delete keys: %w", err)
+			}
+			deleted.Add(n)
+			return nil
+		})
+	})
+
+	c.log.Info(op, slog.String("pattern", pattern), "deleted", deleted.Load(), "duration", time.Since(start))
+	return deleted.Load(), err
+}
+
+// Stats суммирует DBSIZE и INFO по всем мастерам. Hits и Misses — keyspace_hits и
+// keyspace_misses Redis: они считаются с последнего рестарта или CONFIG RESETSTAT и
+// включают чтения всех клиентов базы, а не только этого сервиса.
+func (c *Client) Stats(ctx context.Context) (cache.Stats, error) {
+	var (
+		mu    sync.Mutex
+		stats cache.Stats
+	)
+	err := c.forEachNode(ctx, func(ctx context.Context, node redis.Cmdable) error {
+		keys, err := node.DBSize(ctx).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get dbsize: %w", err)
+		}
+		info, err := node.Info(ctx, "memory", "stats").Result()
+		if err != nil {
+			return fmt.Errorf("failed to get info: %w", err)
+		}
+		fields := parseInfo(info)
+
+		mu.Lock()
+		defer mu.Unlock()
+		stats.Keys += keys
+		stats.MemoryBytes += fields["used_memory"]
+		stats.Hits += fields["keyspace_hits"]
+		stats.Misses += fields["keyspace_misses"]
+		return nil
+	})
+	if err != nil {
+		return cache.Stats{}, err
+	}
+	return stats, nil
+}
+
+// forEachNode вызывает fn для сервера Redis, а в кластере — параллельно для каждого мастера.
+func (c *Client) forEachNode(ctx context.Context, fn func(ctx context.Context, node redis.Cmdable) error) error {
+	cluster, ok := c.client.(*redis.ClusterClient)
+	if !ok {
+		return fn(ctx, c.client)
+	}
+	return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		return fn(ctx, node)
+	})
+}
+
+// parseInfo разбирает числовые поля ответа INFO («name:value» по строке).
+func parseInfo(info string) map[string]int64 {
+	fields := make(map[string]int64)
+	for _, line := range strings.Split(info, "\n") {
+		name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			fields[name] = n
+		}
+	}
+	return fields
+}
+
+func scanNode(ctx context.Context, client redis.Cmdable, match string, count int64, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, match, count).Result()
+		if err != nil {
+			return fmt.Errorf("failed to scan keys: %w", err)
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// InvalidationBus рассылает сообщения об инвалидации кэша между инстансами через Redis pub/sub.
+type InvalidationBus struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+func NewInvalidationBus(client redis.UniversalClient, channel string) *InvalidationBus {
+	return &InvalidationBus{
+		client:  client,
+		channel: channel,
+	}
+}
+
+func (b *InvalidationBus) Publish(ctx context.Context, message string) error {
+	return b.client.Publish(ctx, b.channel, message).Err()
+}
+
+// Subscribe подписывается на канал и отдает сообщения, пока не отменен ctx. После
+// обрыва соединения go-redis переподписывается сам.
+func (b *InvalidationBus) Subscribe(ctx context.Context) (<-chan string, error) {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", b.channel, err)
+	}
+
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case out <- msg.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
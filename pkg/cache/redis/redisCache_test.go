@@ -0,0 +1,19 @@
+package rediscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInfo(t *testing.T) {
+	info := "# Memory\r\nused_memory:1048576\r\nused_memory_human:1.00M\r\n\r\n" +
+		"# Stats\r\nkeyspace_hits:30\r\nkeyspace_misses:10\r\n"
+
+	fields := parseInfo(info)
+
+	assert.Equal(t, int64(1048576), fields["used_memory"])
+	assert.Equal(t, int64(30), fields["keyspace_hits"])
+	assert.Equal(t, int64(10), fields["keyspace_misses"])
+	assert.NotContains(t, fields, "used_memory_human")
+}
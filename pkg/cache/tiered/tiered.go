@@ -0,0 +1,189 @@
+// Package tieredcache — двухуровневый кэш: локальный LRU в памяти процесса перед общим
+// (Redis). Изменения ключей рассылаются другим инстансам, чтобы они сбросили локальные копии.
+package tieredcache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhavkk/order-service/pkg/cache"
+	lrucache "github.com/zhavkk/order-service/pkg/cache/lru"
+	prometheusmetrics "github.com/zhavkk/order-service/pkg/metrics/prometheus"
+)
+
+const (
+	TierLocal  = "local"
+	TierRemote = "redis"
+
+	resubscribeDelay = time.Second
+	// patternPrefix отличает в рассылке шаблон ключей от отдельного ключа.
+	patternPrefix = "pattern:"
+)
+
+// Bus — канал рассылки инвалидаций между инстансами.
+type Bus interface {
+	Publish(ctx context.Context, message string) error
+	Subscribe(ctx context.Context) (<-chan string, error)
+}
+
+type Cache struct {
+	local      *lrucache.Cache
+	remote     cache.Cache
+	bus        Bus
+	instanceID string
+	log        *slog.Logger
+
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+func New(local *lrucache.Cache, remote cache.Cache, bus Bus, logger *slog.Logger) *Cache {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Cache{
+		local:      local,
+		remote:     remote,
+		bus:        bus,
+		instanceID: newInstanceID(),
+		log:        logger,
+		stopped:    make(chan struct{}),
+	}
+}
+
+func (c *Cache) Get(ctx context.Context, key string, destination any) error {
+	if err := c.local.Get(ctx, key, destination); err == nil {
+		prometheusmetrics.CacheRequestsTotal.WithLabelValues(TierLocal, "hit").Inc()
+		return nil
+	}
+	prometheusmetrics.CacheRequestsTotal.WithLabelValues(TierLocal, "miss").Inc()
+
+	err := c.remote.Get(ctx, key, destination)
+	switch {
+	case err == nil:
+		prometheusmetrics.CacheRequestsTotal.WithLabelValues(TierRemote, "hit").Inc()
+	case errors.Is(err, cache.ErrCacheMiss):
+		prometheusmetrics.CacheRequestsTotal.WithLabelValues(TierRemote, "miss").Inc()
+		return err
+	default:
+		prometheusmetrics.CacheRequestsTotal.WithLabelValues(TierRemote, "error").Inc()
+		return err
+	}
+
+	// Прогреваем локальный уровень; TTL ограничен TTL локального кэша.
+	_ = c.local.Set(ctx, key, reflect.ValueOf(destination).Elem().Interface(), 0)
+	return nil
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	if err := c.remote.Set(ctx, key, value, ttl); err != nil {
+		_ = c.local.Delete(ctx, key)
+		return err
+	}
+	_ = c.local.Set(ctx, key, value, ttl)
+	c.broadcast(ctx, key)
+	return nil
+}
+
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	_ = c.local.Delete(ctx, key)
+	err := c.remote.Delete(ctx, key)
+	c.broadcast(ctx, key)
+	return err
+}
+
+// DeleteByPattern удаляет ключи по шаблону на всех уровнях и у остальных инстансов.
+// Возвращает число ключей, удаленных из общего кэша.
+func (c *Cache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	if _, err := c.local.DeleteByPattern(ctx, pattern); err != nil {
+		return 0, err
+	}
+	deleted, err := c.remote.DeleteByPattern(ctx, pattern)
+	c.broadcast(ctx, patternPrefix+pattern)
+	return deleted, err
+}
+
+// Stats возвращает статистику общего кэша с локальным уровнем этого инстанса в Local.
+// Если общий кэш недоступен, возвращается только Local, а причина — в RemoteError.
+func (c *Cache) Stats(ctx context.Context) (cache.Stats, error) {
+	stats, err := c.remote.Stats(ctx)
+	if err != nil {
+		c.log.Warn("failed to get shared cache stats", slog.Any("error", err))
+		stats = cache.Stats{RemoteError: err.Error()}
+	}
+	local, err := c.local.Stats(ctx)
+	if err != nil {
+		return cache.Stats{}, err
+	}
+	stats.Local = &local
+	return stats, nil
+}
+
+// broadcast просит остальные инстансы сбросить локальную копию ключа. Ошибка рассылки
+// не фатальна: чужие копии устареют не позже TTL локального кэша.
+func (c *Cache) broadcast(ctx context.Context, key string) {
+	if err := c.bus.Publish(ctx, c.instanceID+" "+key); err != nil {
+		c.log.Warn("failed to broadcast cache invalidation", slog.String("key", key), slog.Any("error", err))
+	}
+}
+
+// Run слушает инвалидации от других инстансов до Close или отмены ctx.
+func (c *Cache) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stopped:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		messages, err := c.bus.Subscribe(ctx)
+		if err != nil {
+			c.log.Warn("failed to subscribe to cache invalidations", slog.Any("error", err))
+		} else {
+			c.listen(ctx, messages)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+func (c *Cache) listen(ctx context.Context, messages <-chan string) {
+	for message := range messages {
+		instanceID, key, ok := strings.Cut(message, " ")
+		if !ok || instanceID == c.instanceID {
+			continue
+		}
+		if pattern, ok := strings.CutPrefix(key, patternPrefix); ok {
+			_, _ = c.local.DeleteByPattern(ctx, pattern)
+		} else {
+			_ = c.local.Delete(ctx, key)
+		}
+		prometheusmetrics.CacheInvalidationsReceivedTotal.Inc()
+	}
+}
+
+func (c *Cache) Close() error {
+	c.stopOnce.Do(func() { close(c.stopped) })
+	return nil
+}
+
+func newInstanceID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
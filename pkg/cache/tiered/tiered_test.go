@@ -0,0 +1,158 @@
+package tieredcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/pkg/cache"
+	lrucache "github.com/zhavkk/order-service/pkg/cache/lru"
+)
+
+// memoryBus доставляет сообщения всем подписчикам, как канал Redis pub/sub.
+type memoryBus struct {
+	mu          sync.Mutex
+	subscribers []chan string
+}
+
+func (b *memoryBus) Publish(_ context.Context, message string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, ch := range b.subscribers {
+		ch <- message
+	}
+	return nil
+}
+
+func (b *memoryBus) Subscribe(ctx context.Context) (<-chan string, error) {
+	ch := make(chan string, 16)
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, ch)
+	b.mu.Unlock()
+
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-ch:
+				out <- msg
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (b *memoryBus) subscribed() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+func TestCache_ReadThroughLocal(t *testing.T) {
+	ctx := context.Background()
+	remote := lrucache.New(10, time.Minute)
+	local := lrucache.New(10, time.Minute)
+	c := New(local, remote, &memoryBus{}, nil)
+
+	require.NoError(t, remote.Set(ctx, "order:1", "v1", time.Minute))
+
+	var got string
+	require.NoError(t, c.Get(ctx, "order:1", &got))
+	assert.Equal(t, "v1", got)
+	assert.Equal(t, 1, local.Len(), "remote hit warms the local tier")
+
+	require.NoError(t, remote.Delete(ctx, "order:1"))
+	require.NoError(t, c.Get(ctx, "order:1", &got), "served from the local tier")
+
+	require.NoError(t, c.Delete(ctx, "order:1"))
+	assert.ErrorIs(t, c.Get(ctx, "order:1", &got), cache.ErrCacheMiss)
+}
+
+func TestCache_InvalidationBroadcast(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := &memoryBus{}
+	remote := lrucache.New(10, time.Minute)
+	localA, localB := lrucache.New(10, time.Minute), lrucache.New(10, time.Minute)
+	a := New(localA, remote, bus, nil)
+	b := New(localB, remote, bus, nil)
+	go func() { _ = a.Run(ctx) }()
+	go func() { _ = b.Run(ctx) }()
+	require.Eventually(t, func() bool { return bus.subscribed() == 2 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, a.Set(ctx, "order:1", "v1", time.Minute))
+	var got string
+	require.NoError(t, b.Get(ctx, "order:1", &got))
+	require.Equal(t, 1, localB.Len())
+
+	require.NoError(t, a.Set(ctx, "order:1", "v2", time.Minute))
+
+	require.Eventually(t, func() bool { return localB.Len() == 0 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, localA.Len(), "an instance ignores its own broadcasts")
+	require.NoError(t, b.Get(ctx, "order:1", &got))
+	assert.Equal(t, "v2", got)
+}
+
+func TestCache_DeleteByPatternBroadcast(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := &memoryBus{}
+	remote := lrucache.New(10, time.Minute)
+	localA, localB := lrucache.New(10, time.Minute), lrucache.New(10, time.Minute)
+	a := New(localA, remote, bus, nil)
+	b := New(localB, remote, bus, nil)
+	go func() { _ = b.Run(ctx) }()
+	require.Eventually(t, func() bool { return bus.subscribed() == 1 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, remote.Set(ctx, "order:1", "v1", time.Minute))
+	require.NoError(t, remote.Set(ctx, "other", "v", time.Minute))
+	var got string
+	require.NoError(t, b.Get(ctx, "order:1", &got))
+	require.NoError(t, b.Get(ctx, "other", &got))
+	require.Equal(t, 2, localB.Len())
+
+	deleted, err := a.DeleteByPattern(ctx, "order:*")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	require.Eventually(t, func() bool { return localB.Len() == 1 }, time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, b.Get(ctx, "order:1", &got), cache.ErrCacheMiss)
+
+	stats, err := b.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Keys)
+	require.NotNil(t, stats.Local)
+	assert.Equal(t, int64(1), stats.Local.Keys)
+}
+
+// unavailableStats имитирует общий кэш, у которого не получается прочитать статистику.
+type unavailableStats struct {
+	*lrucache.Cache
+}
+
+func (unavailableStats) Stats(context.Context) (cache.Stats, error) {
+	return cache.Stats{}, errors.New("cache is unavailable")
+}
+
+func TestCache_StatsWithoutRemote(t *testing.T) {
+	ctx := context.Background()
+	local := lrucache.New(10, time.Minute)
+	c := New(local, unavailableStats{lrucache.New(10, time.Minute)}, &memoryBus{}, nil)
+	require.NoError(t, local.Set(ctx, "order:1", "v1", time.Minute))
+
+	stats, err := c.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "cache is unavailable", stats.RemoteError)
+	assert.Zero(t, stats.Keys)
+	require.NotNil(t, stats.Local)
+	assert.Equal(t, int64(1), stats.Local.Keys)
+}
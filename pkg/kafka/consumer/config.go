@@ -1,6 +1,8 @@
 package kafkapkg
 
 import (
+	"fmt"
+
 	"github.com/IBM/sarama"
 	"github.com/zhavkk/order-service/internal/config"
 )
@@ -16,8 +18,18 @@ func NewSaramaConfig(conf *config.Config) (*sarama.Config, error) {
 
 	cfg.Consumer.Group.Rebalance.Strategy = sarama.NewBalanceStrategyRoundRobin()
 	cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
-	cfg.Consumer.Offsets.AutoCommit.Enable = true
-	cfg.Consumer.Offsets.AutoCommit.Interval = conf.Kafka.AutoCommitInterval
+
+	switch conf.Kafka.CommitMode {
+	case config.CommitModeAuto, "":
+		cfg.Consumer.Offsets.AutoCommit.Enable = true
+		cfg.Consumer.Offsets.AutoCommit.Interval = conf.Kafka.AutoCommitInterval
+	case config.CommitModeManual:
+		// Оффсеты коммитит сам консьюмер: интервал используется для батчинга коммитов.
+		cfg.Consumer.Offsets.AutoCommit.Enable = false
+		cfg.Consumer.Offsets.AutoCommit.Interval = conf.Kafka.CommitInterval
+	default:
+		return nil, fmt.Errorf("unknown kafka commit mode: %q", conf.Kafka.CommitMode)
+	}
 
 	return cfg, nil
 }
@@ -0,0 +1,24 @@
+package kafkaproducer
+
+import (
+	"github.com/IBM/sarama"
+	"github.com/zhavkk/order-service/internal/config"
+)
+
+func NewSaramaConfig(conf *config.Config) (*sarama.Config, error) {
+	cfg := sarama.NewConfig()
+
+	version, err := sarama.ParseKafkaVersion(conf.Kafka.Version)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Version = version
+
+	cfg.Producer.RequiredAcks = sarama.WaitForAll
+	cfg.Producer.Retry.Max = conf.Kafka.Retries
+	cfg.Producer.Retry.Backoff = conf.Kafka.Backoff
+	cfg.Producer.Return.Successes = true
+	cfg.Producer.Return.Errors = true
+
+	return cfg, nil
+}
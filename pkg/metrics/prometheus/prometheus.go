@@ -47,6 +47,119 @@ var (
 		},
 		[]string{"status"},
 	)
+
+	OrdersSubmittedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "orders_submitted_total",
+			Help: "Total number of orders submitted over HTTP by status (accepted, rejected, failed)",
+		},
+		[]string{"status"},
+	)
+
+	OrderUpsertsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "order_upserts_total",
+			Help: "Total number of order writes by result (created, updated, replay, rejected)",
+		},
+		[]string{"result"},
+	)
+
+	OrderValidationViolationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "order_validation_violations_total",
+			Help: "Total number of order business rule violations by rule and mode",
+		},
+		[]string{"rule", "mode"},
+	)
+
+	OrderBatchFallbacksTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "order_batch_fallbacks_total",
+			Help: "Total number of order batches that fell back to per-order processing",
+		},
+	)
+
+	DLQMessagesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dlq_messages_total",
+			Help: "Total number of messages published to the dead-letter topic",
+		},
+		[]string{"source_topic", "status"},
+	)
+
+	OutboxEventsPublishedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_events_published_total",
+			Help: "Total number of outbox events relayed to Kafka",
+		},
+		[]string{"status"},
+	)
+
+	CacheRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "Total number of cache lookups by tier (local, redis) and result (hit, miss, error)",
+		},
+		[]string{"tier", "result"},
+	)
+
+	CacheInvalidationsReceivedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "cache_invalidations_received_total",
+			Help: "Total number of local cache invalidations received from other instances",
+		},
+	)
+
+	CacheDegraded = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cache_degraded",
+			Help: "1 while the shared cache is unavailable and the service runs without it",
+		},
+	)
+
+	OrderLookupsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "order_lookups_total",
+			Help: "Total number of order lookups by result (cache_hit, negative_hit, loaded, not_found, coalesced, early_refresh)",
+		},
+		[]string{"result"},
+	)
+
+	CacheWarmUpOrders = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cache_warmup_orders",
+			Help: "Number of orders in the startup cache warm-up by state (planned, cached, failed)",
+		},
+		[]string{"state"},
+	)
+
+	CacheWarmUpProgressRatio = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cache_warmup_progress_ratio",
+			Help: "Share of planned warm-up orders already processed (cached or failed)",
+		},
+	)
+
+	AccessCountsDroppedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "order_access_counts_dropped_total",
+			Help: "Number of order reads dropped from the access counts buffer because it was full",
+		},
+	)
+
+	OutboxPendingEvents = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "outbox_pending_events",
+			Help: "Number of outbox events not yet published",
+		},
+	)
+
+	OutboxLagSeconds = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "outbox_lag_seconds",
+			Help: "Age of the oldest unpublished outbox event in seconds",
+		},
+	)
 )
 
 func Init() {
@@ -55,6 +168,21 @@ func Init() {
 	prometheus.MustRegister(HTTPRequestErrors)
 	prometheus.MustRegister(OrdersCreatedTotal)
 	prometheus.MustRegister(MessageProcessedTotal)
+	prometheus.MustRegister(OrdersSubmittedTotal)
+	prometheus.MustRegister(OrderUpsertsTotal)
+	prometheus.MustRegister(OrderValidationViolationsTotal)
+	prometheus.MustRegister(OrderBatchFallbacksTotal)
+	prometheus.MustRegister(DLQMessagesTotal)
+	prometheus.MustRegister(OutboxEventsPublishedTotal)
+	prometheus.MustRegister(CacheRequestsTotal)
+	prometheus.MustRegister(CacheInvalidationsReceivedTotal)
+	prometheus.MustRegister(CacheDegraded)
+	prometheus.MustRegister(OrderLookupsTotal)
+	prometheus.MustRegister(CacheWarmUpOrders)
+	prometheus.MustRegister(CacheWarmUpProgressRatio)
+	prometheus.MustRegister(AccessCountsDroppedTotal)
+	prometheus.MustRegister(OutboxPendingEvents)
+	prometheus.MustRegister(OutboxLagSeconds)
 }
 
 func Handler() http.Handler {
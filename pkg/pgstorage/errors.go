@@ -0,0 +1,59 @@
+package pgstorage
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/zhavkk/order-service/internal/apperrors"
+)
+
+const (
+	pgUniqueViolation      = "23505"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgAdminShutdown        = "57P01"
+	pgCannotConnectNow     = "57P03"
+)
+
+// ClassifyError приводит ошибку драйвера к таксономии apperrors.
+// Неизвестные ошибки возвращаются без изменений.
+func ClassifyError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		class := pgErr.Code[:2]
+		switch {
+		case pgErr.Code == pgUniqueViolation:
+			return apperrors.Conflict(op+": "+pgErr.ConstraintName, err)
+		case pgErr.Code == pgSerializationFailure,
+			pgErr.Code == pgDeadlockDetected,
+			pgErr.Code == pgAdminShutdown,
+			pgErr.Code == pgCannotConnectNow:
+			return apperrors.Transient(op, err)
+		case class == "23":
+			return apperrors.Invalid(op+": "+pgErr.ConstraintName, err)
+		case class == "08", class == "53":
+			return apperrors.Transient(op, err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if pgconn.SafeToRetry(err) || pgconn.Timeout(err) || errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return apperrors.Transient(op, err)
+	}
+
+	return err
+}
+
+// IsRetryable сообщает, имеет ли смысл повторить операцию: serialization_failure,
+// deadlock_detected, ошибки соединения и уже классифицированные временные ошибки.
+func IsRetryable(err error) bool {
+	return apperrors.IsTransient(ClassifyError("", err))
+}
@@ -0,0 +1,46 @@
+package pgstorage
+
+import (
+	"context"
+	"sync"
+)
+
+type hooksKey struct{}
+
+type commitHooks struct {
+	mu  sync.Mutex
+	fns []func(ctx context.Context)
+}
+
+// AfterCommit регистрирует fn, которая выполнится только после успешного коммита транзакции
+// из ctx. Если транзакция откатилась или будет перезапущена, fn не вызывается. Вне
+// транзакции fn выполняется сразу. Хуки выполняются по порядку регистрации с контекстом
+// без транзакции, поэтому обращения к базе из них идут мимо уже закрытой транзакции.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	hooks, ok := ctx.Value(hooksKey{}).(*commitHooks)
+	if !ok {
+		fn(ctx)
+		return
+	}
+	hooks.mu.Lock()
+	hooks.fns = append(hooks.fns, fn)
+	hooks.mu.Unlock()
+}
+
+// WithCommitHooks возвращает контекст, в котором AfterCommit откладывает хуки, и функцию,
+// которая их выполняет. Нужна реализациям TxManagerInterface, в том числе тестовым:
+// commit вызывается только после успешного коммита.
+func WithCommitHooks(ctx context.Context) (context.Context, func(ctx context.Context)) {
+	hooks := &commitHooks{}
+	commit := func(ctx context.Context) {
+		hooks.mu.Lock()
+		fns := hooks.fns
+		hooks.fns = nil
+		hooks.mu.Unlock()
+
+		for _, fn := range fns {
+			fn(ctx)
+		}
+	}
+	return context.WithValue(ctx, hooksKey{}, hooks), commit
+}
@@ -0,0 +1,35 @@
+package pgstorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAfterCommit(t *testing.T) {
+	t.Run("outside transaction runs immediately", func(t *testing.T) {
+		called := false
+		AfterCommit(context.Background(), func(context.Context) { called = true })
+		assert.True(t, called)
+	})
+
+	t.Run("runs in registration order after commit", func(t *testing.T) {
+		ctx, commit := WithCommitHooks(context.Background())
+		var calls []int
+		AfterCommit(ctx, func(context.Context) { calls = append(calls, 1) })
+		AfterCommit(ctx, func(context.Context) { calls = append(calls, 2) })
+		assert.Empty(t, calls)
+
+		commit(context.Background())
+		assert.Equal(t, []int{1, 2}, calls)
+
+		commit(context.Background())
+		assert.Equal(t, []int{1, 2}, calls, "hooks run once")
+	})
+
+	t.Run("not committed", func(t *testing.T) {
+		ctx, _ := WithCommitHooks(context.Background())
+		AfterCommit(ctx, func(context.Context) { t.Fatal("hook must not run without commit") })
+	})
+}
@@ -3,20 +3,24 @@ package pgstorage
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/zhavkk/order-service/internal/config"
+	"github.com/zhavkk/order-service/pkg/utils"
 )
 
 type TxManagerInterface interface {
 	RunSerializable(ctx context.Context, f func(ctx context.Context) error) error
+	RunSerializableWithRetry(ctx context.Context, f func(ctx context.Context) error) error
 	RunReadUncommited(ctx context.Context, f func(context.Context) error) error
 	RunReadCommited(ctx context.Context, f func(context.Context) error) error
 	RunRepeatableRead(ctx context.Context, f func(context.Context) error) error
 }
 
 type TxManager struct {
-	db *Storage
+	db    *Storage
+	retry utils.RetryPolicy
 }
 
 func NewTxManager(ctx context.Context, cfg *config.Config) (*TxManager, error) {
@@ -24,13 +28,20 @@ func NewTxManager(ctx context.Context, cfg *config.Config) (*TxManager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("NewTxManager: %w", ErrFailedToConnectToDB)
 	}
-	return &TxManager{db: db}, nil
+	return &TxManager{
+		db:    db,
+		retry: utils.NewRetryPolicy(cfg.Postgres.Retries, cfg.Postgres.Backoff, cfg.Postgres.MaxBackoff, IsRetryable),
+	}, nil
 }
 
 func (m *TxManager) GetDatabase() *Storage {
 	return m.db
 }
 
+func (m *TxManager) Close() error {
+	return m.db.Close()
+}
+
 func (m *TxManager) RunSerializable(ctx context.Context, f func(context.Context) error) error {
 	opts := pgx.TxOptions{
 		IsoLevel:   pgx.Serializable,
@@ -39,6 +50,15 @@ func (m *TxManager) RunSerializable(ctx context.Context, f func(context.Context)
 	return m.beginFunc(ctx, opts, f)
 }
 
+// RunSerializableWithRetry перезапускает всю транзакцию целиком при serialization_failure,
+// deadlock_detected и ошибках соединения. f должна быть идемпотентной: после ошибки
+// транзакция уже прервана, поэтому повторять отдельные запросы внутри нее бессмысленно.
+func (m *TxManager) RunSerializableWithRetry(ctx context.Context, f func(context.Context) error) error {
+	return m.retry.Do(ctx, func(ctx context.Context) error {
+		return m.RunSerializable(ctx, f)
+	})
+}
+
 func (m *TxManager) RunReadUncommited(ctx context.Context, f func(context.Context) error) error {
 	opts := pgx.TxOptions{
 		IsoLevel:   pgx.ReadUncommitted,
@@ -63,22 +83,28 @@ func (m *TxManager) RunRepeatableRead(ctx context.Context, f func(context.Contex
 	return m.beginFunc(ctx, opts, f)
 }
 func (m *TxManager) beginFunc(ctx context.Context, opts pgx.TxOptions, f func(context.Context) error) error {
+	const op = "TxManager.beginFunc"
+
 	tx, err := m.db.GetPool().BeginTx(ctx, opts)
 	if err != nil {
-		return err
+		return ClassifyError(op, err)
 	}
 
 	defer func() {
 		_ = tx.Rollback(ctx)
 	}()
 
-	ctx = context.WithValue(ctx, txKey{}, tx)
+	txCtx, runHooks := WithCommitHooks(context.WithValue(ctx, txKey{}, tx))
 
-	if err := f(ctx); err != nil {
+	if err := f(txCtx); err != nil {
 		return err
 	}
 
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return ClassifyError(op, err)
+	}
+	runHooks(ctx)
+	return nil
 }
 
 type txKey struct{}
@@ -89,5 +115,8 @@ func GetTxFromContext(ctx context.Context) (pgx.Tx, bool) {
 }
 
 func NewTxManagerForTest(db *Storage) *TxManager {
-	return &TxManager{db: db}
+	return &TxManager{
+		db:    db,
+		retry: utils.NewRetryPolicy(3, 10*time.Millisecond, 100*time.Millisecond, IsRetryable),
+	}
 }
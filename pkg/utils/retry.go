@@ -1,23 +1,106 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"time"
 
 	"github.com/zhavkk/order-service/internal/logger"
 )
 
-// exponential backoff retry mechanism
-func RetryWithBackoff(operation func() error, maxRetries int, initialBackoff time.Duration) error {
-	backoff := initialBackoff
-	for i := 0; i < maxRetries; i++ {
-		if err := operation(); err != nil {
-			logger.Log.Warn("Retrying operation", "attempt", i+1, "error", err)
-			time.Sleep(backoff)
-			backoff *= 2
-			continue
+var ErrRetriesExhausted = errors.New("retries exhausted")
+
+// RetryPolicy — экспоненциальный backoff с full jitter.
+// Повторяются только ошибки, для которых Retryable возвращает true (nil — повторять все).
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Retryable      func(error) bool
+}
+
+func NewRetryPolicy(
+	maxAttempts int,
+	initialBackoff time.Duration,
+	maxBackoff time.Duration,
+	retryable func(error) bool,
+) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     maxBackoff,
+		Retryable:      retryable,
+	}
+}
+
+// Do выполняет operation, пока она не завершится успешно, не вернет неповторяемую ошибку,
+// не закончатся попытки или не будет отменен ctx. Последняя ошибка операции оборачивается,
+// поэтому вызывающий код может проверять ее через errors.Is / errors.As.
+func (p RetryPolicy) Do(ctx context.Context, operation func(ctx context.Context) error) error {
+	if p.MaxAttempts <= 0 {
+		return fmt.Errorf("%w: no attempts allowed", ErrRetriesExhausted)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= p.MaxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return joinCtxErr(err, lastErr)
+		}
+
+		lastErr = operation(ctx)
+		if lastErr == nil {
+			return nil
+		}
+		if p.Retryable != nil && !p.Retryable(lastErr) {
+			return lastErr
+		}
+		if attempt == p.MaxAttempts {
+			break
+		}
+
+		delay := p.Backoff(attempt)
+		logger.Log.Warn("Retrying operation", "attempt", attempt, "delay", delay, "error", lastErr)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return joinCtxErr(ctx.Err(), lastErr)
+		case <-timer.C:
+		}
+	}
+
+	return fmt.Errorf("%w after %d attempts: %w", ErrRetriesExhausted, p.MaxAttempts, lastErr)
+}
+
+// Backoff возвращает задержку перед попыткой attempt+1: случайное значение
+// в диапазоне [0, min(MaxBackoff, InitialBackoff*2^(attempt-1))].
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	ceiling := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		ceiling *= 2
+		if p.MaxBackoff > 0 && ceiling >= p.MaxBackoff {
+			break
 		}
-		return nil
+		if ceiling <= 0 {
+			ceiling = p.MaxBackoff
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && ceiling > p.MaxBackoff {
+		ceiling = p.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
+
+func joinCtxErr(ctxErr, lastErr error) error {
+	if lastErr == nil {
+		return ctxErr
 	}
-	return fmt.Errorf("operation failed after %d retries", maxRetries)
+	return fmt.Errorf("retry aborted: %w", errors.Join(ctxErr, lastErr))
 }
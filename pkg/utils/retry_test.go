@@ -1,28 +1,33 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/zhavkk/order-service/internal/logger"
 )
 
-func TestRetryWithBackoff(t *testing.T) {
+var errPermanent = errors.New("permanent error")
+
+func TestRetryPolicy_Do(t *testing.T) {
 	t.Parallel()
 
-	operationSuccess := func() error {
+	operationSuccess := func(context.Context) error {
 		return nil
 	}
 
-	operationFail := func() error {
+	operationFail := func(context.Context) error {
 		return fmt.Errorf("operation failed")
 	}
 	logger.Init("local")
 
-	operationRetrySuccess := func() func() error {
+	operationRetrySuccess := func() func(context.Context) error {
 		attempts := 0
-		return func() error {
+		return func(context.Context) error {
 			attempts++
 			if attempts < 3 {
 				return fmt.Errorf("temporary error")
@@ -31,38 +36,58 @@ func TestRetryWithBackoff(t *testing.T) {
 		}
 	}()
 
+	operationPermanent := func() func(context.Context) error {
+		attempts := 0
+		return func(context.Context) error {
+			attempts++
+			if attempts > 1 {
+				return nil
+			}
+			return errPermanent
+		}
+	}()
+
+	retryable := func(err error) bool { return !errors.Is(err, errPermanent) }
+
 	tests := []struct {
 		name           string
-		operation      func() error
-		maxRetries     int
+		operation      func(context.Context) error
+		maxAttempts    int
 		initialBackoff time.Duration
 		expectError    bool
 	}{
 		{
 			name:           "Success on first attempt",
 			operation:      operationSuccess,
-			maxRetries:     3,
+			maxAttempts:    3,
 			initialBackoff: 100 * time.Millisecond,
 			expectError:    false,
 		},
 		{
 			name:           "Fail after max retries",
 			operation:      operationFail,
-			maxRetries:     3,
+			maxAttempts:    3,
 			initialBackoff: 100 * time.Millisecond,
 			expectError:    true,
 		},
 		{
 			name:           "Success after retries",
 			operation:      operationRetrySuccess,
-			maxRetries:     5,
+			maxAttempts:    5,
 			initialBackoff: 100 * time.Millisecond,
 			expectError:    false,
 		},
+		{
+			name:           "Non-retryable error is not retried",
+			operation:      operationPermanent,
+			maxAttempts:    3,
+			initialBackoff: 100 * time.Millisecond,
+			expectError:    true,
+		},
 		{
 			name:           "No retries allowed",
 			operation:      operationFail,
-			maxRetries:     0,
+			maxAttempts:    0,
 			initialBackoff: 100 * time.Millisecond,
 			expectError:    true,
 		},
@@ -71,10 +96,53 @@ func TestRetryWithBackoff(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			err := RetryWithBackoff(tt.operation, tt.maxRetries, tt.initialBackoff)
+			policy := NewRetryPolicy(tt.maxAttempts, tt.initialBackoff, time.Second, retryable)
+			err := policy.Do(context.Background(), tt.operation)
 			if (err != nil) != tt.expectError {
-				t.Errorf("RetryWithBackoff() error = %v, expectError %v", err, tt.expectError)
+				t.Errorf("RetryPolicy.Do() error = %v, expectError %v", err, tt.expectError)
 			}
 		})
 	}
 }
+
+func TestRetryPolicy_Do_WrapsLastError(t *testing.T) {
+	logger.Init("local")
+	errDB := errors.New("db is down")
+
+	policy := NewRetryPolicy(3, time.Millisecond, 5*time.Millisecond, nil)
+	err := policy.Do(context.Background(), func(context.Context) error { return errDB })
+
+	assert.ErrorIs(t, err, ErrRetriesExhausted)
+	assert.ErrorIs(t, err, errDB)
+}
+
+func TestRetryPolicy_Do_StopsOnContextCancel(t *testing.T) {
+	logger.Init("local")
+	errDB := errors.New("db is down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	policy := NewRetryPolicy(100, time.Hour, time.Hour, nil)
+	start := time.Now()
+	err := policy.Do(ctx, func(context.Context) error { return errDB })
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, errDB)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := NewRetryPolicy(10, 100*time.Millisecond, 300*time.Millisecond, nil)
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		for i := 0; i < 50; i++ {
+			delay := policy.Backoff(attempt)
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.LessOrEqual(t, delay, 300*time.Millisecond)
+			if attempt == 1 {
+				assert.LessOrEqual(t, delay, 100*time.Millisecond)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zhavkk/order-service/internal/dto"
+	"github.com/zhavkk/order-service/internal/logger"
+	"github.com/zhavkk/order-service/internal/repository/postgres"
+	"github.com/zhavkk/order-service/internal/service"
+	"github.com/zhavkk/order-service/pkg/cache"
+	"github.com/zhavkk/order-service/pkg/pgstorage"
+	"github.com/zhavkk/order-service/pkg/utils"
+)
+
+type noopCache struct{}
+
+func (noopCache) Get(context.Context, string, any) error                 { return nil }
+func (noopCache) Set(context.Context, string, any, time.Duration) error  { return nil }
+func (noopCache) Delete(context.Context, string) error                   { return nil }
+func (noopCache) DeleteByPattern(context.Context, string) (int64, error) { return 0, nil }
+func (noopCache) Stats(context.Context) (cache.Stats, error)             { return cache.Stats{}, nil }
+
+// BenchmarkIngest сравнивает запись заказов по одному (ProcessOrder) и пачками (ProcessOrders).
+// Запуск: go test ./tests/integration -run '^$' -bench BenchmarkIngest -benchtime 2000x
+func BenchmarkIngest(b *testing.B) {
+	logger.Init("prod")
+	ctx, pgContainer, storage, cfg := setupPostgresContainer(b)
+	b.Cleanup(func() {
+		_ = storage.Close()
+		_ = pgContainer.Terminate(ctx)
+	})
+	applyMigrations(b, ctx, storage)
+
+	txManager, err := pgstorage.NewTxManager(ctx, cfg)
+	require.NoError(b, err)
+
+	retry := utils.NewRetryPolicy(cfg.Postgres.Retries, cfg.Postgres.Backoff, cfg.Postgres.MaxBackoff, pgstorage.IsRetryable)
+	orderService := service.NewOrderService(
+		postgres.NewOrderRepository(storage, retry),
+		postgres.NewDeliveryRepository(storage, retry),
+		postgres.NewPaymentRepository(storage, retry),
+		postgres.NewItemRepository(storage, retry),
+		postgres.NewOutboxRepository(storage),
+		postgres.NewHistoryRepository(storage),
+		txManager,
+		noopCache{},
+		time.Minute,
+	)
+
+	b.Run("single", func(b *testing.B) {
+		reqs := generateOrderRequests(b, b.N)
+		b.ResetTimer()
+		for _, req := range reqs {
+			if err := orderService.ProcessOrder(ctx, req); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "orders/s")
+	})
+
+	for _, size := range []int{10, 100, 500} {
+		b.Run("batch_"+strconv.Itoa(size), func(b *testing.B) {
+			reqs := generateOrderRequests(b, b.N)
+			b.ResetTimer()
+			for start := 0; start < len(reqs); start += size {
+				end := min(start+size, len(reqs))
+				for _, err := range orderService.ProcessOrders(ctx, reqs[start:end], nil) {
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "orders/s")
+		})
+	}
+}
+
+func generateOrderRequests(tb testing.TB, n int) []*dto.ProcessOrderRequest {
+	reqs := make([]*dto.ProcessOrderRequest, n)
+	for i := range reqs {
+		data, err := json.Marshal(generateTestOrder())
+		require.NoError(tb, err)
+
+		var in dto.OrderRequest
+		require.NoError(tb, json.Unmarshal(data, &in))
+		reqs[i] = &dto.ProcessOrderRequest{Order: in}
+	}
+	return reqs
+}
@@ -2,8 +2,12 @@ package integration
 
 import (
 	"context"
+	"errors"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -14,11 +18,14 @@ import (
 	"github.com/stretchr/testify/suite"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/zhavkk/order-service/internal/app/outbox"
 	"github.com/zhavkk/order-service/internal/config"
 	"github.com/zhavkk/order-service/internal/logger"
 	"github.com/zhavkk/order-service/internal/models"
 	"github.com/zhavkk/order-service/internal/repository/postgres"
+	"github.com/zhavkk/order-service/internal/service"
 	"github.com/zhavkk/order-service/pkg/pgstorage"
+	"github.com/zhavkk/order-service/pkg/utils"
 )
 
 type RepositorySuite struct {
@@ -31,6 +38,10 @@ type RepositorySuite struct {
 	deliveryRepo *postgres.DeliveryRepository
 	paymentRepo  *postgres.PaymentRepository
 	itemRepo     *postgres.ItemRepository
+	outboxRepo   *postgres.OutboxRepository
+	historyRepo  *postgres.HistoryRepository
+	accessRepo   *postgres.AccessRepository
+	idemRepo     *postgres.IdempotencyRepository
 }
 
 func (s *RepositorySuite) SetupSuite() {
@@ -43,25 +54,21 @@ func (s *RepositorySuite) SetupSuite() {
 	s.txManager, err = pgstorage.NewTxManager(ctx, cfg)
 	require.NoError(s.T(), err)
 
-	s.orderRepo = postgres.NewOrderRepository(storage, cfg.Postgres.Retries, cfg.Postgres.Backoff)
-	s.deliveryRepo = postgres.NewDeliveryRepository(storage, cfg.Postgres.Retries, cfg.Postgres.Backoff)
-	s.paymentRepo = postgres.NewPaymentRepository(storage, cfg.Postgres.Retries, cfg.Postgres.Backoff)
-	s.itemRepo = postgres.NewItemRepository(storage, cfg.Postgres.Retries, cfg.Postgres.Backoff)
+	retry := utils.NewRetryPolicy(cfg.Postgres.Retries, cfg.Postgres.Backoff, cfg.Postgres.MaxBackoff, pgstorage.IsRetryable)
+	s.orderRepo = postgres.NewOrderRepository(storage, retry)
+	s.deliveryRepo = postgres.NewDeliveryRepository(storage, retry)
+	s.paymentRepo = postgres.NewPaymentRepository(storage, retry)
+	s.itemRepo = postgres.NewItemRepository(storage, retry)
+	s.outboxRepo = postgres.NewOutboxRepository(storage)
+	s.historyRepo = postgres.NewHistoryRepository(storage)
+	s.accessRepo = postgres.NewAccessRepository(storage)
+	s.idemRepo = postgres.NewIdempotencyRepository(storage)
 
-	schemaBytes, err := os.ReadFile("../../migrations/20250803160056_init.sql")
-	require.NoError(s.T(), err)
-	schema := string(schemaBytes)
-	schema = strings.Split(schema, "-- +goose Down")[0]
-	schema = strings.ReplaceAll(schema, "-- +goose Up", "")
-	schema = strings.ReplaceAll(schema, "-- +goose StatementBegin", "")
-	schema = strings.ReplaceAll(schema, "-- +goose StatementEnd", "")
-
-	_, err = s.storage.GetPool().Exec(ctx, schema)
-	require.NoError(s.T(), err, "Failed to apply migration schema")
+	applyMigrations(s.T(), ctx, storage)
 }
 
 func (s *RepositorySuite) SetupTest() {
-	_, err := s.storage.GetPool().Exec(s.ctx, "TRUNCATE TABLE orders, delivery, payments, items RESTART IDENTITY CASCADE")
+	_, err := s.storage.GetPool().Exec(s.ctx, "TRUNCATE TABLE orders, delivery, payments, items, outbox, status_history, order_events, order_access_counts, idempotency_keys RESTART IDENTITY CASCADE")
 	require.NoError(s.T(), err)
 }
 
@@ -97,10 +104,11 @@ func (s *RepositorySuite) TestCreateAndGetOrder() {
 	retrievedOrder, err := s.orderRepo.GetOrderByID(s.ctx, order.OrderUID)
 	s.Require().NoError(err, "Failed to get order by ID")
 	s.Require().NotNil(retrievedOrder)
+	order.Status = models.OrderStatusCreated
 
 	opts := []cmp.Option{
 		cmpopts.EquateApproxTime(time.Second),
-		cmpopts.IgnoreFields(models.Order{}, "DateCreated"),
+		cmpopts.IgnoreFields(models.Order{}, "DateCreated", "UpdatedAt"),
 		cmpopts.IgnoreFields(models.Delivery{}, "ID"),
 		cmpopts.IgnoreFields(models.Item{}, "ID", "OrderID"),
 	}
@@ -108,6 +116,239 @@ func (s *RepositorySuite) TestCreateAndGetOrder() {
 	if diff := cmp.Diff(&order, retrievedOrder, opts...); diff != "" {
 		s.T().Errorf("retrieved order mismatch (-want +got):\n%s", diff)
 	}
+	s.Assert().False(order.UpdatedAt.IsZero())
+	s.Assert().True(order.UpdatedAt.Equal(retrievedOrder.UpdatedAt))
+}
+
+func (s *RepositorySuite) TestCreateOrders() {
+	first, second := generateTestOrder(), generateTestOrder()
+	orders := []*models.Order{&first, &second}
+
+	err := s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		return s.orderRepo.CreateOrders(txCtx, orders)
+	})
+	s.Require().NoError(err)
+
+	for _, order := range orders {
+		stored, err := s.orderRepo.GetOrderByID(s.ctx, order.OrderUID)
+		s.Require().NoError(err)
+		s.Assert().Equal(1, order.Version)
+		s.Assert().False(order.UpdatedAt.IsZero())
+		s.Assert().True(order.UpdatedAt.Equal(stored.UpdatedAt))
+	}
+
+	err = s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		return s.orderRepo.CreateOrders(txCtx, []*models.Order{&first})
+	})
+	s.Assert().ErrorIs(err, postgres.ErrOrderExists)
+}
+
+func (s *RepositorySuite) TestGetOrdersByIDs() {
+	first, second := generateTestOrder(), generateTestOrder()
+	for _, order := range []*models.Order{&first, &second} {
+		order.Delivery.OrderID = order.OrderUID
+		order.Payment.OrderID = order.OrderUID
+		err := s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+			if err := s.orderRepo.CreateOrder(txCtx, order); err != nil {
+				return err
+			}
+			if err := s.deliveryRepo.CreateDelivery(txCtx, &order.Delivery); err != nil {
+				return err
+			}
+			if err := s.paymentRepo.CreatePayment(txCtx, &order.Payment); err != nil {
+				return err
+			}
+			return s.itemRepo.AddItems(txCtx, order.OrderUID, itemsToPointers(order.Items))
+		})
+		s.Require().NoError(err)
+	}
+
+	orders, err := s.orderRepo.GetOrdersByIDs(s.ctx, []string{second.OrderUID, uuid.NewString(), first.OrderUID})
+	s.Require().NoError(err)
+	s.Require().Len(orders, 2)
+	s.Assert().Equal(second.OrderUID, orders[0].OrderUID)
+	s.Assert().Equal(first.OrderUID, orders[1].OrderUID)
+	s.Assert().Equal(second.Payment.Transaction, orders[0].Payment.Transaction)
+	s.Assert().Equal(second.Delivery.Email, orders[0].Delivery.Email)
+	s.Require().Len(orders[1].Items, len(first.Items))
+	s.Assert().Equal(first.Items[0].ChrtID, orders[1].Items[0].ChrtID)
+}
+
+func (s *RepositorySuite) TestUpdateStatus() {
+	order := generateTestOrder()
+	err := s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		return s.orderRepo.CreateOrder(txCtx, &order)
+	})
+	s.Require().NoError(err)
+
+	change := &models.StatusChange{
+		OrderUID:  order.OrderUID,
+		To:        models.OrderStatusPaid,
+		ChangedBy: "billing",
+		Reason:    "payment captured",
+	}
+	err = s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		from, err := s.orderRepo.GetStatusForUpdate(txCtx, order.OrderUID)
+		if err != nil {
+			return err
+		}
+		change.From = from
+		return s.orderRepo.UpdateStatus(txCtx, change)
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal(models.OrderStatusCreated, change.From)
+	s.Assert().NotZero(change.ID)
+
+	retrieved, err := s.orderRepo.GetOrderByID(s.ctx, order.OrderUID)
+	s.Require().NoError(err)
+	s.Assert().Equal(models.OrderStatusPaid, retrieved.Status)
+
+	var changedBy, reason string
+	err = s.storage.GetPool().QueryRow(s.ctx,
+		"SELECT changed_by, reason FROM status_history WHERE order_uid = $1", order.OrderUID,
+	).Scan(&changedBy, &reason)
+	s.Require().NoError(err)
+	s.Assert().Equal("billing", changedBy)
+	s.Assert().Equal("payment captured", reason)
+
+	err = s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		_, err := s.orderRepo.GetStatusForUpdate(txCtx, uuid.NewString())
+		return err
+	})
+	s.Assert().ErrorIs(err, postgres.ErrOrderNotFound)
+}
+
+func (s *RepositorySuite) TestUpdateOrder() {
+	order := generateTestOrder()
+	order.ContentHash = "v1"
+	order.Delivery.OrderID = order.OrderUID
+	err := s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		if err := s.orderRepo.CreateOrder(txCtx, &order); err != nil {
+			return err
+		}
+		return s.deliveryRepo.CreateDelivery(txCtx, &order.Delivery)
+	})
+	s.Require().NoError(err)
+
+	err = s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		return s.orderRepo.CreateOrder(txCtx, &order)
+	})
+	s.Assert().ErrorIs(err, postgres.ErrOrderExists)
+
+	order.TrackNumber = "UPDATED"
+	order.ContentHash = "v2"
+	order.Delivery.City = "Kazan"
+	err = s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		if err := s.orderRepo.UpdateOrder(txCtx, &order, 1); err != nil {
+			return err
+		}
+		return s.deliveryRepo.CreateDelivery(txCtx, &order.Delivery)
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal(2, order.Version)
+
+	version, err := s.orderRepo.GetOrderVersion(s.ctx, order.OrderUID)
+	s.Require().NoError(err)
+	s.Assert().Equal("v2", version.ContentHash)
+	s.Assert().Equal(2, version.Version)
+
+	retrieved, err := s.orderRepo.GetOrderByID(s.ctx, order.OrderUID)
+	s.Require().NoError(err)
+	s.Assert().Equal("UPDATED", retrieved.TrackNumber)
+	s.Assert().Equal("Kazan", retrieved.Delivery.City)
+
+	var deliveries int
+	err = s.storage.GetPool().QueryRow(s.ctx, "SELECT count(*) FROM delivery WHERE order_uid = $1", order.OrderUID).Scan(&deliveries)
+	s.Require().NoError(err)
+	s.Assert().Equal(1, deliveries)
+
+	err = s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		return s.orderRepo.UpdateOrder(txCtx, &order, 1)
+	})
+	s.Assert().ErrorIs(err, postgres.ErrVersionConflict)
+}
+
+func (s *RepositorySuite) TestSetContentHash() {
+	order := generateTestOrder()
+	order.ContentHash = ""
+	err := s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		if err := s.orderRepo.CreateOrder(txCtx, &order); err != nil {
+			return err
+		}
+		return s.orderRepo.SetContentHash(txCtx, order.OrderUID, "v1")
+	})
+	s.Require().NoError(err)
+
+	// Записанный хэш повторно не перезаписывается.
+	err = s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		return s.orderRepo.SetContentHash(txCtx, order.OrderUID, "v2")
+	})
+	s.Require().NoError(err)
+
+	version, err := s.orderRepo.GetOrderVersion(s.ctx, order.OrderUID)
+	s.Require().NoError(err)
+	s.Assert().Equal("v1", version.ContentHash)
+	s.Assert().Equal(1, version.Version)
+}
+
+func (s *RepositorySuite) TestOrderHistory() {
+	orderUID := uuid.NewString()
+	err := s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		return s.historyRepo.AddEvents(txCtx, []*models.OrderHistoryEvent{
+			{OrderUID: orderUID, EventType: "order.created", Actor: "kafka-consumer", Source: "kafka:orders/0/1"},
+		})
+	})
+	s.Require().NoError(err)
+
+	err = s.historyRepo.AddEvents(s.ctx, []*models.OrderHistoryEvent{{
+		OrderUID: orderUID, EventType: "order.updated", Actor: "kafka-consumer", Source: "kafka:orders/0/2",
+		Changes: []models.FieldChange{{Field: "payment.amount", Old: float64(1000), New: float64(1200)}},
+	}})
+	s.Require().NoError(err)
+
+	events, err := s.historyRepo.GetHistory(s.ctx, orderUID)
+	s.Require().NoError(err)
+	s.Require().Len(events, 2)
+	s.Assert().Equal("order.created", events[0].EventType)
+	s.Assert().Empty(events[0].Changes)
+	s.Assert().Equal("kafka:orders/0/2", events[1].Source)
+	s.Assert().Equal([]models.FieldChange{{Field: "payment.amount", Old: float64(1000), New: float64(1200)}}, events[1].Changes)
+
+	_, err = s.storage.GetPool().Exec(s.ctx, "DELETE FROM order_events WHERE order_uid = $1", orderUID)
+	s.Assert().Error(err, "order_events must be append-only")
+}
+
+func (s *RepositorySuite) TestAfterCommitHooks() {
+	committed := generateTestOrder()
+	var seen []string
+	err := s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		if err := s.orderRepo.CreateOrder(txCtx, &committed); err != nil {
+			return err
+		}
+		pgstorage.AfterCommit(txCtx, func(ctx context.Context) {
+			_, inTx := pgstorage.GetTxFromContext(ctx)
+			s.Assert().False(inTx, "hooks run outside the committed transaction")
+			// Хук видит закоммиченные данные.
+			_, err := s.orderRepo.GetOrderVersion(ctx, committed.OrderUID)
+			s.Assert().NoError(err)
+			seen = append(seen, committed.OrderUID)
+		})
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{committed.OrderUID}, seen)
+
+	rolledBack := generateTestOrder()
+	errRollback := errors.New("rollback")
+	err = s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		if err := s.orderRepo.CreateOrder(txCtx, &rolledBack); err != nil {
+			return err
+		}
+		pgstorage.AfterCommit(txCtx, func(context.Context) { seen = append(seen, rolledBack.OrderUID) })
+		return errRollback
+	})
+	s.Require().ErrorIs(err, errRollback)
+	s.Assert().Len(seen, 1, "hooks of a rolled back transaction must not run")
 }
 
 func (s *RepositorySuite) TestGetOrderByID_NotFound() {
@@ -133,6 +374,160 @@ func (s *RepositorySuite) TestGetRecentOrders() {
 	s.Assert().True(recentOrders[0].DateCreated.After(recentOrders[1].DateCreated))
 }
 
+func (s *RepositorySuite) TestGetOrderUIDsCreatedSince() {
+	var uids []string
+	for i := 0; i < 3; i++ {
+		order := generateTestOrder()
+		order.DateCreated = time.Now().Add(-time.Duration(i) * 24 * time.Hour)
+		err := s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+			return s.orderRepo.CreateOrder(txCtx, &order)
+		})
+		s.Require().NoError(err)
+		uids = append(uids, order.OrderUID)
+	}
+
+	got, err := s.orderRepo.GetOrderUIDsCreatedSince(s.ctx, time.Now().Add(-36*time.Hour), 10)
+	s.Require().NoError(err)
+	s.Assert().Equal(uids[:2], got)
+
+	got, err = s.orderRepo.GetRecentOrderUIDs(s.ctx, 1)
+	s.Require().NoError(err)
+	s.Assert().Equal(uids[:1], got)
+}
+
+func (s *RepositorySuite) TestAccessCounts() {
+	now := time.Now().UTC().Truncate(time.Hour)
+	old := now.Add(-48 * time.Hour)
+
+	s.Require().NoError(s.accessRepo.AddAccessCounts(s.ctx, old, map[string]int64{"old": 100}))
+	s.Require().NoError(s.accessRepo.AddAccessCounts(s.ctx, now, map[string]int64{"a": 1, "b": 3}))
+	// Повторная запись в тот же час складывается.
+	s.Require().NoError(s.accessRepo.AddAccessCounts(s.ctx, now, map[string]int64{"a": 5}))
+
+	uids, err := s.accessRepo.GetMostAccessedOrderUIDs(s.ctx, now.Add(-24*time.Hour), 10)
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"a", "b"}, uids)
+
+	deleted, err := s.accessRepo.DeleteAccessCountsBefore(s.ctx, now.Add(-24*time.Hour))
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(1), deleted)
+}
+
+func (s *RepositorySuite) TestIdempotencyKeys() {
+	record := &models.IdempotencyRecord{Key: "key-1", RequestHash: "h1", Response: []byte(`{"accepted":1}`)}
+	s.Require().NoError(s.idemRepo.SaveIdempotencyRecord(s.ctx, record, time.Now().Add(-time.Hour)))
+
+	got, err := s.idemRepo.GetIdempotencyRecord(s.ctx, "key-1", time.Now().Add(-time.Hour))
+	s.Require().NoError(err)
+	s.Assert().Equal("h1", got.RequestHash)
+	s.Assert().JSONEq(`{"accepted":1}`, string(got.Response))
+
+	// Действующий ключ не перезаписывается, истекший — перезаписывается.
+	other := &models.IdempotencyRecord{Key: "key-1", RequestHash: "h2", Response: []byte(`{}`)}
+	s.Require().NoError(s.idemRepo.SaveIdempotencyRecord(s.ctx, other, time.Now().Add(-time.Hour)))
+	got, err = s.idemRepo.GetIdempotencyRecord(s.ctx, "key-1", time.Now().Add(-time.Hour))
+	s.Require().NoError(err)
+	s.Assert().Equal("h1", got.RequestHash)
+
+	s.Require().NoError(s.idemRepo.SaveIdempotencyRecord(s.ctx, other, time.Now().Add(time.Minute)))
+	got, err = s.idemRepo.GetIdempotencyRecord(s.ctx, "key-1", time.Now().Add(-time.Hour))
+	s.Require().NoError(err)
+	s.Assert().Equal("h2", got.RequestHash)
+
+	_, err = s.idemRepo.GetIdempotencyRecord(s.ctx, "key-1", time.Now().Add(time.Minute))
+	s.Assert().ErrorIs(err, postgres.ErrIdempotencyKeyNotFound)
+
+	deleted, err := s.idemRepo.DeleteIdempotencyRecordsBefore(s.ctx, time.Now().Add(time.Minute))
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(1), deleted)
+}
+
+func (s *RepositorySuite) TestListOrders() {
+	customerID := uuid.NewString()
+	base := time.Now().UTC().Truncate(time.Second)
+	for i := 0; i < 3; i++ {
+		order := generateTestOrder()
+		order.CustomerID = customerID
+		order.DateCreated = base.Add(-time.Duration(i) * time.Minute)
+		order.Payment.OrderID = order.OrderUID
+		err := s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+			if err := s.orderRepo.CreateOrder(txCtx, &order); err != nil {
+				return err
+			}
+			return s.paymentRepo.CreatePayment(txCtx, &order.Payment)
+		})
+		s.Require().NoError(err)
+	}
+	other := generateTestOrder()
+	err := s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		return s.orderRepo.CreateOrder(txCtx, &other)
+	})
+	s.Require().NoError(err)
+
+	filter := models.OrderFilter{CustomerID: customerID, Currency: "USD", Limit: 2}
+	page, err := s.orderRepo.ListOrders(s.ctx, filter)
+	s.Require().NoError(err)
+	s.Require().Len(page, 2)
+	s.Assert().True(page[0].DateCreated.After(page[1].DateCreated))
+
+	last := page[1]
+	filter.After = &models.OrderCursor{DateCreated: last.DateCreated, OrderUID: last.OrderUID}
+	page, err = s.orderRepo.ListOrders(s.ctx, filter)
+	s.Require().NoError(err)
+	s.Require().Len(page, 1)
+	s.Assert().Equal(customerID, page[0].CustomerID)
+	s.Assert().True(page[0].DateCreated.Before(last.DateCreated))
+
+	page, err = s.orderRepo.ListOrders(s.ctx, models.OrderFilter{
+		CustomerID:  customerID,
+		CreatedFrom: base.Add(-30 * time.Second),
+		Limit:       10,
+	})
+	s.Require().NoError(err)
+	s.Assert().Len(page, 1)
+}
+
+func (s *RepositorySuite) TestListOrders_NullDateCreated() {
+	customerID := uuid.NewString()
+	base := time.Now().UTC().Truncate(time.Second)
+	var nullUIDs []string
+	for i := 0; i < 4; i++ {
+		order := generateTestOrder()
+		order.CustomerID = customerID
+		order.DateCreated = base.Add(-time.Duration(i) * time.Minute)
+		err := s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+			return s.orderRepo.CreateOrder(txCtx, &order)
+		})
+		s.Require().NoError(err)
+		if i%2 == 1 {
+			_, err = s.storage.GetPool().Exec(s.ctx, `UPDATE orders SET date_created = NULL WHERE order_uid = $1`, order.OrderUID)
+			s.Require().NoError(err)
+			nullUIDs = append(nullUIDs, order.OrderUID)
+		}
+	}
+
+	// Постранично по одному заказу: каждый заказ ровно один раз, без даты — в конце.
+	var seen []*models.Order
+	filter := models.OrderFilter{CustomerID: customerID, Limit: 1}
+	for range 10 {
+		page, err := s.orderRepo.ListOrders(s.ctx, filter)
+		s.Require().NoError(err)
+		if len(page) == 0 {
+			break
+		}
+		last := page[len(page)-1]
+		seen = append(seen, page...)
+		filter.After = &models.OrderCursor{DateCreated: last.DateCreated, OrderUID: last.OrderUID}
+	}
+
+	s.Require().Len(seen, 4)
+	s.Assert().True(seen[0].DateCreated.After(seen[1].DateCreated))
+	s.Assert().True(seen[2].DateCreated.IsZero())
+	s.Assert().True(seen[3].DateCreated.IsZero())
+	s.Assert().ElementsMatch(nullUIDs, []string{seen[2].OrderUID, seen[3].OrderUID})
+	s.Assert().Greater(seen[2].OrderUID, seen[3].OrderUID)
+}
+
 func (s *RepositorySuite) TestIndividualComponentRepos() {
 	order := generateTestOrder()
 
@@ -161,11 +556,104 @@ func (s *RepositorySuite) TestIndividualComponentRepos() {
 	s.Assert().Equal(items[0].ChrtID, retrievedItems[0].ChrtID)
 }
 
+func (s *RepositorySuite) TestRunSerializableWithRetry_ConcurrentDuplicates() {
+	order := generateTestOrder()
+	order.Payment.OrderID = order.OrderUID
+
+	const workers = 2
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.txManager.RunSerializableWithRetry(s.ctx, func(txCtx context.Context) error {
+				if err := s.orderRepo.CreateOrder(txCtx, &order); err != nil {
+					return err
+				}
+				return s.paymentRepo.CreatePayment(txCtx, &order.Payment)
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		s.Assert().ErrorIs(err, postgres.ErrOrderExists)
+	}
+	s.Assert().Equal(1, succeeded)
+
+	var payments int
+	err := s.storage.GetPool().QueryRow(s.ctx, "SELECT count(*) FROM payments WHERE order_uid = $1", order.OrderUID).Scan(&payments)
+	s.Require().NoError(err)
+	s.Assert().Equal(1, payments)
+}
+
+func (s *RepositorySuite) TestOutboxRelay() {
+	order := generateTestOrder()
+
+	err := s.txManager.RunSerializable(s.ctx, func(txCtx context.Context) error {
+		if err := s.orderRepo.CreateOrder(txCtx, &order); err != nil {
+			return err
+		}
+		return s.outboxRepo.AddEvents(txCtx, []*models.OutboxEvent{{
+			AggregateID: order.OrderUID,
+			EventType:   service.EventOrderCreated,
+			Payload:     []byte(`{"order_uid":"` + order.OrderUID + `"}`),
+		}})
+	})
+	s.Require().NoError(err)
+
+	pending, _, err := s.outboxRepo.PendingStats(s.ctx)
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(1), pending)
+
+	publisher := outbox.NewMemoryPublisher()
+	relay := outbox.NewRelay(s.outboxRepo, s.txManager, publisher, "order_events", time.Second, 10)
+
+	sent, err := relay.RelayOnce(s.ctx)
+	s.Require().NoError(err)
+	s.Assert().Equal(1, sent)
+
+	messages := publisher.Messages()
+	s.Require().Len(messages, 1)
+	s.Assert().Equal(order.OrderUID, messages[0].Key)
+	s.Assert().JSONEq(`{"order_uid":"`+order.OrderUID+`"}`, string(messages[0].Value))
+
+	pending, _, err = s.outboxRepo.PendingStats(s.ctx)
+	s.Require().NoError(err)
+	s.Assert().Zero(pending)
+}
+
 func TestStorageSuite(t *testing.T) {
 	suite.Run(t, new(RepositorySuite))
 }
 
-func setupPostgresContainer(t *testing.T) (context.Context, testcontainers.Container, *pgstorage.Storage, *config.Config) {
+func applyMigrations(t testing.TB, ctx context.Context, storage *pgstorage.Storage) {
+	files, err := filepath.Glob("../../migrations/*.sql")
+	require.NoError(t, err)
+	sort.Strings(files)
+
+	for _, file := range files {
+		schemaBytes, err := os.ReadFile(file)
+		require.NoError(t, err)
+		schema := string(schemaBytes)
+		schema = strings.Split(schema, "-- +goose Down")[0]
+		schema = strings.ReplaceAll(schema, "-- +goose Up", "")
+		schema = strings.ReplaceAll(schema, "-- +goose StatementBegin", "")
+		schema = strings.ReplaceAll(schema, "-- +goose StatementEnd", "")
+
+		_, err = storage.GetPool().Exec(ctx, schema)
+		require.NoError(t, err, "Failed to apply migration schema %s", file)
+	}
+}
+
+func setupPostgresContainer(t testing.TB) (context.Context, testcontainers.Container, *pgstorage.Storage, *config.Config) {
 	ctx := context.Background()
 
 	req := testcontainers.ContainerRequest{
@@ -201,6 +689,7 @@ func setupPostgresContainer(t *testing.T) (context.Context, testcontainers.Conta
 	}
 	cfg.Postgres.Retries = 3
 	cfg.Postgres.Backoff = time.Millisecond * 200
+	cfg.Postgres.MaxBackoff = time.Second
 
 	storage, err := pgstorage.NewStorage(ctx, cfg)
 	require.NoError(t, err)